@@ -1,9 +1,11 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,9 +17,24 @@ import (
 )
 
 type LLMAdapter struct {
-	cfg    *config.Config
-	client *http.Client
-	mcpURL string
+	cfg      *config.Config
+	client   *http.Client
+	mcpURL   string
+	provider Provider
+
+	// timeout bounds each call whose context carries no deadline of its own
+	timeout time.Duration
+
+	// maxRetries is the number of extra attempts Chat makes on transient failures
+	maxRetries int
+	// retryBaseDelay is the first backoff delay; it doubles on each retry
+	retryBaseDelay time.Duration
+
+	// maxIterations caps the number of chat rounds Run makes before giving up
+	maxIterations int
+
+	// toolRoutes maps a qualified tool name to its gateway worker, filled by FetchToolSchema
+	toolRoutes map[string]string
 }
 
 type ChatRequest struct {
@@ -28,9 +45,11 @@ type ChatRequest struct {
 }
 
 type Message struct {
-	Role      string         `json:"role"`
-	Content   string         `json:"content"`
-	ToolCalls []ToolResponse `json:"tool_calls,omitempty"`
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	ToolCalls  []ToolResponse `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+	IsError    bool           `json:"is_error,omitempty"`
 }
 
 type ToolCall struct {
@@ -71,15 +90,82 @@ type ToolResult struct {
 	IsError    bool   `json:"is_error,omitempty"`
 }
 
-func NewLLMAdapter(cfg *config.Config, mcpURL string) *LLMAdapter {
+// newToolErrorResult wraps a failed tool call as {"error": true, "message": ...}
+// so the model sees a structured failure instead of free text
+func newToolErrorResult(toolCallID string, err error) ToolResult {
+	content, _ := json.Marshal(map[string]any{
+		"error":   true,
+		"message": err.Error(),
+	})
+	return ToolResult{
+		ToolCallID: toolCallID,
+		Content:    string(content),
+		IsError:    true,
+	}
+}
+
+// Message converts the result into the tool message appended to the conversation
+func (r ToolResult) Message() Message {
+	return Message{
+		Role:       "tool",
+		Content:    r.Content,
+		ToolCallID: r.ToolCallID,
+		IsError:    r.IsError,
+	}
+}
+
+// NewLLMAdapter creates an adapter. timeout is the default per-call limit applied
+// when the caller's context has no deadline; zero or negative means 120s.
+func NewLLMAdapter(cfg *config.Config, mcpURL string, timeout time.Duration) *LLMAdapter {
+	if timeout <= 0 {
+		timeout = 120 * time.Second
+	}
 	return &LLMAdapter{
-		cfg:    cfg,
-		client: &http.Client{Timeout: 120 * time.Second},
-		mcpURL: mcpURL,
+		// No client-level timeout: deadlines come from the request context so a
+		// caller's longer deadline isn't silently cut short
+		cfg:      cfg,
+		client:   &http.Client{},
+		mcpURL:   mcpURL,
+		provider: newProvider(cfg.MCP.LLM.Provider),
+		timeout:  timeout,
+
+		maxRetries:     3,
+		retryBaseDelay: 500 * time.Millisecond,
+		maxIterations:  10,
+	}
+}
+
+// IterationLimitError is returned by Run when the model keeps requesting tools past
+// maxIterations. Messages holds the conversation so far for inspection.
+type IterationLimitError struct {
+	Iterations int
+	Messages   []Message
+}
+
+func (e *IterationLimitError) Error() string {
+	return fmt.Sprintf("exceeded max tool-call iterations (%d)", e.Iterations)
+}
+
+// withTimeout applies the adapter's default timeout unless ctx already has a deadline
+func (a *LLMAdapter) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
 	}
+	return context.WithTimeout(ctx, a.timeout)
 }
 
+// retryableError marks a Chat failure that is worth retrying (network errors, 5xx, 429)
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
 func (a *LLMAdapter) Chat(ctx context.Context, messages []Message, tools json.RawMessage) (*ChatResponse, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
 	req := ChatRequest{
 		Model:    a.cfg.MCP.LLM.Model,
 		Messages: messages,
@@ -90,12 +176,38 @@ func (a *LLMAdapter) Chat(ctx context.Context, messages []Message, tools json.Ra
 		req.Tools = tools
 	}
 
-	body, err := json.Marshal(req)
+	body, err := a.provider.EncodeRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.cfg.MCP.LLM.Endpoint+"/api/chat", bytes.NewReader(body))
+	delay := a.retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		result, err := a.doChat(ctx, body)
+		if err == nil {
+			return result, nil
+		}
+
+		var retryErr *retryableError
+		if !errors.As(err, &retryErr) || attempt >= a.maxRetries {
+			if attempt > 0 {
+				return nil, fmt.Errorf("chat failed after %d attempts: %w", attempt+1, err)
+			}
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// doChat performs a single /api/chat round trip
+func (a *LLMAdapter) doChat(ctx context.Context, body []byte) (*ChatResponse, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.cfg.MCP.LLM.Endpoint+a.provider.Endpoint(), bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -106,28 +218,196 @@ func (a *LLMAdapter) Chat(ctx context.Context, messages []Message, tools json.Ra
 
 	resp, err := a.client.Do(httpReq)
 	if err != nil {
-		return nil, err
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, &retryableError{err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("LLM API error: %s", string(b))
+		apiErr := fmt.Errorf("LLM API error (%d): %s", resp.StatusCode, string(b))
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return nil, &retryableError{apiErr}
+		}
+		return nil, apiErr
 	}
 
 	b, _ := io.ReadAll(resp.Body)
 
-	var result ChatResponse
-	if err := json.Unmarshal(b, &result); err != nil {
+	return a.provider.DecodeResponse(b)
+}
+
+// streamChunk is one line of a streaming chat response. Ollama sends bare JSON
+// objects with Message/Done; OpenAI-style servers send "data: " lines with Choices.
+type streamChunk struct {
+	Message Message `json:"message"`
+	Done    bool    `json:"done"`
+	Error   string  `json:"error,omitempty"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// content returns the text delta carried by the chunk, whichever format it is in
+func (c streamChunk) content() string {
+	if len(c.Choices) > 0 {
+		return c.Choices[0].Delta.Content
+	}
+	return c.Message.Content
+}
+
+// finished reports whether the chunk marks the end of the stream
+func (c streamChunk) finished() bool {
+	return c.Done || (len(c.Choices) > 0 && c.Choices[0].FinishReason != nil)
+}
+
+// ChatStream sends messages with streaming enabled and emits content deltas on the
+// returned channel. The channel is closed when the model finishes, the stream
+// breaks, or ctx is cancelled. Use Chat for tool-call loops.
+func (a *LLMAdapter) ChatStream(ctx context.Context, messages []Message, tools json.RawMessage) (<-chan string, error) {
+	req := ChatRequest{
+		Model:    a.cfg.MCP.LLM.Model,
+		Messages: messages,
+		Stream:   true,
+	}
+
+	if len(tools) > 0 {
+		req.Tools = tools
+	}
+
+	body, err := a.provider.EncodeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.cfg.MCP.LLM.Endpoint+a.provider.Endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if a.cfg.MCP.LLM.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+a.cfg.MCP.LLM.APIKey)
+	}
+
+	// Streams can outlive the default timeout; only the caller's ctx ends them
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
 		return nil, err
 	}
-	return &result, nil
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("LLM API error (%d): %s", resp.StatusCode, string(b))
+	}
+
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		// ReadBytes buffers until a full line, so chunks split across reads are reassembled
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadBytes('\n')
+			line = bytes.TrimPrefix(bytes.TrimSpace(line), []byte("data: "))
+			if bytes.Equal(line, []byte("[DONE]")) {
+				return
+			}
+			if len(line) > 0 {
+				var chunk streamChunk
+				if jsonErr := json.Unmarshal(line, &chunk); jsonErr != nil || chunk.Error != "" {
+					return
+				}
+				if delta := chunk.content(); delta != "" {
+					select {
+					case out <- delta:
+					case <-ctx.Done():
+						return
+					}
+				}
+				if chunk.finished() {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// FetchToolSchema asks the gateway's /tools?format=openai endpoint for every registered tool and
+// returns them as the function schemas sent with each chat request. It also records
+// which worker serves each tool so CallMCPTool can route calls it has no prefix for.
+func (a *LLMAdapter) FetchToolSchema(ctx context.Context) (json.RawMessage, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", a.mcpURL+"/tools?format=openai", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tool listing failed: %s", string(b))
+	}
+
+	var listing struct {
+		Tools []struct {
+			Worker   string          `json:"worker"`
+			Type     string          `json:"type"`
+			Function json.RawMessage `json:"function"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(b, &listing); err != nil {
+		return nil, fmt.Errorf("failed to parse tool listing: %w", err)
+	}
+	if len(listing.Tools) == 0 {
+		return nil, fmt.Errorf("gateway returned no tools")
+	}
+
+	routes := make(map[string]string, len(listing.Tools))
+	tools := make([]map[string]interface{}, 0, len(listing.Tools))
+	for _, t := range listing.Tools {
+		var fn struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(t.Function, &fn); err != nil {
+			return nil, fmt.Errorf("failed to parse tool listing: %w", err)
+		}
+		routes[fn.Name] = t.Worker
+		tools = append(tools, map[string]interface{}{
+			"type":     t.Type,
+			"function": t.Function,
+		})
+	}
+	a.toolRoutes = routes
+
+	return json.Marshal(tools)
 }
 
 func (a *LLMAdapter) CallMCPTool(ctx context.Context, toolCallID, toolName string, args json.RawMessage) (string, error) {
 	var workerName, toolShortName string
 
-	if strings.HasPrefix(toolName, "file_io_") {
+	if worker, ok := a.toolRoutes[toolName]; ok {
+		workerName = worker
+		toolShortName = strings.TrimPrefix(toolName, worker+"_")
+	} else if strings.HasPrefix(toolName, "file_io_") {
 		workerName = "file_io"
 		toolShortName = strings.TrimPrefix(toolName, "file_io_")
 	} else if strings.HasPrefix(toolName, "sqlite_") {
@@ -142,6 +422,9 @@ func (a *LLMAdapter) CallMCPTool(ctx context.Context, toolCallID, toolName strin
 
 	url := fmt.Sprintf("%s/tools/%s/%s", a.mcpURL, workerName, toolShortName)
 
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(args))
 	if err != nil {
 		return "", err
@@ -167,7 +450,11 @@ func (a *LLMAdapter) Run(ctx context.Context, systemPrompt string, userPrompt st
 		{Role: "user", Content: userPrompt},
 	}
 
-	for {
+	for iteration := 0; ; iteration++ {
+		if iteration >= a.maxIterations {
+			return "", &IterationLimitError{Iterations: a.maxIterations, Messages: messages}
+		}
+
 		resp, err := a.Chat(ctx, messages, tools)
 		if err != nil {
 			return "", err
@@ -191,13 +478,12 @@ func (a *LLMAdapter) Run(ctx context.Context, systemPrompt string, userPrompt st
 		for _, tc := range msg.ToolCalls {
 			args := tc.Function.Arguments
 			result, err := a.CallMCPTool(ctx, tc.ID, tc.Function.Name, args)
+			toolResult := ToolResult{ToolCallID: tc.ID, Content: result}
 			if err != nil {
-				result = fmt.Sprintf("error: %v", err)
+				fmt.Fprintf(os.Stderr, "Tool %s failed: %v\n", tc.Function.Name, err)
+				toolResult = newToolErrorResult(tc.ID, err)
 			}
-			messages = append(messages, Message{
-				Role:    "tool",
-				Content: result,
-			})
+			messages = append(messages, toolResult.Message())
 		}
 	}
 }
@@ -268,12 +554,16 @@ func main() {
 
 	mcpURL := "http://localhost:8080"
 
-	adapter := NewLLMAdapter(cfg, mcpURL)
+	adapter := NewLLMAdapter(cfg, mcpURL, 120*time.Second)
 
-	tools, err := loadToolsSchema()
+	tools, err := adapter.FetchToolSchema(context.Background())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to load tools: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Warning: tool discovery failed, using built-in tools: %v\n", err)
+		tools, err = loadToolsSchema()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load tools: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	systemPrompt := "You are a helpful assistant with access to file and database tools. Use the tools when needed."
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ericksa/mymcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAdapter(endpoint string) *LLMAdapter {
+	cfg := &config.Config{
+		MCP: config.MCPConfig{
+			LLM: config.LLMConfig{Endpoint: endpoint, Model: "test-model"},
+		},
+	}
+	a := NewLLMAdapter(cfg, "", 0)
+	a.retryBaseDelay = time.Millisecond
+	return a
+}
+
+func TestChat_RetriesTransientErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"hello"}}`))
+	}))
+	defer srv.Close()
+
+	resp, err := testAdapter(srv.URL).Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", resp.Message.Content)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestChat_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	_, err := testAdapter(srv.URL).Chat(context.Background(), nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestChat_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	_, err := testAdapter(srv.URL).Chat(context.Background(), nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 4 attempts")
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestChatStream_EmitsDeltas(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		// Split the second object across two writes to exercise partial-line handling
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hel"},"done":false}` + "\n" + `{"message":{"role":"assis`))
+		flusher.Flush()
+		w.Write([]byte(`tant","content":"lo"},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":""},"done":true}` + "\n"))
+	}))
+	defer srv.Close()
+
+	ch, err := testAdapter(srv.URL).ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	require.NoError(t, err)
+
+	var got []string
+	for delta := range ch {
+		got = append(got, delta)
+	}
+	assert.Equal(t, []string{"Hel", "lo"}, got)
+}
+
+func TestChatStream_ClosesOnError(t *testing.T) {
+	for name, body := range map[string]string{
+		"error chunk": `{"message":{"content":"Hel"},"done":false}` + "\n" + `{"error":"model crashed"}` + "\n",
+		"malformed":   `{"message":{"content":"Hel"},"done":false}` + "\n" + `{"message":` + "\n",
+		"truncated":   `{"message":{"content":"Hel"},"done":false}` + "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(body))
+			}))
+			defer srv.Close()
+
+			ch, err := testAdapter(srv.URL).ChatStream(context.Background(), nil, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, "Hel", <-ch)
+			select {
+			case <-drain(ch):
+			case <-time.After(2 * time.Second):
+				t.Fatal("stream channel was not closed after a broken stream")
+			}
+		})
+	}
+}
+
+func TestChatStream_ClosesOnCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 100; i++ {
+			w.Write([]byte(`{"message":{"content":"x"},"done":false}` + "\n"))
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := testAdapter(srv.URL).ChatStream(ctx, nil, nil)
+	require.NoError(t, err)
+
+	<-ch
+	cancel()
+
+	select {
+	case <-drain(ch):
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream channel was not closed after cancel")
+	}
+}
+
+func drain(ch <-chan string) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	return done
+}
+
+func TestFetchToolSchema_RoutesDiscoveredTools(t *testing.T) {
+	var called string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/tools?format=openai":
+			w.Write([]byte(`{"tools":[{"worker":"email_parser","type":"function","function":{"name":"email_parser_list_recent","description":"List recent emails","parameters":{"type":"object"}}}]}`))
+		default:
+			called = r.URL.Path
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer srv.Close()
+
+	a := testAdapter("")
+	a.mcpURL = srv.URL
+
+	raw, err := a.FetchToolSchema(context.Background())
+	require.NoError(t, err)
+
+	var tools []map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &tools))
+	require.Len(t, tools, 1)
+	assert.Equal(t, "function", tools[0]["type"])
+	assert.NotContains(t, tools[0], "worker")
+
+	_, err = a.CallMCPTool(context.Background(), "call_1", "email_parser_list_recent", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, "/tools/email_parser/list_recent", called)
+}
+
+func TestFetchToolSchema_Unreachable(t *testing.T) {
+	a := testAdapter("")
+	a.mcpURL = "http://127.0.0.1:1"
+
+	_, err := a.FetchToolSchema(context.Background())
+	assert.Error(t, err)
+}
+
+func TestRun_StopsAfterMaxIterations(t *testing.T) {
+	var chats int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/chat" {
+			atomic.AddInt32(&chats, 1)
+			w.Write([]byte(`{"message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"file_io_read_file","arguments":{"path":"x"}}}]}}`))
+			return
+		}
+		w.Write([]byte(`{"content":"data"}`))
+	}))
+	defer srv.Close()
+
+	a := testAdapter(srv.URL)
+	a.mcpURL = srv.URL
+	a.maxIterations = 3
+
+	_, err := a.Run(context.Background(), "system", "user", nil)
+	require.Error(t, err)
+
+	var limitErr *IterationLimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 3, limitErr.Iterations)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&chats))
+	// system + user + 3 x (assistant tool call + tool result)
+	assert.Len(t, limitErr.Messages, 8)
+	assert.Contains(t, err.Error(), "exceeded max tool-call iterations")
+}
+
+func TestMessage_ToolCallIDRoundTrip(t *testing.T) {
+	data, err := json.Marshal(Message{Role: "tool", Content: "ok", ToolCallID: "call_42"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"tool_call_id":"call_42"`)
+
+	var msg Message
+	require.NoError(t, json.Unmarshal(data, &msg))
+	assert.Equal(t, "call_42", msg.ToolCallID)
+
+	data, err = json.Marshal(Message{Role: "user", Content: "hi"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "tool_call_id")
+}
+
+func TestRun_ToolResultCarriesToolCallID(t *testing.T) {
+	var second ChatRequest
+	var chats int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.Write([]byte(`{"content":"data"}`))
+			return
+		}
+		if atomic.AddInt32(&chats, 1) == 1 {
+			w.Write([]byte(`{"message":{"role":"assistant","tool_calls":[{"id":"call_abc","type":"function","function":{"name":"file_io_read_file","arguments":{}}}]}}`))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&second)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"done"}}`))
+	}))
+	defer srv.Close()
+
+	a := testAdapter(srv.URL)
+	a.mcpURL = srv.URL
+
+	out, err := a.Run(context.Background(), "system", "user", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "done", out)
+
+	require.Len(t, second.Messages, 4)
+	toolMsg := second.Messages[3]
+	assert.Equal(t, "tool", toolMsg.Role)
+	assert.Equal(t, "call_abc", toolMsg.ToolCallID)
+}
+
+func slowServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Drain the body so the server notices the client hanging up
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+}
+
+func TestChat_RespectsContextDeadline(t *testing.T) {
+	srv := slowServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := testAdapter(srv.URL).Chat(ctx, nil, nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestChat_DefaultTimeoutWithoutDeadline(t *testing.T) {
+	srv := slowServer()
+	defer srv.Close()
+
+	a := testAdapter(srv.URL)
+	a.timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := a.Chat(context.Background(), nil, nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRun_ToolErrorIsStructured(t *testing.T) {
+	var second ChatRequest
+	var chats int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.Error(w, "permission denied", http.StatusInternalServerError)
+			return
+		}
+		if atomic.AddInt32(&chats, 1) == 1 {
+			w.Write([]byte(`{"message":{"role":"assistant","tool_calls":[{"id":"call_err","type":"function","function":{"name":"file_io_read_file","arguments":{}}}]}}`))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&second)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"sorry"}}`))
+	}))
+	defer srv.Close()
+
+	a := testAdapter(srv.URL)
+	a.mcpURL = srv.URL
+
+	_, err := a.Run(context.Background(), "system", "user", nil)
+	require.NoError(t, err)
+
+	require.Len(t, second.Messages, 4)
+	toolMsg := second.Messages[3]
+	assert.True(t, toolMsg.IsError)
+	assert.Equal(t, "call_err", toolMsg.ToolCallID)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(toolMsg.Content), &payload))
+	assert.Equal(t, true, payload["error"])
+	assert.Contains(t, payload["message"], "permission denied")
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Provider adapts chat requests and responses to a specific LLM backend's wire format
+type Provider interface {
+	// Endpoint is the chat path appended to the configured LLM endpoint
+	Endpoint() string
+	EncodeRequest(req ChatRequest) ([]byte, error)
+	DecodeResponse(body []byte) (*ChatResponse, error)
+}
+
+// newProvider selects a Provider by name. Unknown names fall back to Ollama,
+// which was the only shape the adapter spoke before providers existed.
+func newProvider(name string) Provider {
+	switch strings.ToLower(name) {
+	case "lmstudio", "openai":
+		return openAIProvider{}
+	default:
+		return ollamaProvider{}
+	}
+}
+
+// ollamaProvider speaks Ollama's native /api/chat format
+type ollamaProvider struct{}
+
+func (ollamaProvider) Endpoint() string { return "/api/chat" }
+
+func (ollamaProvider) EncodeRequest(req ChatRequest) ([]byte, error) {
+	return json.Marshal(req)
+}
+
+func (ollamaProvider) DecodeResponse(body []byte) (*ChatResponse, error) {
+	var result ChatResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// openAIProvider speaks the OpenAI /v1/chat/completions format, which LM Studio also serves.
+// The main difference from Ollama is that tool call arguments travel as JSON-encoded strings.
+type openAIProvider struct{}
+
+type openAIRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Tools    json.RawMessage `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (openAIProvider) Endpoint() string { return "/v1/chat/completions" }
+
+func (openAIProvider) EncodeRequest(req ChatRequest) ([]byte, error) {
+	out := openAIRequest{
+		Model:  req.Model,
+		Tools:  req.Tools,
+		Stream: req.Stream,
+	}
+
+	for _, m := range req.Messages {
+		msg := openAIMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCallID: m.ToolCallID,
+		}
+		for _, tc := range m.ToolCalls {
+			var call openAIToolCall
+			call.ID = tc.ID
+			call.Type = tc.Type
+			if call.Type == "" {
+				call.Type = "function"
+			}
+			call.Function.Name = tc.Function.Name
+			call.Function.Arguments = string(tc.Function.Arguments)
+			if call.Function.Arguments == "" {
+				call.Function.Arguments = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		out.Messages = append(out.Messages, msg)
+	}
+
+	return json.Marshal(out)
+}
+
+func (openAIProvider) DecodeResponse(body []byte) (*ChatResponse, error) {
+	var resp openAIResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
+
+	var result ChatResponse
+	for _, c := range resp.Choices {
+		msg := Message{
+			Role:    c.Message.Role,
+			Content: c.Message.Content,
+		}
+		for i, tc := range c.Message.ToolCalls {
+			args := json.RawMessage(tc.Function.Arguments)
+			if !json.Valid(args) {
+				// Some models emit malformed arguments; pass an empty object rather than fail the turn
+				args = json.RawMessage("{}")
+			}
+			msg.ToolCalls = append(msg.ToolCalls, ToolResponse{
+				Index:    i,
+				ID:       tc.ID,
+				Type:     tc.Type,
+				Function: ToolFunc{Name: tc.Function.Name, Arguments: args},
+			})
+		}
+		result.Choices = append(result.Choices, Choice{Message: msg})
+	}
+	return &result, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvider(t *testing.T) {
+	assert.IsType(t, ollamaProvider{}, newProvider("ollama"))
+	assert.IsType(t, ollamaProvider{}, newProvider(""))
+	assert.IsType(t, openAIProvider{}, newProvider("lmstudio"))
+	assert.IsType(t, openAIProvider{}, newProvider("OpenAI"))
+}
+
+func TestOpenAIProvider_EncodeStringifiesToolArguments(t *testing.T) {
+	body, err := openAIProvider{}.EncodeRequest(ChatRequest{
+		Model: "m",
+		Messages: []Message{
+			{Role: "assistant", ToolCalls: []ToolResponse{{ID: "call_1", Function: ToolFunc{Name: "file_io_read_file", Arguments: json.RawMessage(`{"path":"a"}`)}}}},
+			{Role: "tool", Content: "data", ToolCallID: "call_1"},
+		},
+	})
+	require.NoError(t, err)
+
+	var req openAIRequest
+	require.NoError(t, json.Unmarshal(body, &req))
+	require.Len(t, req.Messages, 2)
+	call := req.Messages[0].ToolCalls[0]
+	assert.Equal(t, "function", call.Type)
+	assert.Equal(t, `{"path":"a"}`, call.Function.Arguments)
+	assert.Equal(t, "call_1", req.Messages[1].ToolCallID)
+}
+
+func TestChat_LMStudioProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"id":"call_9","type":"function","function":{"name":"sqlite_sql_query","arguments":"{\"query\":\"SELECT 1\"}"}}]}}]}`))
+	}))
+	defer srv.Close()
+
+	a := testAdapter(srv.URL)
+	a.provider = newProvider("lmstudio")
+
+	resp, err := a.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Choices, 1)
+	tc := resp.Choices[0].Message.ToolCalls[0]
+	assert.Equal(t, "call_9", tc.ID)
+	assert.JSONEq(t, `{"query":"SELECT 1"}`, string(tc.Function.Arguments))
+}
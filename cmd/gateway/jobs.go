@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ericksa/mymcp/internal/workers"
+	"github.com/gorilla/mux"
+)
+
+// Job states
+const (
+	jobRunning   = "running"
+	jobCompleted = "completed"
+	jobFailed    = "failed"
+)
+
+// job is a tool call running in the background
+type job struct {
+	ID          string            `json:"job_id"`
+	Tool        string            `json:"tool"`
+	Status      string            `json:"status"`
+	Result      json.RawMessage   `json:"result,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Progress    []json.RawMessage `json:"progress,omitempty"` // updates reported while running
+	CreatedAt   time.Time         `json:"created_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+}
+
+// jobStore keeps jobs in memory. Finished jobs are dropped ttl after they complete.
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+	ttl  time.Duration
+}
+
+func newJobStore(ttl time.Duration) *jobStore {
+	return &jobStore{jobs: make(map[string]*job), ttl: ttl}
+}
+
+var (
+	jobs = newJobStore(time.Hour)
+	// jobTimeout bounds background tool calls, which are expected to outlive toolTimeout
+	jobTimeout = time.Hour
+)
+
+func (s *jobStore) create(tool string) *job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked()
+
+	j := &job{
+		ID:        newJobID(),
+		Tool:      tool,
+		Status:    jobRunning,
+		CreatedAt: time.Now(),
+	}
+	s.jobs[j.ID] = j
+	return j
+}
+
+func (s *jobStore) finish(id string, result []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	j.CompletedAt = &now
+	if err != nil {
+		j.Status = jobFailed
+		j.Error = err.Error()
+		return
+	}
+	j.Status = jobCompleted
+	if json.Valid(result) {
+		j.Result = result
+	} else {
+		j.Result, _ = json.Marshal(string(result))
+	}
+}
+
+// addProgress appends an intermediate update to a running job
+func (s *jobStore) addProgress(id string, update any) {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j, ok := s.jobs[id]; ok && j.Status == jobRunning {
+		j.Progress = append(j.Progress, data)
+	}
+}
+
+// jobIDKey carries the job ID on a background tool call's context
+type jobIDKey struct{}
+
+// jobProgressSink records orchestrator_evolve_stream updates on the job running the call
+func jobProgressSink(ctx context.Context, stats workers.GenerationStats) {
+	if id, ok := ctx.Value(jobIDKey{}).(string); ok {
+		jobs.addProgress(id, stats)
+	}
+}
+
+// get returns a copy of the job so callers can encode it without holding the lock
+func (s *jobStore) get(id string) (job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return job{}, false
+	}
+	cp := *j
+	cp.Progress = append([]json.RawMessage(nil), j.Progress...)
+	return cp, true
+}
+
+// sweepLocked removes finished jobs older than the TTL; s.mu must be held
+func (s *jobStore) sweepLocked() {
+	cutoff := time.Now().Add(-s.ttl)
+	for id, j := range s.jobs {
+		if j.CompletedAt != nil && j.CompletedAt.Before(cutoff) {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// createJobHandler starts a tool call in the background and returns its job_id immediately
+func createJobHandler(w http.ResponseWriter, r *http.Request) {
+	if handler == nil {
+		writeJSONError(w, http.StatusInternalServerError, "handler not initialized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	fullToolName := vars["worker"] + "_" + vars["tool"]
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+
+	var args map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	argsJSON, _ := json.Marshal(args)
+
+	j := jobs.create(fullToolName)
+
+	// Detached from the request context: the job must keep running after we respond
+	go func(id string) {
+		ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+		defer cancel()
+		ctx = context.WithValue(ctx, jobIDKey{}, id)
+
+		start := time.Now()
+		result, err := handler.ExecuteTool(ctx, fullToolName, argsJSON)
+		metrics.Record(fullToolName, time.Since(start), err != nil)
+		jobs.finish(id, result, err)
+	}(j.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"job_id": j.ID,
+		"status": jobRunning,
+	})
+}
+
+func getJobHandler(w http.ResponseWriter, r *http.Request) {
+	j, ok := jobs.get(mux.Vars(r)["job_id"])
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(j)
+}
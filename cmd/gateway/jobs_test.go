@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ericksa/mymcp/internal/config"
+	"github.com/ericksa/mymcp/internal/workers"
+	"github.com/ericksa/mymcp/pkg/mcp"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoWorker returns its input, or fails for the "fail" tool
+type echoWorker struct{}
+
+func (echoWorker) GetTools() []workers.ToolDef {
+	return []workers.ToolDef{{Name: "echo"}, {Name: "fail"}}
+}
+
+func (echoWorker) Execute(ctx context.Context, name string, input json.RawMessage) ([]byte, error) {
+	if name == "fail" {
+		return nil, errors.New("boom")
+	}
+	return input, nil
+}
+
+func jobsRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/jobs/{worker}/{tool}", createJobHandler).Methods("POST")
+	router.HandleFunc("/jobs/{job_id}", getJobHandler).Methods("GET")
+	return router
+}
+
+func waitForJob(t *testing.T, router *mux.Router, id string) job {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+id, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var j job
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &j))
+		if j.Status != jobRunning {
+			return j
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return job{}
+}
+
+func startJob(t *testing.T, router *mux.Router, path, body string) string {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var resp map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, jobRunning, resp["status"])
+	require.NotEmpty(t, resp["job_id"])
+	return resp["job_id"]
+}
+
+func TestJobs_Lifecycle(t *testing.T) {
+	handler = mcp.NewHandler(&config.Config{})
+	handler.RegisterWorker("echo", echoWorker{})
+	defer func() { handler = nil }()
+	router := jobsRouter()
+
+	done := waitForJob(t, router, startJob(t, router, "/jobs/echo/echo", `{"msg":"hi"}`))
+	assert.Equal(t, jobCompleted, done.Status)
+	assert.JSONEq(t, `{"msg":"hi"}`, string(done.Result))
+	assert.NotNil(t, done.CompletedAt)
+
+	failed := waitForJob(t, router, startJob(t, router, "/jobs/echo/fail", `{}`))
+	assert.Equal(t, jobFailed, failed.Status)
+	assert.Equal(t, "boom", failed.Error)
+}
+
+func TestJobs_RecordsEvolutionProgress(t *testing.T) {
+	handler = mcp.NewHandler(&config.Config{})
+	orchestrator := workers.NewOrchestratorWorkerState(4, 0, nil)
+	orchestrator.SetProgressSink(jobProgressSink)
+	handler.RegisterWorker("orchestrator", orchestrator)
+	defer func() { handler = nil }()
+	router := jobsRouter()
+
+	out, err := orchestrator.Execute(context.Background(), "orchestrator_register_agent", json.RawMessage(`{"name":"writer","model":"m"}`))
+	require.NoError(t, err)
+	var registered struct {
+		AgentID string `json:"agent_id"`
+	}
+	require.NoError(t, json.Unmarshal(out, &registered))
+
+	body := `{"parent_ids":["` + registered.AgentID + `"],"population_size":4,"generations":2}`
+	done := waitForJob(t, router, startJob(t, router, "/jobs/orchestrator/orchestrator_evolve_stream", body))
+	require.Equal(t, jobCompleted, done.Status, done.Error)
+	require.Len(t, done.Progress, 3)
+
+	var last workers.GenerationStats
+	require.NoError(t, json.Unmarshal(done.Progress[2], &last))
+	assert.Equal(t, 2, last.Generation)
+}
+
+func TestJobs_UnknownJob(t *testing.T) {
+	w := httptest.NewRecorder()
+	jobsRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestJobStore_SweepsExpiredJobs(t *testing.T) {
+	store := newJobStore(time.Minute)
+	j := store.create("echo_echo")
+	store.finish(j.ID, []byte(`{}`), nil)
+
+	old := time.Now().Add(-2 * time.Minute)
+	store.jobs[j.ID].CompletedAt = &old
+
+	_, ok := store.get(j.ID)
+	assert.False(t, ok)
+}
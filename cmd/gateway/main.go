@@ -3,21 +3,39 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/ericksa/mymcp/internal/config"
 	"github.com/ericksa/mymcp/internal/middleware"
+	"github.com/ericksa/mymcp/internal/standup"
+	"github.com/ericksa/mymcp/internal/workers"
 	"github.com/ericksa/mymcp/pkg/mcp"
 	"github.com/gorilla/mux"
 )
 
 var handler *mcp.Handler
 
+// standupDBURL is the tasks database used by the /standup endpoint
+var standupDBURL string
+
+// maxRequestBytes caps tool and MCP request bodies
+var maxRequestBytes int64 = 10 << 20
+
+// toolTimeout bounds each tool execution; toolTimeouts overrides it per full tool name
+var (
+	toolTimeout  = 60 * time.Second
+	toolTimeouts = map[string]time.Duration{}
+)
+
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
@@ -31,20 +49,60 @@ func main() {
 
 	// Create MCP handler
 	handler = mcp.NewHandler(cfg)
+	// Report orchestrator_evolve_stream progress on the async job that started it
+	if w, ok := handler.Worker("orchestrator"); ok {
+		if orchestrator, ok := w.(*workers.OrchestratorWorkerState); ok {
+			orchestrator.SetProgressSink(jobProgressSink)
+		}
+	}
+	standupDBURL = cfg.MCP.Workers.Task.DBURL
+	if cfg.MCP.Server.MaxRequestBytes > 0 {
+		maxRequestBytes = cfg.MCP.Server.MaxRequestBytes
+	}
+	// Durations were checked by Validate
+	if d, err := time.ParseDuration(cfg.MCP.Server.ToolTimeout); err == nil && d > 0 {
+		toolTimeout = d
+	}
+	for tool, timeout := range cfg.MCP.Server.ToolTimeouts {
+		if d, err := time.ParseDuration(timeout); err == nil && d > 0 {
+			toolTimeouts[tool] = d
+		}
+	}
+
+	// Leave room for the longest tool timeout so slow tools get a 504 rather than a dropped connection
+	longest := toolTimeout
+	for _, d := range toolTimeouts {
+		if d > longest {
+			longest = d
+		}
+	}
+	writeTimeout := 15 * time.Second
+	if longest+5*time.Second > writeTimeout {
+		writeTimeout = longest + 5*time.Second
+	}
 
 	// Set up router
 	router := mux.NewRouter()
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
+	// CORS runs before auth so browser preflights, which carry no token, succeed
+	router.Use(middleware.CORS(cfg.MCP.Server.CORS))
 	router.Use(middleware.AuthMiddleware(cfg))
 
 	// MCP endpoint
-	router.PathPrefix("/mcp").Handler(handler)
+	router.PathPrefix("/mcp").Handler(middleware.MaxBytes(maxRequestBytes)(handler))
 
 	// Health endpoint
 	router.HandleFunc("/health", healthHandler).Methods("GET")
 
+	// Per-tool call metrics (Prometheus text format)
+	router.HandleFunc("/metrics", metricsHandler).Methods("GET")
+
+	// Standup report
+	router.HandleFunc("/standup", standupHandler).Methods("GET")
+
 	// Tools endpoints
+	router.HandleFunc("/tools", listToolsHandler).Methods("GET")
 	router.HandleFunc("/tools/file_io/{tool}", fileIOToolHandler).Methods("POST")
 	router.HandleFunc("/tools/sqlite/{tool}", sqliteToolHandler).Methods("POST")
 	router.HandleFunc("/tools/vector/{tool}", vectorToolHandler).Methods("POST")
@@ -55,16 +113,25 @@ func main() {
 	router.HandleFunc("/tools/whisper/{tool}", whisperToolHandler).Methods("POST")
 	router.HandleFunc("/tools/dataset/{tool}", datasetToolHandler).Methods("POST")
 	router.HandleFunc("/tools/email_parser/{tool}", emailParserToolHandler).Methods("POST")
+	// Any other registered worker
+	router.HandleFunc("/tools/{worker}/{tool}", workerToolHandler).Methods("POST")
+
+	// Async jobs for long-running tools
+	router.HandleFunc("/jobs/{worker}/{tool}", createJobHandler).Methods("POST")
+	router.HandleFunc("/jobs/{job_id}", getJobHandler).Methods("GET")
 
 	// Configuration API
 	router.PathPrefix("/configure").Handler(config.NewConfigAPI(cfg).Router())
 
+	// Catch-all for preflight requests; mux only runs middleware on matched routes
+	router.PathPrefix("/").Methods("OPTIONS").HandlerFunc(preflightHandler)
+
 	// Start server
 	srv := &http.Server{
 		Addr:         cfg.MCP.Server.Addr,
 		Handler:      router,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: writeTimeout,
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -89,12 +156,153 @@ func main() {
 	log.Println("Server stopped")
 }
 
+func preflightHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"status":"ok"}`))
 }
 
+func standupHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := standup.FilterOptions{
+		Client: q.Get("client"),
+		Status: q.Get("status"),
+	}
+
+	if v := q.Get("start"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid start date: "+err.Error())
+			return
+		}
+		filter.StartDate = &t
+	}
+
+	if v := q.Get("end"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid end date: "+err.Error())
+			return
+		}
+		filter.EndDate = &t
+	}
+
+	includeDone := false
+	if v := q.Get("done"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid done flag: "+err.Error())
+			return
+		}
+		includeDone = b
+	}
+
+	report, err := standup.Generate(standupDBURL, filter, includeDone, standup.PeriodDay)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// writeJSONError writes {"error": msg} with the given status code
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// toolInfo describes one tool in the /tools listing. FullName is the
+// worker-prefixed name accepted by POST /tools/{worker}/{tool}.
+type toolInfo struct {
+	Name        string `json:"name"`
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+}
+
+// toolSchema describes one tool as an OpenAI-style function definition. Worker
+// names the /tools/{worker}/{tool} route that executes it.
+type toolSchema struct {
+	Worker   string         `json:"worker"`
+	Type     string         `json:"type"`
+	Function toolSchemaFunc `json:"function"`
+}
+
+type toolSchemaFunc struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// listToolsHandler lists every enabled worker's tools grouped by worker name.
+// With ?format=openai it returns a flat list of function schemas instead.
+func listToolsHandler(w http.ResponseWriter, r *http.Request) {
+	if handler == nil {
+		writeJSONError(w, http.StatusInternalServerError, "handler not initialized")
+		return
+	}
+
+	if r.URL.Query().Get("format") == "openai" {
+		listToolSchemas(w)
+		return
+	}
+
+	grouped := make(map[string][]toolInfo)
+	for workerName, tools := range handler.ListTools() {
+		infos := make([]toolInfo, 0, len(tools))
+		for _, tool := range tools {
+			infos = append(infos, toolInfo{
+				Name:        tool.Name,
+				FullName:    workerName + "_" + tool.Name,
+				Description: tool.Description,
+			})
+		}
+		grouped[workerName] = infos
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(grouped)
+}
+
+func listToolSchemas(w http.ResponseWriter) {
+	var schemas []toolSchema
+	for workerName, tools := range handler.ListTools() {
+		for _, tool := range tools {
+			schemas = append(schemas, toolSchema{
+				Worker: workerName,
+				Type:   "function",
+				Function: toolSchemaFunc{
+					Name:        workerName + "_" + tool.Name,
+					Description: tool.Description,
+					// Workers don't publish argument schemas, so accept any object
+					Parameters: map[string]any{
+						"type":                 "object",
+						"additionalProperties": true,
+					},
+				},
+			})
+		}
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Function.Name < schemas[j].Function.Name
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"tools": schemas})
+}
+
+func workerToolHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	executeToolHandler(w, r, vars["worker"], vars["tool"])
+}
+
 func fileIOToolHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	toolName := vars["tool"]
@@ -161,8 +369,15 @@ func executeToolHandler(w http.ResponseWriter, r *http.Request, workerName, tool
 		return
 	}
 
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+
 	var args map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -170,7 +385,44 @@ func executeToolHandler(w http.ResponseWriter, r *http.Request, workerName, tool
 	argsJSON, _ := json.Marshal(args)
 	fullToolName := workerName + "_" + toolName
 
-	result, err := handler.ExecuteTool(r.Context(), fullToolName, argsJSON)
+	timeout := toolTimeout
+	if d, ok := toolTimeouts[fullToolName]; ok {
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	// Run the tool in its own goroutine so a worker that ignores ctx can't hold the request past its deadline
+	type toolOutcome struct {
+		result []byte
+		err    error
+	}
+	done := make(chan toolOutcome, 1)
+
+	start := time.Now()
+	go func() {
+		result, err := handler.ExecuteTool(ctx, fullToolName, argsJSON)
+		done <- toolOutcome{result, err}
+	}()
+
+	var result []byte
+	var err error
+	select {
+	case out := <-done:
+		result, err = out.result, out.err
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	metrics.Record(fullToolName, time.Since(start), err != nil)
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		http.Error(w, fmt.Sprintf("tool %s timed out after %s", fullToolName, timeout), http.StatusGatewayTimeout)
+		return
+	}
+	if errors.Is(err, workers.ErrStaleTask) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ericksa/mymcp/internal/config"
 	"github.com/ericksa/mymcp/internal/middleware"
+	"github.com/ericksa/mymcp/internal/workers"
+	"github.com/ericksa/mymcp/pkg/mcp"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHealthHandler(t *testing.T) {
@@ -97,3 +104,221 @@ func TestRecovererMiddleware_Panic(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+func TestStandupHandler_BadDate(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/standup?start=01/15/2024", nil)
+	w := httptest.NewRecorder()
+
+	standupHandler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var resp map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Contains(t, resp["error"], "invalid start date")
+}
+
+func TestListToolsHandler(t *testing.T) {
+	handler = mcp.NewHandler(&config.Config{})
+	defer func() { handler = nil }()
+
+	req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+	w := httptest.NewRecorder()
+
+	listToolsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string][]toolInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	require.Contains(t, resp, "file_io")
+	assert.NotContains(t, resp, "minio") // disabled by default
+	var found bool
+	for _, tool := range resp["file_io"] {
+		if tool.Name == "read_file" {
+			found = true
+			assert.Equal(t, "file_io_read_file", tool.FullName)
+			assert.NotEmpty(t, tool.Description)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestListToolsHandler_OpenAIFormat(t *testing.T) {
+	handler = mcp.NewHandler(&config.Config{})
+	defer func() { handler = nil }()
+
+	req := httptest.NewRequest(http.MethodGet, "/tools?format=openai", nil)
+	w := httptest.NewRecorder()
+
+	listToolsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Tools []toolSchema `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	names := make(map[string]string)
+	for _, tool := range resp.Tools {
+		assert.Equal(t, "function", tool.Type)
+		names[tool.Function.Name] = tool.Worker
+	}
+	assert.Equal(t, "file_io", names["file_io_read_file"])
+	assert.Equal(t, "sqlite", names["sqlite_sql_query"])
+	assert.NotContains(t, names, "minio_upload")
+}
+
+func TestExecuteToolHandler_RejectsOversizedBody(t *testing.T) {
+	handler = mcp.NewHandler(&config.Config{})
+	oldLimit := maxRequestBytes
+	maxRequestBytes = 64
+	defer func() {
+		handler = nil
+		maxRequestBytes = oldLimit
+	}()
+
+	body := `{"path":"` + strings.Repeat("a", 1024) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/tools/file_io/read_file", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	fileIOToolHandler(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestMCPEndpoint_RejectsOversizedBody(t *testing.T) {
+	// The MCP handler never reads the body, so the limit must be enforced before it
+	h := middleware.MaxBytes(64)(mcp.NewHandler(&config.Config{}))
+
+	body := `{"jsonrpc":"2.0","method":"` + strings.Repeat("a", 1024) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+// slowWorker blocks until its context is cancelled
+type slowWorker struct {
+	cancelled chan struct{}
+}
+
+func (s *slowWorker) GetTools() []workers.ToolDef {
+	return []workers.ToolDef{{Name: "wait", Description: "Block until cancelled"}}
+}
+
+func (s *slowWorker) Execute(ctx context.Context, name string, input json.RawMessage) ([]byte, error) {
+	<-ctx.Done()
+	close(s.cancelled)
+	return nil, ctx.Err()
+}
+
+func TestExecuteToolHandler_Timeout(t *testing.T) {
+	worker := &slowWorker{cancelled: make(chan struct{})}
+	handler = mcp.NewHandler(&config.Config{})
+	handler.RegisterWorker("slow", worker)
+	toolTimeouts["slow_wait"] = 50 * time.Millisecond
+	defer func() {
+		handler = nil
+		delete(toolTimeouts, "slow_wait")
+	}()
+
+	req := httptest.NewRequest(http.MethodPost, "/tools/slow/wait", strings.NewReader(`{}`))
+	req = mux.SetURLVars(req, map[string]string{"worker": "slow", "tool": "wait"})
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	workerToolHandler(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Less(t, time.Since(start), time.Second)
+	select {
+	case <-worker.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("worker context was not cancelled")
+	}
+}
+
+// staleWorker fails every call as a concurrent task update would
+type staleWorker struct{}
+
+func (staleWorker) GetTools() []workers.ToolDef {
+	return []workers.ToolDef{{Name: "update"}}
+}
+
+func (staleWorker) Execute(ctx context.Context, name string, input json.RawMessage) ([]byte, error) {
+	return nil, fmt.Errorf("task 1: %w", workers.ErrStaleTask)
+}
+
+func TestExecuteToolHandler_StaleTaskIsConflict(t *testing.T) {
+	handler = mcp.NewHandler(&config.Config{})
+	handler.RegisterWorker("stale", staleWorker{})
+	defer func() { handler = nil }()
+
+	req := httptest.NewRequest(http.MethodPost, "/tools/stale/update", strings.NewReader(`{}`))
+	req = mux.SetURLVars(req, map[string]string{"worker": "stale", "tool": "update"})
+	w := httptest.NewRecorder()
+
+	workerToolHandler(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func corsRouter(cors config.CORSConfig) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(middleware.CORS(cors))
+	router.Use(middleware.AuthMiddleware(&config.Config{}))
+	router.HandleFunc("/standup", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET")
+	router.PathPrefix("/").Methods("OPTIONS").HandlerFunc(preflightHandler)
+	return router
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	router := corsRouter(config.CORSConfig{
+		AllowedOrigins: []string{"http://dashboard.local"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type"},
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/standup", nil)
+	req.Header.Set("Origin", "http://dashboard.local")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "http://dashboard.local", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+}
+
+func TestCORS_DisallowedOrigin(t *testing.T) {
+	router := corsRouter(config.CORSConfig{AllowedOrigins: []string{"http://dashboard.local"}})
+
+	req := httptest.NewRequest(http.MethodGet, "/standup", nil)
+	req.Header.Set("Origin", "http://evil.example")
+	req.Header.Set("Authorization", "Bearer x")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_DisabledByDefault(t *testing.T) {
+	router := corsRouter(config.CORSConfig{})
+
+	req := httptest.NewRequest(http.MethodGet, "/standup", nil)
+	req.Header.Set("Origin", "http://dashboard.local")
+	req.Header.Set("Authorization", "Bearer x")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the histogram upper bounds, in seconds
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// toolStats holds the counters for a single tool. Fields are updated atomically
+// so recording never takes the registry lock once the entry exists.
+type toolStats struct {
+	calls   atomic.Uint64
+	errors  atomic.Uint64
+	sumNano atomic.Uint64
+	buckets []atomic.Uint64 // non-cumulative counts, one per latencyBuckets entry
+}
+
+// toolMetrics is a concurrency-safe registry of per-tool call statistics
+type toolMetrics struct {
+	mu    sync.RWMutex
+	tools map[string]*toolStats
+}
+
+func newToolMetrics() *toolMetrics {
+	return &toolMetrics{tools: make(map[string]*toolStats)}
+}
+
+var metrics = newToolMetrics()
+
+func (m *toolMetrics) get(tool string) *toolStats {
+	m.mu.RLock()
+	s, ok := m.tools[tool]
+	m.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok = m.tools[tool]; !ok {
+		s = &toolStats{buckets: make([]atomic.Uint64, len(latencyBuckets))}
+		m.tools[tool] = s
+	}
+	return s
+}
+
+// Record adds one call of tool that took d and failed if failed is true
+func (m *toolMetrics) Record(tool string, d time.Duration, failed bool) {
+	s := m.get(tool)
+	s.calls.Add(1)
+	if failed {
+		s.errors.Add(1)
+	}
+	s.sumNano.Add(uint64(d))
+
+	secs := d.Seconds()
+	for i, le := range latencyBuckets {
+		if secs <= le {
+			s.buckets[i].Add(1)
+			break
+		}
+	}
+}
+
+// WritePrometheus renders all metrics in the Prometheus text exposition format
+func (m *toolMetrics) WritePrometheus(b *strings.Builder) {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.tools))
+	for name := range m.tools {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+	sort.Strings(names)
+
+	b.WriteString("# HELP mymcp_tool_calls_total Total tool calls.\n")
+	b.WriteString("# TYPE mymcp_tool_calls_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(b, "mymcp_tool_calls_total{tool=%q} %d\n", name, m.get(name).calls.Load())
+	}
+
+	b.WriteString("# HELP mymcp_tool_errors_total Total failed tool calls.\n")
+	b.WriteString("# TYPE mymcp_tool_errors_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(b, "mymcp_tool_errors_total{tool=%q} %d\n", name, m.get(name).errors.Load())
+	}
+
+	b.WriteString("# HELP mymcp_tool_duration_seconds Tool call latency.\n")
+	b.WriteString("# TYPE mymcp_tool_duration_seconds histogram\n")
+	for _, name := range names {
+		s := m.get(name)
+		var cumulative uint64
+		for i, le := range latencyBuckets {
+			cumulative += s.buckets[i].Load()
+			fmt.Fprintf(b, "mymcp_tool_duration_seconds_bucket{tool=%q,le=\"%g\"} %d\n", name, le, cumulative)
+		}
+		calls := s.calls.Load()
+		fmt.Fprintf(b, "mymcp_tool_duration_seconds_bucket{tool=%q,le=\"+Inf\"} %d\n", name, calls)
+		fmt.Fprintf(b, "mymcp_tool_duration_seconds_sum{tool=%q} %g\n", name, time.Duration(s.sumNano.Load()).Seconds())
+		fmt.Fprintf(b, "mymcp_tool_duration_seconds_count{tool=%q} %d\n", name, calls)
+	}
+}
+
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	metrics.WritePrometheus(&b)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
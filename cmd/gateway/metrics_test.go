@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolMetrics_Record(t *testing.T) {
+	m := newToolMetrics()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Record("file_io_read_file", 20*time.Millisecond, i%10 == 0)
+		}(i)
+	}
+	wg.Wait()
+	m.Record("sqlite_sql_query", 2*time.Second, false)
+
+	var b strings.Builder
+	m.WritePrometheus(&b)
+	out := b.String()
+
+	assert.Contains(t, out, `mymcp_tool_calls_total{tool="file_io_read_file"} 50`)
+	assert.Contains(t, out, `mymcp_tool_errors_total{tool="file_io_read_file"} 5`)
+	assert.Contains(t, out, `mymcp_tool_duration_seconds_bucket{tool="file_io_read_file",le="0.01"} 0`)
+	assert.Contains(t, out, `mymcp_tool_duration_seconds_bucket{tool="file_io_read_file",le="0.025"} 50`)
+	assert.Contains(t, out, `mymcp_tool_duration_seconds_bucket{tool="sqlite_sql_query",le="1"} 0`)
+	assert.Contains(t, out, `mymcp_tool_duration_seconds_bucket{tool="sqlite_sql_query",le="+Inf"} 1`)
+	assert.Contains(t, out, `mymcp_tool_duration_seconds_count{tool="sqlite_sql_query"} 1`)
+}
+
+func TestMetricsHandler(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+
+	metricsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, w.Body.String(), "# TYPE mymcp_tool_calls_total counter")
+}
@@ -1,83 +1,18 @@
 package main
 
 import (
-	"database/sql"
 	"encoding/json"
 	"flag"
 	"fmt"
+	htmltemplate "html/template"
 	"os"
 	"strings"
 	"text/template"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/ericksa/mymcp/internal/standup"
 )
 
-// Task represents a task from the database
-type Task struct {
-	ID             string     `json:"id"`
-	Title          string     `json:"title"`
-	Description    string     `json:"description"`
-	Client         string     `json:"client"`
-	Project        string     `json:"project"`
-	EmailSubject   string     `json:"email_subject"`
-	EmailFrom      string     `json:"email_from"`
-	DueDate        *time.Time `json:"due_date"`
-	Status         string     `json:"status"`
-	Priority       int        `json:"priority"`
-	Urgency        string     `json:"urgency"`
-	AssignedAgent  string     `json:"assigned_agent"`
-	Source         string     `json:"source"`
-	EstimatedHours float64    `json:"estimated_hours"`
-	ActualHours    float64    `json:"actual_hours"`
-	BillingStatus  string     `json:"billing_status"`
-	Tags           []string   `json:"tags"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
-}
-
-// TimeEntry represents a time entry from the database
-type TimeEntry struct {
-	ID              string     `json:"id"`
-	TaskID          string     `json:"task_id"`
-	StartedAt       *time.Time `json:"started_at"`
-	EndedAt         *time.Time `json:"ended_at"`
-	DurationMinutes int        `json:"duration_minutes"`
-	Description     string     `json:"description"`
-	AgentID         string     `json:"agent_id"`
-}
-
-// StandupReport represents the generated standup report
-type StandupReport struct {
-	GeneratedAt     time.Time `json:"generated_at"`
-	DateRange       string    `json:"date_range"`
-	TotalTasks      int       `json:"total_tasks"`
-	OverdueTasks    []Task    `json:"overdue_tasks"`
-	DueTodayTasks   []Task    `json:"due_today_tasks"`
-	InProgressTasks []Task    `json:"in_progress_tasks"`
-	CompletedTasks  []Task    `json:"completed_tasks"`
-	Summary         Summary   `json:"summary"`
-}
-
-// Summary provides high-level stats
-type Summary struct {
-	OverdueCount    int     `json:"overdue_count"`
-	DueTodayCount   int     `json:"due_today_count"`
-	InProgressCount int     `json:"in_progress_count"`
-	CompletedCount  int     `json:"completed_count"`
-	TotalHours      float64 `json:"total_hours"`
-	BilledHours     float64 `json:"billed_hours"`
-	UnbilledHours   float64 `json:"unbilled_hours"`
-}
-
-// FilterOptions for query filtering
-type FilterOptions struct {
-	Client    string
-	Status    string
-	StartDate *time.Time
-	EndDate   *time.Time
-}
-
 // Config holds database connection configuration
 type Config struct {
 	DatabaseURL string
@@ -93,6 +28,8 @@ func main() {
 		endDate     = flag.String("end", "", "End date for range (YYYY-MM-DD)")
 		dbURL       = flag.String("db", "", "Database URL (default: from DATABASE_URL env)")
 		includeDone = flag.Bool("done", false, "Include completed tasks in report")
+		maxPriority = flag.Int("max-priority", 5, "Only include tasks at or above this priority (1=critical, 5=no filter)")
+		rangeFlag   = flag.String("range", "day", "Report window: day or week")
 		help        = flag.Bool("help", false, "Show help")
 	)
 	flag.Parse()
@@ -102,6 +39,12 @@ func main() {
 		os.Exit(0)
 	}
 
+	period, err := standup.ParsePeriod(*rangeFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid range: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Get database URL
 	databaseURL := *dbURL
 	if databaseURL == "" {
@@ -113,9 +56,10 @@ func main() {
 	}
 
 	// Build filter options
-	filter := FilterOptions{
-		Client: *client,
-		Status: *status,
+	filter := standup.FilterOptions{
+		Client:      *client,
+		Status:      *status,
+		MaxPriority: *maxPriority,
 	}
 
 	if *startDate != "" {
@@ -137,7 +81,7 @@ func main() {
 	}
 
 	// Generate report
-	report, err := generateReport(databaseURL, filter, *includeDone)
+	report, err := standup.Generate(databaseURL, filter, *includeDone, period)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating report: %v\n", err)
 		os.Exit(1)
@@ -161,6 +105,12 @@ func main() {
 			os.Exit(1)
 		}
 		fmt.Printf("Report written to: %s\n", *output)
+	case strings.HasSuffix(*output, ".html"):
+		if err := writeHTMLReport(report, *output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Report written to: %s\n", *output)
 	default:
 		// Default to console for unknown output
 		printConsoleReport(report)
@@ -168,13 +118,13 @@ func main() {
 }
 
 func printHelp() {
-	fmt.Println(`MyMCP Daily Standup Report Generator
+	fmt.Print(`MyMCP Daily Standup Report Generator
 
 USAGE:
     standup [OPTIONS]
 
 OPTIONS:
-    -output <format>   Output format: console, json, or file path (.json, .md, .txt)
+    -output <format>   Output format: console, json, or file path (.json, .md, .txt, .html)
                        (default: console)
     -client <name>     Filter by client name
     -status <status>   Filter by status (e.g., open, in_progress, completed)
@@ -182,6 +132,11 @@ OPTIONS:
     -end <date>        End date for range filter (YYYY-MM-DD)
     -db <url>          Database URL (default: from DATABASE_URL env)
     -done              Include completed tasks in the report
+    -max-priority <n>  Only include tasks with priority <= n (1=critical, 2=high,
+                       3=medium, 4=low). Applies to every section. (default: 5, no filter)
+    -range <period>    Report window: day or week (default: day). The weekly
+                       rollup covers the previous 7 days and always lists
+                       everything completed in that window.
     -help              Show this help message
 
 EXAMPLES:
@@ -194,289 +149,34 @@ EXAMPLES:
     # Export to JSON file
     standup -output /tmp/standup-2024-01-15.json
 
+    # Email-ready HTML
+    standup -output standup.html
+
     # Date range with specific status
     standup -start 2024-01-01 -end 2024-01-31 -status in_progress
 
+    # Only critical and high-priority items
+    standup -max-priority 2
+
+    # Monday summary of the previous week
+    standup -range week -output week.md
+
     # Full report including completed tasks
     standup -done -output standup.md
 `)
 }
 
-func generateReport(dbURL string, filter FilterOptions, includeDone bool) (*StandupReport, error) {
-	db, err := openDB(dbURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
-	}
-	defer db.Close()
-
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-
-	report := &StandupReport{
-		GeneratedAt: now,
-		DateRange:   today.Format("2006-01-02"),
-	}
-
-	// Fetch overdue tasks
-	overdue, err := fetchTasks(db, TaskQuery{
-		Filter:      filter,
-		Overdue:     true,
-		Today:       today,
-		ExcludeDone: !includeDone,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch overdue tasks: %w", err)
-	}
-	report.OverdueTasks = overdue
-
-	// Fetch due today tasks
-	dueToday, err := fetchTasks(db, TaskQuery{
-		Filter:      filter,
-		DueToday:    true,
-		Today:       today,
-		ExcludeDone: !includeDone,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch due today tasks: %w", err)
-	}
-	report.DueTodayTasks = dueToday
-
-	// Fetch in progress tasks
-	inProgress, err := fetchTasks(db, TaskQuery{
-		Filter:       filter,
-		StatusFilter: "in_progress",
-		ExcludeDone:  !includeDone,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch in progress tasks: %w", err)
-	}
-	report.InProgressTasks = inProgress
-
-	// Fetch completed tasks if requested
-	if includeDone {
-		completed, err := fetchTasks(db, TaskQuery{
-			Filter:       filter,
-			StatusFilter: "completed",
-			CompletedToday: true,
-			Today:         today,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch completed tasks: %w", err)
-		}
-		report.CompletedTasks = completed
-	}
-
-	// Calculate summary
-	report.Summary = Summary{
-		OverdueCount:    len(report.OverdueTasks),
-		DueTodayCount:   len(report.DueTodayTasks),
-		InProgressCount: len(report.InProgressTasks),
-		CompletedCount:  len(report.CompletedTasks),
-	}
-
-	for _, t := range report.OverdueTasks {
-		report.Summary.TotalHours += t.ActualHours
-		if t.BillingStatus == "billed" {
-			report.Summary.BilledHours += t.ActualHours
-		} else {
-			report.Summary.UnbilledHours += t.ActualHours
-		}
-	}
-	for _, t := range report.DueTodayTasks {
-		report.Summary.TotalHours += t.ActualHours
-	}
-	for _, t := range report.InProgressTasks {
-		report.Summary.TotalHours += t.ActualHours
-	}
-
-	report.TotalTasks = report.Summary.OverdueCount + report.Summary.DueTodayCount +
-		report.Summary.InProgressCount + report.Summary.CompletedCount
-
-	return report, nil
-}
-
-// TaskQuery specifies query parameters
-type TaskQuery struct {
-	Filter        FilterOptions
-	Overdue       bool
-	DueToday      bool
-	StatusFilter  string
-	CompletedToday bool
-	Today         time.Time
-	ExcludeDone   bool
-}
-
-func fetchTasks(db *DB, query TaskQuery) ([]Task, error) {
-	var conditions []string
-	var args []interface{}
-	argNum := 1
-
-	// Base condition: not deleted
-	conditions = append(conditions, "1=1")
-
-	// Client filter
-	if query.Filter.Client != "" {
-		conditions = append(conditions, fmt.Sprintf("client ILIKE $%d", argNum))
-		args = append(args, "%"+query.Filter.Client+"%")
-		argNum++
-	}
-
-	// Status filter
-	if query.StatusFilter != "" {
-		conditions = append(conditions, fmt.Sprintf("status = $%d", argNum))
-		args = append(args, query.StatusFilter)
-		argNum++
-	} else if query.Filter.Status != "" {
-		conditions = append(conditions, fmt.Sprintf("status = $%d", argNum))
-		args = append(args, query.Filter.Status)
-		argNum++
-	}
-
-	// Date range filter
-	if query.Filter.StartDate != nil {
-		conditions = append(conditions, fmt.Sprintf("due_date >= $%d", argNum))
-		args = append(args, *query.Filter.StartDate)
-		argNum++
-	}
-	if query.Filter.EndDate != nil {
-		conditions = append(conditions, fmt.Sprintf("due_date <= $%d", argNum))
-		args = append(args, *query.Filter.EndDate)
-		argNum++
-	}
-
-	// Overdue condition
-	if query.Overdue {
-		conditions = append(conditions, fmt.Sprintf("due_date < $%d", argNum))
-		args = append(args, query.Today)
-		argNum++
-		conditions = append(conditions, "status NOT IN ('completed', 'cancelled')")
-	}
-
-	// Due today condition
-	if query.DueToday {
-		tomorrow := query.Today.Add(24 * time.Hour)
-		conditions = append(conditions, fmt.Sprintf("due_date >= $%d AND due_date < $%d", argNum, argNum+1))
-		args = append(args, query.Today, tomorrow)
-		argNum += 2
-		conditions = append(conditions, "status NOT IN ('completed', 'cancelled')")
-	}
-
-	// Completed today
-	if query.CompletedToday {
-		conditions = append(conditions, fmt.Sprintf("DATE(updated_at) = $%d", argNum))
-		args = append(args, query.Today.Format("2006-01-02"))
-		argNum++
-	}
-
-	// Exclude done
-	if query.ExcludeDone && query.StatusFilter == "" {
-		conditions = append(conditions, "status NOT IN ('completed', 'cancelled')")
-	}
-
-	// Build query
-	whereClause := strings.Join(conditions, " AND ")
-	querySQL := fmt.Sprintf(`
-		SELECT id, title, description, client, project, email_subject, email_from,
-		       due_date, status, priority, urgency, assigned_agent, source,
-		       estimated_hours, actual_hours, billing_status, tags, created_at, updated_at
-		FROM tasks
-		WHERE %s
-		ORDER BY 
-			CASE priority 
-				WHEN 1 THEN 1 
-				WHEN 2 THEN 2 
-				WHEN 3 THEN 3 
-				WHEN 4 THEN 4 
-				ELSE 5 
-			END,
-			due_date NULLS LAST,
-			created_at DESC
-	`, whereClause)
-
-	rows, err := db.Query(querySQL, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var tasks []Task
-	for rows.Next() {
-		var t Task
-		var dueDate, emailSubject, emailFrom, description, client, project, assignedAgent sql.NullString
-		var tags []byte
-
-		err := rows.Scan(
-			&t.ID, &t.Title, &description, &client, &project, &emailSubject, &emailFrom,
-			&dueDate, &t.Status, &t.Priority, &t.Urgency, &assignedAgent, &t.Source,
-			&t.EstimatedHours, &t.ActualHours, &t.BillingStatus, &tags, &t.CreatedAt, &t.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		t.Description = nullToString(description)
-		t.Client = nullToString(client)
-		t.Project = nullToString(project)
-		t.EmailSubject = nullToString(emailSubject)
-		t.EmailFrom = nullToString(emailFrom)
-		t.AssignedAgent = nullToString(assignedAgent)
-
-		if dueDate.Valid {
-			if parsed, err := time.Parse("2006-01-02 15:04:05", dueDate.String); err == nil {
-				t.DueDate = &parsed
-			}
-		}
-
-		if len(tags) > 0 {
-			json.Unmarshal(tags, &t.Tags)
-		}
-
-		tasks = append(tasks, t)
-	}
-
-	return tasks, rows.Err()
-}
-
-// DB wraps database connection
-type DB struct {
-	conn interface {
-		Query(query string, args ...interface{}) (*sql.Rows, error)
-		Close() error
-	}
-}
-
-func openDB(dbURL string) (*DB, error) {
-	db, err := sql.Open("postgres", dbURL)
-	if err != nil {
-		return nil, err
-	}
-	if err := db.Ping(); err != nil {
-		return nil, err
-	}
-	return &DB{conn: db}, nil
-}
-
-func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return db.conn.Query(query, args...)
-}
-
-func (db *DB) Close() error {
-	return db.conn.Close()
-}
-
-func nullToString(ns sql.NullString) string {
-	if ns.Valid {
-		return ns.String
-	}
-	return ""
-}
-
-func printConsoleReport(report *StandupReport) {
+func printConsoleReport(report *standup.Report) {
 	fmt.Println()
 	fmt.Println("═══════════════════════════════════════════════════════════════")
-	fmt.Println("                    DAILY STANDUP REPORT")
+	if report.Period == standup.PeriodWeek {
+		fmt.Println("                    WEEKLY STANDUP ROLLUP")
+	} else {
+		fmt.Println("                    DAILY STANDUP REPORT")
+	}
 	fmt.Println("═══════════════════════════════════════════════════════════════")
 	fmt.Printf("Generated: %s\n", report.GeneratedAt.Format("Mon Jan 2, 2006 3:04 PM"))
+	fmt.Printf("Period:    %s\n", report.DateRange)
 	fmt.Println()
 
 	// Summary
@@ -488,6 +188,8 @@ func printConsoleReport(report *StandupReport) {
 	fmt.Printf("│  In Progress:  %3d tasks                                    │\n", report.Summary.InProgressCount)
 	fmt.Printf("│  Completed:    %3d tasks                                    │\n", report.Summary.CompletedCount)
 	fmt.Printf("│  Total Active: %3d tasks                                    │\n", report.Summary.OverdueCount+report.Summary.DueTodayCount+report.Summary.InProgressCount)
+	fmt.Printf("│  Hours:        %5.1f actual / %5.1f estimated               │\n", report.Summary.TotalHours, report.Summary.EstimatedTotal)
+	fmt.Printf("│  Completion:   %5.1f%%                                       │\n", report.Summary.CompletionRate*100)
 	fmt.Println("└─────────────────────────────────────────────────────────────┘")
 	fmt.Println()
 
@@ -520,7 +222,11 @@ func printConsoleReport(report *StandupReport) {
 
 	// Completed Tasks
 	if len(report.CompletedTasks) > 0 {
-		fmt.Printf("\n✅ COMPLETED TODAY (%d)\n", len(report.CompletedTasks))
+		if report.Period == standup.PeriodWeek {
+			fmt.Printf("\n✅ COMPLETED THIS WEEK (%d)\n", len(report.CompletedTasks))
+		} else {
+			fmt.Printf("\n✅ COMPLETED TODAY (%d)\n", len(report.CompletedTasks))
+		}
 		fmt.Println("─────────────────────────────────────────────────────────────────")
 		for _, t := range report.CompletedTasks {
 			printTaskCard(t, false)
@@ -536,14 +242,14 @@ func printConsoleReport(report *StandupReport) {
 	fmt.Println("═══════════════════════════════════════════════════════════════")
 }
 
-func printTaskCard(t Task, showOverdue bool) {
+func printTaskCard(t standup.Task, showOverdue bool) {
 	priorityIcon := getPriorityIcon(t.Priority)
 	client := t.Client
 	if client == "" {
 		client = "No Client"
 	}
 
-	fmt.Printf("\n  %s [%s] %s\n", priorityIcon, t.ID[:8], t.Title)
+	fmt.Printf("\n  %s [%s] %s\n", priorityIcon, shortID(t.ID), t.Title)
 	if t.Description != "" {
 		desc := t.Description
 		if len(desc) > 80 {
@@ -579,6 +285,15 @@ func printTaskCard(t Task, showOverdue bool) {
 	}
 }
 
+// shortID returns up to the first 8 runes of a task ID for display
+func shortID(id string) string {
+	runes := []rune(id)
+	if len(runes) > 8 {
+		return string(runes[:8])
+	}
+	return id
+}
+
 func getPriorityIcon(priority int) string {
 	switch priority {
 	case 1:
@@ -594,7 +309,7 @@ func getPriorityIcon(priority int) string {
 	}
 }
 
-func printJSONReport(report *StandupReport) {
+func printJSONReport(report *standup.Report) {
 	data, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
@@ -603,7 +318,7 @@ func printJSONReport(report *StandupReport) {
 	fmt.Println(string(data))
 }
 
-func writeJSONReport(report *StandupReport, path string) error {
+func writeJSONReport(report *standup.Report, path string) error {
 	data, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		return err
@@ -611,9 +326,10 @@ func writeJSONReport(report *StandupReport, path string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
-func writeMarkdownReport(report *StandupReport, path string) error {
-	tmpl := `# Daily Standup Report
+func writeMarkdownReport(report *standup.Report, path string) error {
+	tmpl := `# {{if eq .Period "week"}}Weekly Standup Rollup{{else}}Daily Standup Report{{end}}
 **Generated:** {{.GeneratedAt.Format "Mon Jan 2, 2006 3:04 PM"}}
+**Period:** {{.DateRange}}
 
 ## Summary
 
@@ -629,7 +345,7 @@ func writeMarkdownReport(report *StandupReport, path string) error {
 ## 🔴 Overdue Tasks ({{len .OverdueTasks}})
 
 {{range .OverdueTasks}}
-- **[{{.ID | printf "%.8s"}}]** {{.Title}}
+- **[{{shortID .ID}}]** {{.Title}}
   - Client: {{if .Client}}{{.Client}}{{else}}No Client{{end}}
   - Priority: {{.Priority}} | Status: {{.Status}}
   {{- if .DueDate}}
@@ -642,7 +358,7 @@ func writeMarkdownReport(report *StandupReport, path string) error {
 ## 🟡 Due Today ({{len .DueTodayTasks}})
 
 {{range .DueTodayTasks}}
-- **[{{.ID | printf "%.8s"}}]** {{.Title}}
+- **[{{shortID .ID}}]** {{.Title}}
   - Client: {{if .Client}}{{.Client}}{{else}}No Client{{end}}
   - Priority: {{.Priority}} | Status: {{.Status}}
 {{end}}
@@ -652,7 +368,7 @@ func writeMarkdownReport(report *StandupReport, path string) error {
 ## 🟢 In Progress ({{len .InProgressTasks}})
 
 {{range .InProgressTasks}}
-- **[{{.ID | printf "%.8s"}}]** {{.Title}}
+- **[{{shortID .ID}}]** {{.Title}}
   - Client: {{if .Client}}{{.Client}}{{else}}No Client{{end}}
   - Priority: {{.Priority}} | Status: {{.Status}}
   {{- if .DueDate}}
@@ -662,10 +378,10 @@ func writeMarkdownReport(report *StandupReport, path string) error {
 {{end}}
 
 {{if gt (len .CompletedTasks) 0}}
-## ✅ Completed Today ({{len .CompletedTasks}})
+## ✅ Completed {{if eq .Period "week"}}This Week{{else}}Today{{end}} ({{len .CompletedTasks}})
 
 {{range .CompletedTasks}}
-- **[{{.ID | printf "%.8s"}}]** {{.Title}}
+- **[{{shortID .ID}}]** {{.Title}}
   - Client: {{if .Client}}{{.Client}}{{else}}No Client{{end}}
   - 🎉 Completed
 {{end}}
@@ -675,7 +391,94 @@ func writeMarkdownReport(report *StandupReport, path string) error {
 No tasks found matching the criteria.
 {{end}}
 `
-	t, err := template.New("report").Parse(tmpl)
+	t, err := template.New("report").Funcs(template.FuncMap{"shortID": shortID}).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+
+	var buf strings.Builder
+	if err := t.Execute(&buf, report); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(buf.String()), 0644)
+}
+
+// priorityColor maps a task priority to the dot color used in HTML output
+func priorityColor(priority int) string {
+	switch priority {
+	case 1:
+		return "#d32f2f" // Critical
+	case 2:
+		return "#f57c00" // High
+	case 3:
+		return "#fbc02d" // Medium
+	case 4:
+		return "#388e3c" // Low
+	default:
+		return "#9e9e9e"
+	}
+}
+
+func writeHTMLReport(report *standup.Report, path string) error {
+	// Styles are inlined so the document survives being pasted into an email client
+	tmpl := `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{if eq .Period "week"}}Weekly Standup Rollup{{else}}Daily Standup Report{{end}} {{.DateRange}}</title>
+</head>
+<body style="font-family: -apple-system, Helvetica, Arial, sans-serif; color: #212121; max-width: 720px; margin: 0 auto; padding: 16px;">
+<h1 style="font-size: 22px; margin-bottom: 4px;">{{if eq .Period "week"}}Weekly Standup Rollup{{else}}Daily Standup Report{{end}}</h1>
+<p style="color: #616161; margin-top: 0;">Generated {{.GeneratedAt.Format "Mon Jan 2, 2006 3:04 PM"}} &middot; {{.DateRange}}</p>
+
+<table style="border-collapse: collapse; margin: 16px 0;">
+<tr><th style="text-align: left; padding: 4px 16px 4px 0; border-bottom: 1px solid #e0e0e0;">Category</th><th style="text-align: right; padding: 4px 0; border-bottom: 1px solid #e0e0e0;">Count</th></tr>
+<tr><td style="padding: 4px 16px 4px 0;">Overdue</td><td style="text-align: right;">{{.Summary.OverdueCount}}</td></tr>
+<tr><td style="padding: 4px 16px 4px 0;">Due Today</td><td style="text-align: right;">{{.Summary.DueTodayCount}}</td></tr>
+<tr><td style="padding: 4px 16px 4px 0;">In Progress</td><td style="text-align: right;">{{.Summary.InProgressCount}}</td></tr>
+<tr><td style="padding: 4px 16px 4px 0;">Completed</td><td style="text-align: right;">{{.Summary.CompletedCount}}</td></tr>
+<tr><td style="padding: 4px 16px 4px 0; border-top: 1px solid #e0e0e0;"><strong>Total</strong></td><td style="text-align: right; border-top: 1px solid #e0e0e0;"><strong>{{.TotalTasks}}</strong></td></tr>
+</table>
+{{template "section" (section "Overdue" "#d32f2f" .OverdueTasks)}}
+{{template "section" (section "Due Today" "#f9a825" .DueTodayTasks)}}
+{{template "section" (section "In Progress" "#388e3c" .InProgressTasks)}}
+{{template "section" (section (printf "Completed %s" (completedLabel .Period)) "#1976d2" .CompletedTasks)}}
+{{if eq .TotalTasks 0}}<p>No tasks found matching the criteria.</p>{{end}}
+</body>
+</html>
+{{define "section"}}{{if .Tasks}}
+<h2 style="font-size: 18px; margin: 24px 0 8px;"><span style="display: inline-block; background: {{.Color}}; color: #ffffff; border-radius: 10px; padding: 2px 10px; font-size: 14px;">{{.Title}} ({{len .Tasks}})</span></h2>
+<ul style="list-style: none; padding-left: 0;">
+{{range .Tasks}}<li style="margin-bottom: 10px;">
+<span style="display: inline-block; width: 10px; height: 10px; border-radius: 50%; background: {{priorityColor .Priority}};" title="Priority {{.Priority}}"></span>
+<code style="color: #757575;">{{shortID .ID}}</code> <strong>{{.Title}}</strong><br>
+<span style="color: #616161; font-size: 13px;">Client: {{if .Client}}{{.Client}}{{else}}No Client{{end}}{{if .Project}} | Project: {{.Project}}{{end}}{{if .DueDate}} | Due: {{.DueDate.Format "Jan 2, 2006"}}{{end}}</span>
+</li>
+{{end}}</ul>
+{{end}}{{end}}`
+
+	type htmlSection struct {
+		Title string
+		Color string
+		Tasks []standup.Task
+	}
+
+	funcs := htmltemplate.FuncMap{
+		"shortID":       shortID,
+		"priorityColor": func(p int) htmltemplate.CSS { return htmltemplate.CSS(priorityColor(p)) },
+		"section": func(title, color string, tasks []standup.Task) htmlSection {
+			return htmlSection{Title: title, Color: color, Tasks: tasks}
+		},
+		"completedLabel": func(p standup.Period) string {
+			if p == standup.PeriodWeek {
+				return "This Week"
+			}
+			return "Today"
+		},
+	}
+
+	t, err := htmltemplate.New("report").Funcs(funcs).Parse(tmpl)
 	if err != nil {
 		return err
 	}
@@ -686,4 +489,4 @@ No tasks found matching the criteria.
 	}
 
 	return os.WriteFile(path, []byte(buf.String()), 0644)
-}
\ No newline at end of file
+}
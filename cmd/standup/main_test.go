@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ericksa/mymcp/internal/standup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"short legacy id", "abc", "abc"},
+		{"exactly eight", "12345678", "12345678"},
+		{"uuid", "3f2b9c1e-7a4d-4e2b-9f1a-1234567890ab", "3f2b9c1e"},
+		{"multibyte", "🔥task-id-long", "🔥task-id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NotPanics(t, func() { shortID(tt.id) })
+			assert.Equal(t, tt.want, shortID(tt.id))
+		})
+	}
+}
+
+func TestWriteHTMLReportEscapesContent(t *testing.T) {
+	due := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	report := &standup.Report{
+		GeneratedAt: due,
+		Period:      standup.PeriodDay,
+		DateRange:   "2024-01-15",
+		TotalTasks:  1,
+		OverdueTasks: []standup.Task{
+			{ID: "abc", Title: "<script>alert(1)</script>", Client: "Acme & Co", Priority: 1, DueDate: &due},
+		},
+		Summary: standup.Summary{OverdueCount: 1},
+	}
+
+	path := filepath.Join(t.TempDir(), "report.html")
+	require.NoError(t, writeHTMLReport(report, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	html := string(data)
+
+	assert.True(t, strings.HasPrefix(html, "<!DOCTYPE html>"))
+	assert.NotContains(t, html, "<script>")
+	assert.Contains(t, html, "&lt;script&gt;")
+	assert.Contains(t, html, "Acme &amp; Co")
+	assert.Contains(t, html, "background: #d32f2f")
+	assert.Contains(t, html, "Overdue (1)")
+	assert.NotContains(t, html, "In Progress (")
+}
@@ -3,6 +3,7 @@ module github.com/ericksa/mymcp
 go 1.24.0
 
 require (
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/gorilla/mux v1.8.1
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.11.2
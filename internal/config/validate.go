@@ -6,6 +6,7 @@ import (
 	"net"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // Validate checks the configuration for errors
@@ -20,6 +21,18 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server address: %v", err)
 	}
 
+	// Validate tool timeouts
+	if c.MCP.Server.ToolTimeout != "" {
+		if _, err := time.ParseDuration(c.MCP.Server.ToolTimeout); err != nil {
+			return fmt.Errorf("invalid server tool_timeout: %v", err)
+		}
+	}
+	for tool, timeout := range c.MCP.Server.ToolTimeouts {
+		if _, err := time.ParseDuration(timeout); err != nil {
+			return fmt.Errorf("invalid tool_timeouts entry for %s: %v", tool, err)
+		}
+	}
+
 	// Validate auth configuration
 	if c.MCP.Auth.Token == "" {
 		return errors.New("auth token cannot be empty")
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strings"
@@ -54,14 +57,69 @@ func AuthMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
 	}
 }
 
-func CORS(origins []string) func(http.Handler) http.Handler {
+// MaxBytes rejects request bodies over limit bytes with 413. The body is read up
+// front, so the limit holds even for handlers that never read it themselves.
+func MaxBytes(limit int64) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusOK)
+			if limit > 0 && r.Body != nil {
+				body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+				if err != nil {
+					var tooLarge *http.MaxBytesError
+					if errors.As(err, &tooLarge) {
+						http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+						return
+					}
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CORS adds cross-origin headers for requests from an allowed origin and answers
+// preflight OPTIONS requests with 204. With no allowed origins it is a no-op.
+func CORS(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	allowAll := false
+	allowed := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[origin] = true
+	}
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+
+	return func(next http.Handler) http.Handler {
+		if len(allowed) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || !(allowAll || allowed[origin]) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowAll {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if methods != "" {
+					w.Header().Set("Access-Control-Allow-Methods", methods)
+				}
+				if headers != "" {
+					w.Header().Set("Access-Control-Allow-Headers", headers)
+				}
+				w.WriteHeader(http.StatusNoContent)
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -0,0 +1,415 @@
+// Package standup builds daily and weekly standup reports from the tasks table.
+// It is shared by the standup CLI and the gateway's /standup endpoint.
+package standup
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// Task represents a task from the database
+type Task struct {
+	ID             string     `json:"id"`
+	Title          string     `json:"title"`
+	Description    string     `json:"description"`
+	Client         string     `json:"client"`
+	Project        string     `json:"project"`
+	EmailSubject   string     `json:"email_subject"`
+	EmailFrom      string     `json:"email_from"`
+	DueDate        *time.Time `json:"due_date"`
+	Status         string     `json:"status"`
+	Priority       int        `json:"priority"`
+	Urgency        string     `json:"urgency"`
+	AssignedAgent  string     `json:"assigned_agent"`
+	Source         string     `json:"source"`
+	EstimatedHours float64    `json:"estimated_hours"`
+	ActualHours    float64    `json:"actual_hours"`
+	BillingStatus  string     `json:"billing_status"`
+	Tags           []string   `json:"tags"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TimeEntry represents a time entry from the database
+type TimeEntry struct {
+	ID              string     `json:"id"`
+	TaskID          string     `json:"task_id"`
+	StartedAt       *time.Time `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Description     string     `json:"description"`
+	AgentID         string     `json:"agent_id"`
+}
+
+// Report represents the generated standup report
+type Report struct {
+	GeneratedAt     time.Time `json:"generated_at"`
+	Period          Period    `json:"period"`
+	DateRange       string    `json:"date_range"`
+	TotalTasks      int       `json:"total_tasks"`
+	OverdueTasks    []Task    `json:"overdue_tasks"`
+	DueTodayTasks   []Task    `json:"due_today_tasks"`
+	InProgressTasks []Task    `json:"in_progress_tasks"`
+	CompletedTasks  []Task    `json:"completed_tasks"`
+	Summary         Summary   `json:"summary"`
+}
+
+// Summary provides high-level stats
+type Summary struct {
+	OverdueCount    int     `json:"overdue_count"`
+	DueTodayCount   int     `json:"due_today_count"`
+	InProgressCount int     `json:"in_progress_count"`
+	CompletedCount  int     `json:"completed_count"`
+	TotalHours      float64 `json:"total_hours"`
+	BilledHours     float64 `json:"billed_hours"`
+	UnbilledHours   float64 `json:"unbilled_hours"`
+	EstimatedTotal  float64 `json:"estimated_total"`
+	CompletionRate  float64 `json:"completion_rate"` // completed / (active + completed)
+	AvgPriority     float64 `json:"avg_priority"`
+}
+
+// FilterOptions for query filtering
+type FilterOptions struct {
+	Client      string
+	Status      string
+	StartDate   *time.Time
+	EndDate     *time.Time
+	MaxPriority int // 1 (critical) through 4 (low); 5 or more disables the filter
+}
+
+// Period selects the window a report covers
+type Period string
+
+const (
+	PeriodDay  Period = "day"
+	PeriodWeek Period = "week"
+)
+
+// ParsePeriod converts a range value ("day" or "week") into a Period
+func ParsePeriod(s string) (Period, error) {
+	switch Period(strings.ToLower(s)) {
+	case "", PeriodDay:
+		return PeriodDay, nil
+	case PeriodWeek:
+		return PeriodWeek, nil
+	default:
+		return "", fmt.Errorf("unknown range %q (expected day or week)", s)
+	}
+}
+
+// Generate connects to dbURL and builds a report for the given filter and period
+func Generate(dbURL string, filter FilterOptions, includeDone bool, period Period) (*Report, error) {
+	db, err := openDB(dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// Completed window: today for the daily report, the previous 7 days for the weekly rollup
+	rangeStart, rangeEnd := today, today.AddDate(0, 0, 1)
+	if period == PeriodWeek {
+		rangeStart, rangeEnd = today.AddDate(0, 0, -7), today
+	}
+
+	report := &Report{
+		GeneratedAt: now,
+		Period:      period,
+		DateRange:   formatDateRange(rangeStart, rangeEnd),
+	}
+
+	// Fetch overdue tasks
+	overdue, err := fetchTasks(db, TaskQuery{
+		Filter:      filter,
+		Overdue:     true,
+		Today:       today,
+		ExcludeDone: !includeDone,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch overdue tasks: %w", err)
+	}
+	report.OverdueTasks = overdue
+
+	// Fetch due today tasks
+	dueToday, err := fetchTasks(db, TaskQuery{
+		Filter:      filter,
+		DueToday:    true,
+		Today:       today,
+		ExcludeDone: !includeDone,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch due today tasks: %w", err)
+	}
+	report.DueTodayTasks = dueToday
+
+	// Fetch in progress tasks
+	inProgress, err := fetchTasks(db, TaskQuery{
+		Filter:       filter,
+		StatusFilter: "in_progress",
+		ExcludeDone:  !includeDone,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch in progress tasks: %w", err)
+	}
+	report.InProgressTasks = inProgress
+
+	// Fetch completed tasks if requested (the weekly rollup always includes them)
+	if includeDone || period == PeriodWeek {
+		completed, err := fetchTasks(db, TaskQuery{
+			Filter:           filter,
+			StatusFilter:     "completed",
+			CompletedInRange: true,
+			RangeStart:       rangeStart,
+			RangeEnd:         rangeEnd,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch completed tasks: %w", err)
+		}
+		report.CompletedTasks = completed
+	}
+
+	// Calculate summary
+	report.Summary = Summary{
+		OverdueCount:    len(report.OverdueTasks),
+		DueTodayCount:   len(report.DueTodayTasks),
+		InProgressCount: len(report.InProgressTasks),
+		CompletedCount:  len(report.CompletedTasks),
+	}
+
+	for _, t := range report.OverdueTasks {
+		report.Summary.TotalHours += t.ActualHours
+		report.Summary.EstimatedTotal += t.EstimatedHours
+		if t.BillingStatus == "billed" {
+			report.Summary.BilledHours += t.ActualHours
+		} else {
+			report.Summary.UnbilledHours += t.ActualHours
+		}
+	}
+	for _, t := range report.DueTodayTasks {
+		report.Summary.TotalHours += t.ActualHours
+		report.Summary.EstimatedTotal += t.EstimatedHours
+	}
+	for _, t := range report.InProgressTasks {
+		report.Summary.TotalHours += t.ActualHours
+		report.Summary.EstimatedTotal += t.EstimatedHours
+	}
+
+	report.TotalTasks = report.Summary.OverdueCount + report.Summary.DueTodayCount +
+		report.Summary.InProgressCount + report.Summary.CompletedCount
+
+	// Velocity stats (guard against empty reports)
+	if report.TotalTasks > 0 {
+		report.Summary.CompletionRate = float64(report.Summary.CompletedCount) / float64(report.TotalTasks)
+
+		prioritySum := 0
+		for _, group := range [][]Task{report.OverdueTasks, report.DueTodayTasks, report.InProgressTasks, report.CompletedTasks} {
+			for _, t := range group {
+				prioritySum += t.Priority
+			}
+		}
+		report.Summary.AvgPriority = float64(prioritySum) / float64(report.TotalTasks)
+	}
+
+	return report, nil
+}
+
+// formatDateRange renders [start, end) as a single date or an inclusive start–end span
+func formatDateRange(start, end time.Time) string {
+	last := end.AddDate(0, 0, -1)
+	if !last.After(start) {
+		return start.Format("2006-01-02")
+	}
+	return start.Format("2006-01-02") + " – " + last.Format("2006-01-02")
+}
+
+// TaskQuery specifies query parameters
+type TaskQuery struct {
+	Filter           FilterOptions
+	Overdue          bool
+	DueToday         bool
+	StatusFilter     string
+	CompletedInRange bool // updated_at within [RangeStart, RangeEnd)
+	RangeStart       time.Time
+	RangeEnd         time.Time
+	Today            time.Time
+	ExcludeDone      bool
+}
+
+func fetchTasks(db *DB, query TaskQuery) ([]Task, error) {
+	var conditions []string
+	var args []interface{}
+	argNum := 1
+
+	// Base condition: not deleted
+	conditions = append(conditions, "deleted_at IS NULL")
+
+	// Client filter
+	if query.Filter.Client != "" {
+		conditions = append(conditions, fmt.Sprintf("client ILIKE $%d", argNum))
+		args = append(args, "%"+query.Filter.Client+"%")
+		argNum++
+	}
+
+	// Status filter
+	if query.StatusFilter != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argNum))
+		args = append(args, query.StatusFilter)
+		argNum++
+	} else if query.Filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argNum))
+		args = append(args, query.Filter.Status)
+		argNum++
+	}
+
+	// Priority threshold (lower number = higher priority)
+	if query.Filter.MaxPriority > 0 && query.Filter.MaxPriority < 5 {
+		conditions = append(conditions, fmt.Sprintf("priority <= $%d", argNum))
+		args = append(args, query.Filter.MaxPriority)
+		argNum++
+	}
+
+	// Date range filter
+	if query.Filter.StartDate != nil {
+		conditions = append(conditions, fmt.Sprintf("due_date >= $%d", argNum))
+		args = append(args, *query.Filter.StartDate)
+		argNum++
+	}
+	if query.Filter.EndDate != nil {
+		conditions = append(conditions, fmt.Sprintf("due_date <= $%d", argNum))
+		args = append(args, *query.Filter.EndDate)
+		argNum++
+	}
+
+	// Overdue condition
+	if query.Overdue {
+		conditions = append(conditions, fmt.Sprintf("due_date < $%d", argNum))
+		args = append(args, query.Today)
+		argNum++
+		conditions = append(conditions, "status NOT IN ('completed', 'cancelled')")
+	}
+
+	// Due today condition
+	if query.DueToday {
+		tomorrow := query.Today.Add(24 * time.Hour)
+		conditions = append(conditions, fmt.Sprintf("due_date >= $%d AND due_date < $%d", argNum, argNum+1))
+		args = append(args, query.Today, tomorrow)
+		argNum += 2
+		conditions = append(conditions, "status NOT IN ('completed', 'cancelled')")
+	}
+
+	// Completed within the report window
+	if query.CompletedInRange {
+		conditions = append(conditions, fmt.Sprintf("updated_at >= $%d AND updated_at < $%d", argNum, argNum+1))
+		args = append(args, query.RangeStart, query.RangeEnd)
+		argNum += 2
+	}
+
+	// Exclude done
+	if query.ExcludeDone && query.StatusFilter == "" {
+		conditions = append(conditions, "status NOT IN ('completed', 'cancelled')")
+	}
+
+	// Build query
+	whereClause := strings.Join(conditions, " AND ")
+	querySQL := fmt.Sprintf(`
+		SELECT id, title, description, client, project, email_subject, email_from,
+		       due_date, status, priority, urgency, assigned_agent, source,
+		       estimated_hours, actual_hours, billing_status, tags, created_at, updated_at
+		FROM tasks
+		WHERE %s
+		ORDER BY 
+			CASE priority 
+				WHEN 1 THEN 1 
+				WHEN 2 THEN 2 
+				WHEN 3 THEN 3 
+				WHEN 4 THEN 4 
+				ELSE 5 
+			END,
+			due_date NULLS LAST,
+			created_at DESC
+	`, whereClause)
+
+	rows, err := db.Query(querySQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var t Task
+		var dueDate, emailSubject, emailFrom, description, client, project, assignedAgent sql.NullString
+		var tags []byte
+
+		err := rows.Scan(
+			&t.ID, &t.Title, &description, &client, &project, &emailSubject, &emailFrom,
+			&dueDate, &t.Status, &t.Priority, &t.Urgency, &assignedAgent, &t.Source,
+			&t.EstimatedHours, &t.ActualHours, &t.BillingStatus, &tags, &t.CreatedAt, &t.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		t.Description = nullToString(description)
+		t.Client = nullToString(client)
+		t.Project = nullToString(project)
+		t.EmailSubject = nullToString(emailSubject)
+		t.EmailFrom = nullToString(emailFrom)
+		t.AssignedAgent = nullToString(assignedAgent)
+
+		if dueDate.Valid {
+			if parsed, err := time.Parse("2006-01-02 15:04:05", dueDate.String); err == nil {
+				t.DueDate = &parsed
+			}
+		}
+
+		if len(tags) > 0 {
+			json.Unmarshal(tags, &t.Tags)
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	return tasks, rows.Err()
+}
+
+// DB wraps database connection
+type DB struct {
+	conn interface {
+		Query(query string, args ...interface{}) (*sql.Rows, error)
+		Close() error
+	}
+}
+
+func openDB(dbURL string) (*DB, error) {
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+	return &DB{conn: db}, nil
+}
+
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.conn.Query(query, args...)
+}
+
+func (db *DB) Close() error {
+	return db.conn.Close()
+}
+
+func nullToString(ns sql.NullString) string {
+	if ns.Valid {
+		return ns.String
+	}
+	return ""
+}
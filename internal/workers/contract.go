@@ -4,19 +4,46 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 )
 
 // ContractWorker handles legal document analysis
 type ContractWorkerState struct {
 	Tools     []ToolDef
-	Contracts map[string]Contract
+	Contracts map[string]Contract // cache; Store is the source of truth when set
+	mu        sync.RWMutex        // guards Contracts
+	Store     ContractStore
 	RAGWorker *RAGWorkerState
 	LLMCaller LLMCaller
+
+	// DayFirstDates reads ambiguous numeric dates like 03/04/2024 as 3 April rather than March 4
+	DayFirstDates bool
+
+	// RequiredClauses are the clause types contract_gap_check expects by default
+	RequiredClauses []string
+
+	// RiskWeights is the score deducted per risk of each severity
+	RiskWeights map[string]float64
+
+	// MaxFileBytes caps the size of a source file parse will read; 0 uses DefaultMaxContractFileBytes
+	MaxFileBytes int64
+
+	// PDFExtractor converts a PDF source to text. Without one, PDF sources are rejected.
+	PDFExtractor func(path string) (string, error)
 }
 
+// DefaultMaxContractFileBytes is the source file size limit when none is configured
+const DefaultMaxContractFileBytes = 10 << 20
+
 type LLMCaller interface {
 	Call(ctx context.Context, prompt string, systemPrompt string) (string, error)
 }
@@ -109,6 +136,22 @@ var ClauseTypes = []string{
 	"ownership",
 }
 
+// DefaultRequiredClauses are the protective clauses most agreements should contain
+var DefaultRequiredClauses = []string{
+	"termination",
+	"liability",
+	"confidentiality",
+	"governing_law",
+	"dispute_resolution",
+}
+
+// DefaultRiskWeights are the per-severity deductions used when none are configured
+var DefaultRiskWeights = map[string]float64{
+	"critical": 25,
+	"high":     15,
+	"medium":   5,
+}
+
 func NewContractWorkerState() *ContractWorkerState {
 	return &ContractWorkerState{
 		Tools: []ToolDef{
@@ -120,8 +163,14 @@ func NewContractWorkerState() *ContractWorkerState {
 			{Name: "contract_qa", Description: "Answer questions about contract"},
 			{Name: "contract_list", Description: "List all parsed contracts"},
 			{Name: "contract_get", Description: "Get contract by ID"},
+			{Name: "contract_expiring", Description: "List contracts expiring within a number of days"},
+			{Name: "contract_value_summary", Description: "Total contract value per currency, optionally converted to a base currency"},
+			{Name: "contract_gap_check", Description: "Find expected clause types missing from a contract"},
+			{Name: "contract_parse_batch", Description: "Parse every contract file in a directory matching a glob"},
 		},
-		Contracts: make(map[string]Contract),
+		Contracts:       make(map[string]Contract),
+		RequiredClauses: slices.Clone(DefaultRequiredClauses),
+		RiskWeights:     maps.Clone(DefaultRiskWeights),
 	}
 }
 
@@ -147,6 +196,14 @@ func (w *ContractWorkerState) Execute(ctx context.Context, name string, input js
 		return w.list(ctx, input)
 	case "contract_contract_get", "contract_get":
 		return w.get(ctx, input)
+	case "contract_contract_expiring", "contract_expiring":
+		return w.expiring(ctx, input)
+	case "contract_contract_value_summary", "contract_value_summary":
+		return w.valueSummary(ctx, input)
+	case "contract_contract_gap_check", "contract_gap_check":
+		return w.gapCheck(ctx, input)
+	case "contract_contract_parse_batch", "contract_parse_batch":
+		return w.parseBatch(ctx, input)
 	default:
 		return nil, nil
 	}
@@ -157,6 +214,51 @@ func (w *ContractWorkerState) SetRAGWorker(rag *RAGWorkerState) {
 	w.RAGWorker = rag
 }
 
+// SetStore enables persistence of parsed contracts
+func (w *ContractWorkerState) SetStore(store ContractStore) {
+	w.Store = store
+}
+
+// lookup returns a contract from the cache, falling back to the store on a miss
+func (w *ContractWorkerState) lookup(id string) (Contract, bool) {
+	w.mu.RLock()
+	c, ok := w.Contracts[id]
+	w.mu.RUnlock()
+	if ok {
+		return c, true
+	}
+	if w.Store == nil {
+		return Contract{}, false
+	}
+	c, err := w.Store.Load(id)
+	if err != nil {
+		return Contract{}, false
+	}
+	w.mu.Lock()
+	w.Contracts[id] = c
+	w.mu.Unlock()
+	return c, true
+}
+
+// loadStored merges persisted contracts into the cache so listings cover both
+func (w *ContractWorkerState) loadStored() error {
+	if w.Store == nil {
+		return nil
+	}
+	stored, err := w.Store.List()
+	if err != nil {
+		return fmt.Errorf("failed to list stored contracts: %w", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, c := range stored {
+		if _, ok := w.Contracts[c.ID]; !ok {
+			w.Contracts[c.ID] = c
+		}
+	}
+	return nil
+}
+
 // SetLLMCaller sets the LLM caller for AI analysis
 func (w *ContractWorkerState) SetLLMCaller(caller LLMCaller) {
 	w.LLMCaller = caller
@@ -181,12 +283,15 @@ func (w *ContractWorkerState) parse(ctx context.Context, input json.RawMessage)
 	// Use provided content or load from source
 	content := req.Content
 	if content == "" && req.Source != "" {
-		// Would load from file - for now, require content
-		return nil, fmt.Errorf("content required (file loading not implemented)")
+		loaded, err := w.loadSource(req.Source)
+		if err != nil {
+			return nil, err
+		}
+		content = loaded
 	}
 
 	contract := Contract{
-		ID:         generateDocID(req.Source + req.Title + time.Now().Format(time.RFC3339)),
+		ID:         generateDocID("contract", req.Source+req.Title+content+time.Now().Format(time.RFC3339Nano)),
 		Title:      req.Title,
 		Source:     req.Source,
 		RawText:    content,
@@ -202,8 +307,11 @@ func (w *ContractWorkerState) parse(ctx context.Context, input json.RawMessage)
 	// Extract value
 	contract.Value, contract.Currency = w.extractValue(content)
 
-	// Extract clauses
+	// Extract clauses, adding any the LLM finds that the regexes missed
 	contract.Clauses = w.extractClauses(content)
+	if w.LLMCaller != nil {
+		contract.Clauses = mergeClauses(contract.Clauses, w.extractClausesLLM(ctx, content))
+	}
 
 	// Extract key terms
 	contract.Terms = w.extractTerms(content)
@@ -214,15 +322,22 @@ func (w *ContractWorkerState) parse(ctx context.Context, input json.RawMessage)
 	// Generate summary using LLM if available
 	if w.LLMCaller != nil {
 		summary, err := w.LLMCaller.Call(ctx,
-			fmt.Sprintf("Summarize this contract in 3-5 bullet points. Focus on: parties, key obligations, duration, and any unusual terms.\n\nContract:\n%s", content[:min(len(content), 8000)]),
+			fmt.Sprintf("Summarize this contract in 3-5 bullet points. Focus on: parties, key obligations, duration, and any unusual terms.\n\nContract:\n%s", truncateUTF8(content, 8000)),
 			"You are a legal assistant summarizing contracts.")
 		if err == nil {
 			contract.Summary = summary
 		}
 	}
 
-	// Store contract
+	// Store contract (write-through when persistence is enabled)
+	if w.Store != nil {
+		if err := w.Store.Save(contract); err != nil {
+			return nil, fmt.Errorf("failed to persist contract: %w", err)
+		}
+	}
+	w.mu.Lock()
 	w.Contracts[contract.ID] = contract
+	w.mu.Unlock()
 
 	// Also ingest into RAG if available
 	if w.RAGWorker != nil {
@@ -248,6 +363,124 @@ func (w *ContractWorkerState) parse(ctx context.Context, input json.RawMessage)
 	})
 }
 
+// loadSource reads contract text from a file path. PDFs go through PDFExtractor;
+// anything else is read as plain text.
+func (w *ContractWorkerState) loadSource(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read source: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("source is a directory: %s", path)
+	}
+
+	limit := w.MaxFileBytes
+	if limit <= 0 {
+		limit = DefaultMaxContractFileBytes
+	}
+	if info.Size() > limit {
+		return "", fmt.Errorf("source file too large: %d bytes (max %d)", info.Size(), limit)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".pdf") {
+		if w.PDFExtractor == nil {
+			return "", fmt.Errorf("pdf extraction not supported: no extractor configured")
+		}
+		text, err := w.PDFExtractor(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract pdf text: %w", err)
+		}
+		return text, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read source: %w", err)
+	}
+	return string(data), nil
+}
+
+// batchResult is the per-file outcome of contract_parse_batch
+type batchResult struct {
+	File        string `json:"file"`
+	ContractID  string `json:"contract_id,omitempty"`
+	ClauseCount int    `json:"clause_count"`
+	RiskCount   int    `json:"risk_count"`
+	Error       string `json:"error,omitempty"`
+}
+
+// parseBatch runs parse over each file in a directory. A failing file is recorded
+// and skipped; cancelling ctx stops the batch between files.
+func (w *ContractWorkerState) parseBatch(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		Dir  string `json:"dir"`
+		Glob string `json:"glob"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+	if req.Dir == "" {
+		return nil, fmt.Errorf("dir required")
+	}
+	if req.Glob == "" {
+		req.Glob = "*.txt"
+	}
+
+	files, err := filepath.Glob(filepath.Join(req.Dir, req.Glob))
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob: %w", err)
+	}
+	sort.Strings(files)
+
+	results := make([]batchResult, 0, len(files))
+	failed := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("batch cancelled after %d of %d files: %w", len(results), len(files), err)
+		}
+
+		result := w.parseFile(ctx, file)
+		if result.Error != "" {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	return json.Marshal(map[string]any{
+		"dir":     req.Dir,
+		"total":   len(results),
+		"failed":  failed,
+		"results": results,
+	})
+}
+
+// parseFile runs one contract file through the single-parse path, which loads it from disk
+func (w *ContractWorkerState) parseFile(ctx context.Context, path string) batchResult {
+	result := batchResult{File: path}
+
+	title := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	input, _ := json.Marshal(map[string]string{"source": path, "title": title})
+	out, err := w.parse(ctx, input)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	var parsed struct {
+		ContractID  string `json:"contract_id"`
+		ClauseCount int    `json:"clause_count"`
+		RiskCount   int    `json:"risk_count"`
+	}
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.ContractID = parsed.ContractID
+	result.ClauseCount = parsed.ClauseCount
+	result.RiskCount = parsed.RiskCount
+	return result
+}
+
 // summarize returns contract summary
 func (w *ContractWorkerState) summarize(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
@@ -259,7 +492,7 @@ func (w *ContractWorkerState) summarize(ctx context.Context, input json.RawMessa
 		return nil, fmt.Errorf("failed to parse request: %w", err)
 	}
 
-	contract, ok := w.Contracts[req.ContractID]
+	contract, ok := w.lookup(req.ContractID)
 	if !ok {
 		return nil, fmt.Errorf("contract not found: %s", req.ContractID)
 	}
@@ -291,7 +524,7 @@ func (w *ContractWorkerState) findClause(ctx context.Context, input json.RawMess
 		return nil, fmt.Errorf("failed to parse request: %w", err)
 	}
 
-	contract, ok := w.Contracts[req.ContractID]
+	contract, ok := w.lookup(req.ContractID)
 	if !ok {
 		return nil, fmt.Errorf("contract not found: %s", req.ContractID)
 	}
@@ -317,14 +550,15 @@ func (w *ContractWorkerState) findClause(ctx context.Context, input json.RawMess
 // riskScore analyzes contract risks
 func (w *ContractWorkerState) riskScore(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
-		ContractID string `json:"contract_id"`
+		ContractID string             `json:"contract_id"`
+		Weights    map[string]float64 `json:"weights"` // per-severity overrides, e.g. {"high": 30}
 	}
 
 	if err := json.Unmarshal(input, &req); err != nil {
 		return nil, fmt.Errorf("failed to parse request: %w", err)
 	}
 
-	contract, ok := w.Contracts[req.ContractID]
+	contract, ok := w.lookup(req.ContractID)
 	if !ok {
 		return nil, fmt.Errorf("contract not found: %s", req.ContractID)
 	}
@@ -336,13 +570,7 @@ func (w *ContractWorkerState) riskScore(ctx context.Context, input json.RawMessa
 	}
 
 	// Calculate overall score (0-100, lower is worse)
-	score := 100.0
-	score -= float64(counts["critical"]) * 25
-	score -= float64(counts["high"]) * 15
-	score -= float64(counts["medium"]) * 5
-	if score < 0 {
-		score = 0
-	}
+	score := w.calculateRiskScore(contract.Risks, w.riskWeights(req.Weights))
 
 	return json.Marshal(map[string]any{
 		"contract_id":    req.ContractID,
@@ -365,8 +593,8 @@ func (w *ContractWorkerState) compare(ctx context.Context, input json.RawMessage
 		return nil, fmt.Errorf("failed to parse request: %w", err)
 	}
 
-	c1, ok1 := w.Contracts[req.ContractID1]
-	c2, ok2 := w.Contracts[req.ContractID2]
+	c1, ok1 := w.lookup(req.ContractID1)
+	c2, ok2 := w.lookup(req.ContractID2)
 	if !ok1 || !ok2 {
 		return nil, fmt.Errorf("one or both contracts not found")
 	}
@@ -396,8 +624,8 @@ func (w *ContractWorkerState) compare(ctx context.Context, input json.RawMessage
 	}
 
 	// Compare risk scores
-	risk1 := w.calculateRiskScore(c1.Risks)
-	risk2 := w.calculateRiskScore(c2.Risks)
+	risk1 := w.calculateRiskScore(c1.Risks, w.riskWeights(nil))
+	risk2 := w.calculateRiskScore(c2.Risks, w.riskWeights(nil))
 
 	return json.Marshal(map[string]any{
 		"contract_1": map[string]any{
@@ -430,7 +658,7 @@ func (w *ContractWorkerState) qa(ctx context.Context, input json.RawMessage) ([]
 		return nil, fmt.Errorf("failed to parse request: %w", err)
 	}
 
-	contract, ok := w.Contracts[req.ContractID]
+	contract, ok := w.lookup(req.ContractID)
 	if !ok {
 		return nil, fmt.Errorf("contract not found: %s", req.ContractID)
 	}
@@ -463,7 +691,7 @@ Answer the question based on this contract.
 		return json.Marshal(map[string]any{
 			"question": req.Question,
 			"answer":   answer,
-			"sources":  contract.Clauses,
+			"sources":  supportingClauses(contract.Clauses, answer),
 		})
 	}
 
@@ -487,8 +715,14 @@ func (w *ContractWorkerState) list(ctx context.Context, input json.RawMessage) (
 		req.Limit = 50
 	}
 
+	if err := w.loadStored(); err != nil {
+		return nil, err
+	}
+
 	contracts := make([]map[string]any, 0)
 	count := 0
+	w.mu.RLock()
+	defer w.mu.RUnlock()
 	for _, c := range w.Contracts {
 		if count >= req.Limit {
 			break
@@ -517,7 +751,7 @@ func (w *ContractWorkerState) get(ctx context.Context, input json.RawMessage) ([
 		return nil, fmt.Errorf("failed to parse request: %w", err)
 	}
 
-	contract, ok := w.Contracts[req.ContractID]
+	contract, ok := w.lookup(req.ContractID)
 	if !ok {
 		return nil, fmt.Errorf("contract not found: %s", req.ContractID)
 	}
@@ -525,6 +759,183 @@ func (w *ContractWorkerState) get(ctx context.Context, input json.RawMessage) ([
 	return json.Marshal(contract)
 }
 
+// expiring lists contracts whose expiry date falls within the next N days
+func (w *ContractWorkerState) expiring(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		WithinDays int `json:"within_days"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+	if req.WithinDays <= 0 {
+		req.WithinDays = 30
+	}
+
+	if err := w.loadStored(); err != nil {
+		return nil, err
+	}
+
+	// Expiry dates are calendar dates, so a contract expiring today is still current
+	today := calendarDay(time.Now())
+
+	type expiringContract struct {
+		ID            string    `json:"id"`
+		Title         string    `json:"title"`
+		ExpiryDate    time.Time `json:"expiry_date"`
+		DaysRemaining int       `json:"days_remaining"`
+	}
+
+	results := make([]expiringContract, 0)
+	w.mu.RLock()
+	for _, c := range w.Contracts {
+		// Contracts without a known expiry can't be placed in the window
+		if c.ExpiryDate == nil {
+			continue
+		}
+		days := int(calendarDay(*c.ExpiryDate).Sub(today).Hours() / 24)
+		if days < 0 || days > req.WithinDays {
+			continue
+		}
+		results = append(results, expiringContract{
+			ID:            c.ID,
+			Title:         c.Title,
+			ExpiryDate:    *c.ExpiryDate,
+			DaysRemaining: days,
+		})
+	}
+	w.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ExpiryDate.Before(results[j].ExpiryDate)
+	})
+
+	return json.Marshal(map[string]any{
+		"within_days": req.WithinDays,
+		"count":       len(results),
+		"contracts":   results,
+	})
+}
+
+// calendarDay returns t's date as midnight UTC, so dates subtract in whole days
+// whatever their time zones and daylight saving
+func calendarDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// valueSummary aggregates contract values per currency. With a base currency and
+// rates (units of base per unit of currency), it also converts to a grand total.
+func (w *ContractWorkerState) valueSummary(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+	req.Base = strings.ToUpper(req.Base)
+
+	if err := w.loadStored(); err != nil {
+		return nil, err
+	}
+
+	rates := make(map[string]float64, len(req.Rates)+1)
+	for cur, rate := range req.Rates {
+		rates[strings.ToUpper(cur)] = rate
+	}
+	if req.Base != "" {
+		rates[req.Base] = 1
+	}
+
+	type unconverted struct {
+		ID       string  `json:"id"`
+		Currency string  `json:"currency"`
+		Value    float64 `json:"value"`
+	}
+
+	subtotals := make(map[string]float64)
+	missing := make([]unconverted, 0)
+	var grandTotal float64
+	counted := 0
+
+	w.mu.RLock()
+	for _, c := range w.Contracts {
+		if c.Value == nil {
+			continue
+		}
+		counted++
+		subtotals[c.Currency] += *c.Value
+
+		if req.Base == "" {
+			continue
+		}
+		// Never guess a rate: contracts we can't convert are reported instead
+		rate, ok := rates[c.Currency]
+		if !ok || rate <= 0 {
+			missing = append(missing, unconverted{ID: c.ID, Currency: c.Currency, Value: *c.Value})
+			continue
+		}
+		grandTotal += *c.Value * rate
+	}
+	w.mu.RUnlock()
+
+	sort.Slice(missing, func(i, j int) bool { return missing[i].ID < missing[j].ID })
+
+	result := map[string]any{
+		"contract_count": counted,
+		"subtotals":      subtotals,
+	}
+	if req.Base != "" {
+		result["base"] = req.Base
+		result["grand_total"] = grandTotal
+		result["unconverted"] = missing
+	}
+	return json.Marshal(result)
+}
+
+// gapCheck reports which required clause types have no matching clause in the contract
+func (w *ContractWorkerState) gapCheck(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		ContractID string   `json:"contract_id"`
+		Required   []string `json:"required"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	contract, ok := w.lookup(req.ContractID)
+	if !ok {
+		return nil, fmt.Errorf("contract not found: %s", req.ContractID)
+	}
+
+	required := req.Required
+	if len(required) == 0 {
+		required = w.RequiredClauses
+	}
+
+	missing := make([]string, 0)
+	for _, want := range required {
+		found := false
+		// Same matching as findClause, so "liability" is satisfied by limitation_of_liability
+		for _, clause := range contract.Clauses {
+			if strings.Contains(strings.ToLower(clause.Type), strings.ToLower(want)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, want)
+		}
+	}
+
+	return json.Marshal(map[string]any{
+		"contract_id": req.ContractID,
+		"required":    required,
+		"missing":     missing,
+		"complete":    len(missing) == 0,
+	})
+}
+
 // --- Helper functions ---
 
 func (w *ContractWorkerState) extractParties(content string) []Party {
@@ -580,20 +991,16 @@ func (w *ContractWorkerState) extractDates(content string) (*time.Time, *time.Ti
 
 	// Effective date patterns
 	effectivePatterns := []string{
-		`(?:effective|date)\s*(?:date)?[:\s]+(\d{1,2}[/-]\d{1,2}[/-]\d{2,4})`,
-		`(?:effective|from)\s*(?:on)?[:\s]+(\w+\s+\d{1,2},?\s+\d{4})`,
-		`commencing\s+(?:on|from)?[:\s]+(\d{1,2}[/-]\d{1,2}[/-]\d{2,4})`,
+		`(?:effective|date)\s*(?:date)?[:\s]+(\d{4}-\d{1,2}-\d{1,2}|\d{1,2}[/-]\d{1,2}[/-]\d{2,4})`,
+		`(?:effective|from)\s*(?:on)?[:\s]+(\w+\s+\d{1,2},?\s+\d{4}|\d{1,2}\s+\w+\s+\d{4})`,
+		`commencing\s+(?:on|from)?[:\s]+(\d{4}-\d{1,2}-\d{1,2}|\d{1,2}[/-]\d{1,2}[/-]\d{2,4})`,
 	}
 
 	for _, pattern := range effectivePatterns {
 		re := regexp.MustCompile(`(?i)` + pattern)
 		matches := re.FindStringSubmatch(content)
 		if len(matches) > 1 {
-			if t, err := time.Parse("01/02/2006", matches[1]); err == nil {
-				effective = &t
-				break
-			}
-			if t, err := time.Parse("January 2, 2006", matches[1]); err == nil {
+			if t, ok := w.parseFlexibleDate(matches[1]); ok {
 				effective = &t
 				break
 			}
@@ -602,8 +1009,9 @@ func (w *ContractWorkerState) extractDates(content string) (*time.Time, *time.Ti
 
 	// Expiry patterns
 	expiryPatterns := []string{
-		`(?:expir(?:y|ation)|ends?|terminates?)\s*(?:on|date)?[:\s]+(\d{1,2}[/-]\d{1,2}[/-]\d{2,4})`,
-		`(?:until|through)\s+(?:the\s+)?(?:date\s+of)?[:\s]+(\d{1,2}[/-]\d{1,2}[/-]\d{2,4})`,
+		`(?:expir(?:y|ation|es|ed)|ends?|terminates?)\s*(?:on|date)?[:\s]+(\d{4}-\d{1,2}-\d{1,2}|\d{1,2}[/-]\d{1,2}[/-]\d{2,4})`,
+		`(?:expir(?:y|ation|es|ed)|ends?|terminates?)\s*(?:on|date)?[:\s]+(\w+\s+\d{1,2},?\s+\d{4}|\d{1,2}\s+\w+\s+\d{4})`,
+		`(?:until|through)\s+(?:the\s+)?(?:date\s+of)?[:\s]+(\d{4}-\d{1,2}-\d{1,2}|\d{1,2}[/-]\d{1,2}[/-]\d{2,4})`,
 		`(\d+)\s+(?:years?|months?)\s+(?:from|after)\s+(?:the\s+)?(?:effective\s+)?date`,
 	}
 
@@ -611,7 +1019,7 @@ func (w *ContractWorkerState) extractDates(content string) (*time.Time, *time.Ti
 		re := regexp.MustCompile(`(?i)` + pattern)
 		matches := re.FindStringSubmatch(content)
 		if len(matches) > 1 {
-			if t, err := time.Parse("01/02/2006", matches[1]); err == nil {
+			if t, ok := w.parseFlexibleDate(matches[1]); ok {
 				expiry = &t
 				break
 			}
@@ -621,6 +1029,35 @@ func (w *ContractWorkerState) extractDates(content string) (*time.Time, *time.Ti
 	return effective, expiry
 }
 
+// parseFlexibleDate tries the date layouts commonly found in contracts. Ambiguous
+// numeric dates are read month-first unless DayFirstDates is set.
+func (w *ContractWorkerState) parseFlexibleDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+
+	numeric := []string{"01/02/2006", "1/2/2006", "01-02-2006", "1-2-2006", "01/02/06", "1/2/06"}
+	if w.DayFirstDates {
+		numeric = []string{"02/01/2006", "2/1/2006", "02-01-2006", "2-1-2006", "02/01/06", "2/1/06"}
+	}
+
+	layouts := append([]string{
+		"2006-01-02",
+		"2006-1-2",
+		"January 2, 2006",
+		"January 2 2006",
+		"Jan 2, 2006",
+		"Jan 2 2006",
+		"2 January 2006",
+		"2 Jan 2006",
+	}, numeric...)
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
 func (w *ContractWorkerState) extractValue(content string) (*float64, string) {
 	// Currency patterns
 	currencyPatterns := []struct {
@@ -662,17 +1099,24 @@ func (w *ContractWorkerState) extractClauses(content string) []Clause {
 
 		for _, pattern := range patterns {
 			re := regexp.MustCompile(pattern)
-			matches := re.FindAllStringSubmatch(content, -1)
+			matches := re.FindAllStringSubmatchIndex(content, -1)
 			for _, m := range matches {
-				if len(m) > 2 {
-					clause := Clause{
-						Type:    clauseType,
-						Title:   m[1],
-						Content: strings.TrimSpace(m[2]),
-					}
-					clause.RiskLevel = w.assessClauseRisk(clauseType, clause.Content)
-					clauses = append(clauses, clause)
+				if len(m) < 6 || m[4] < 0 {
+					continue
+				}
+				// Offsets point at the trimmed clause text so content == raw[StartChar:EndChar]
+				raw := content[m[4]:m[5]]
+				text := strings.TrimSpace(raw)
+				start := m[4] + strings.Index(raw, text)
+				clause := Clause{
+					Type:      clauseType,
+					Title:     content[m[2]:m[3]],
+					Content:   text,
+					StartChar: start,
+					EndChar:   start + len(text),
 				}
+				clause.RiskLevel = w.assessClauseRisk(clauseType, clause.Content)
+				clauses = append(clauses, clause)
 			}
 		}
 	}
@@ -680,6 +1124,92 @@ func (w *ContractWorkerState) extractClauses(content string) []Clause {
 	return clauses
 }
 
+// extractClausesLLM asks the LLM to identify clauses. Any failure, including malformed
+// JSON, is logged and yields no clauses so the regex results still stand.
+func (w *ContractWorkerState) extractClausesLLM(ctx context.Context, content string) []Clause {
+	prompt := fmt.Sprintf(`Identify the clauses in this contract. Respond with only a JSON array of objects with the fields
+"type" (one of: %s), "title", "content" (the clause text, quoted from the contract) and "risk_level" ("low", "medium" or "high").
+
+Contract:
+%s`, strings.Join(ClauseTypes, ", "), truncateUTF8(content, 8000))
+
+	resp, err := w.LLMCaller.Call(ctx, prompt, "You are a legal assistant extracting contract clauses. Output valid JSON only.")
+	if err != nil {
+		fmt.Printf("Warning: LLM clause extraction failed: %v\n", err)
+		return nil
+	}
+
+	// Models often wrap the array in prose or code fences; keep only the outermost brackets
+	start, end := strings.Index(resp, "["), strings.LastIndex(resp, "]")
+	if start < 0 || end <= start {
+		fmt.Printf("Warning: LLM clause extraction returned no JSON array\n")
+		return nil
+	}
+
+	var raw []struct {
+		Type      string `json:"type"`
+		Title     string `json:"title"`
+		Content   string `json:"content"`
+		RiskLevel string `json:"risk_level"`
+	}
+	if err := json.Unmarshal([]byte(resp[start:end+1]), &raw); err != nil {
+		fmt.Printf("Warning: LLM clause extraction returned malformed JSON: %v\n", err)
+		return nil
+	}
+
+	var clauses []Clause
+	for _, r := range raw {
+		text := strings.TrimSpace(r.Content)
+		if r.Type == "" || text == "" {
+			continue
+		}
+		clause := Clause{
+			Type:      strings.ToLower(strings.TrimSpace(r.Type)),
+			Title:     r.Title,
+			Content:   text,
+			RiskLevel: strings.ToLower(r.RiskLevel),
+		}
+		if clause.RiskLevel != "low" && clause.RiskLevel != "medium" && clause.RiskLevel != "high" {
+			clause.RiskLevel = w.assessClauseRisk(clause.Type, clause.Content)
+		}
+		// Offsets are only known when the model quoted the contract verbatim
+		if i := strings.Index(content, text); i >= 0 {
+			clause.StartChar = i
+			clause.EndChar = i + len(text)
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses
+}
+
+// mergeClauses appends extra clauses whose content doesn't overlap any existing clause
+func mergeClauses(existing, extra []Clause) []Clause {
+	merged := existing
+	for _, c := range extra {
+		duplicate := false
+		for _, e := range merged {
+			if clausesOverlap(e, c) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			merged = append(merged, c)
+		}
+	}
+	return merged
+}
+
+// clausesOverlap reports whether one clause's content contains the other's
+func clausesOverlap(a, b Clause) bool {
+	ca := strings.ToLower(strings.Join(strings.Fields(a.Content), " "))
+	cb := strings.ToLower(strings.Join(strings.Fields(b.Content), " "))
+	if ca == "" || cb == "" {
+		return false
+	}
+	return strings.Contains(ca, cb) || strings.Contains(cb, ca)
+}
+
 func (w *ContractWorkerState) extractTerms(content string) []KeyTerm {
 	var terms []KeyTerm
 
@@ -862,6 +1392,44 @@ func (w *ContractWorkerState) termsToText(terms []KeyTerm) string {
 	return b.String()
 }
 
+// supportingClauses returns the clauses the answer draws on: those whose type it names
+// or that share several significant words with it
+func supportingClauses(clauses []Clause, answer string) []Clause {
+	answerLower := strings.ToLower(answer)
+	answerWords := significantWords(answerLower)
+
+	results := make([]Clause, 0)
+	for _, clause := range clauses {
+		if strings.Contains(answerLower, strings.ReplaceAll(strings.ToLower(clause.Type), "_", " ")) {
+			results = append(results, clause)
+			continue
+		}
+		shared := 0
+		for word := range significantWords(strings.ToLower(clause.Content)) {
+			if answerWords[word] {
+				shared++
+			}
+		}
+		if shared >= 3 {
+			results = append(results, clause)
+		}
+	}
+	return results
+}
+
+// significantWords splits text into words of four or more letters, skipping short filler
+func significantWords(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) >= 4 {
+			words[word] = true
+		}
+	}
+	return words
+}
+
 func (w *ContractWorkerState) keywordAnswer(contract Contract, question string) string {
 	questionLower := strings.ToLower(question)
 	var relevantClauses []Clause
@@ -884,21 +1452,33 @@ func (w *ContractWorkerState) keywordAnswer(contract Contract, question string)
 	return b.String()
 }
 
-func (w *ContractWorkerState) calculateRiskScore(risks []Risk) float64 {
+// riskWeights returns the configured weights with any per-request overrides applied
+func (w *ContractWorkerState) riskWeights(override map[string]float64) map[string]float64 {
+	base := w.RiskWeights
+	if base == nil {
+		base = DefaultRiskWeights
+	}
+	weights := make(map[string]float64, len(base)+len(override))
+	for severity, weight := range base {
+		weights[severity] = weight
+	}
+	for severity, weight := range override {
+		weights[strings.ToLower(severity)] = weight
+	}
+	return weights
+}
+
+func (w *ContractWorkerState) calculateRiskScore(risks []Risk, weights map[string]float64) float64 {
 	score := 100.0
 	for _, r := range risks {
-		switch r.Severity {
-		case "critical":
-			score -= 25
-		case "high":
-			score -= 15
-		case "medium":
-			score -= 5
-		}
+		score -= weights[r.Severity]
 	}
 	if score < 0 {
 		score = 0
 	}
+	if score > 100 {
+		score = 100
+	}
 	return score
 }
 
@@ -0,0 +1,105 @@
+package workers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ContractStore persists parsed contracts outside the worker's in-memory cache
+type ContractStore interface {
+	Save(c Contract) error
+	Load(id string) (Contract, error)
+	List() ([]Contract, error)
+}
+
+// FileContractStore keeps one JSON file per contract under a directory
+type FileContractStore struct {
+	dir string
+}
+
+// NewFileContractStore creates the storage directory if needed
+func NewFileContractStore(dir string) (*FileContractStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("storage path required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create contract storage: %w", err)
+	}
+	return &FileContractStore{dir: dir}, nil
+}
+
+func (s *FileContractStore) path(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid contract id: %q", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// Save writes the contract atomically via a temp file and rename
+func (s *FileContractStore) Save(c Contract) error {
+	path, err := s.path(c.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *FileContractStore) Load(id string) (Contract, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return Contract{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Contract{}, fmt.Errorf("contract not found: %s", id)
+		}
+		return Contract{}, err
+	}
+
+	var c Contract
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Contract{}, fmt.Errorf("failed to decode contract %s: %w", id, err)
+	}
+	return c, nil
+}
+
+// List returns all stored contracts, newest analysis first
+func (s *FileContractStore) List() ([]Contract, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var contracts []Contract
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		c, err := s.Load(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		contracts = append(contracts, c)
+	}
+
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].AnalyzedAt.After(contracts[j].AnalyzedAt)
+	})
+	return contracts, nil
+}
@@ -0,0 +1,494 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLLM returns a canned response for every call
+type fakeLLM struct {
+	response string
+	err      error
+}
+
+func (f *fakeLLM) Call(ctx context.Context, prompt string, systemPrompt string) (string, error) {
+	return f.response, f.err
+}
+
+const sampleContract = `This Services Agreement is made by and between Acme Corp and Globex Inc.
+Termination: Either party may terminate this agreement with thirty days written notice to the other party.
+The vendor shall keep all customer data secret and shall not share it with third parties under any circumstances.
+`
+
+func parseContract(t *testing.T, w *ContractWorkerState, content string) Contract {
+	input, _ := json.Marshal(map[string]string{"content": content, "title": "Test"})
+	out, err := w.Execute(context.Background(), "contract_parse", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		ContractID string `json:"contract_id"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	return w.Contracts[resp.ContractID]
+}
+
+func TestContractParse_MergesLLMClauses(t *testing.T) {
+	w := NewContractWorkerState()
+	w.SetLLMCaller(&fakeLLM{response: "Here you go:\n```json\n[" +
+		`{"type":"termination","title":"Termination","content":"Either party may terminate this agreement with thirty days written notice","risk_level":"low"},` +
+		`{"type":"confidentiality","title":"Data","content":"The vendor shall keep all customer data secret and shall not share it with third parties under any circumstances.","risk_level":"medium"}` +
+		"]\n```"})
+
+	contract := parseContract(t, w, sampleContract)
+
+	counts := map[string]int{}
+	for _, c := range contract.Clauses {
+		counts[c.Type]++
+	}
+	assert.Equal(t, 1, counts["termination"], "overlapping LLM clause should be deduplicated")
+	assert.Equal(t, 1, counts["confidentiality"], "clause missed by regex should come from the LLM")
+}
+
+func TestContractParse_MalformedLLMOutputFallsBack(t *testing.T) {
+	w := NewContractWorkerState()
+	w.SetLLMCaller(&fakeLLM{response: `[{"type": "termination", "content": `})
+
+	contract := parseContract(t, w, sampleContract)
+
+	require.NotEmpty(t, contract.Clauses)
+	for _, c := range contract.Clauses {
+		assert.NotEqual(t, "confidentiality", c.Type)
+	}
+}
+
+func TestContractParse_LLMPromptsCutOnRuneBoundary(t *testing.T) {
+	w := NewContractWorkerState()
+	var prompts []string
+	w.SetLLMCaller(promptLLM(func(prompt string) string {
+		prompts = append(prompts, prompt)
+		return "[]"
+	}))
+
+	// The 8000-byte limit falls in the middle of the "€"
+	parseContract(t, w, strings.Repeat("a", 7999)+"€ and the rest")
+	require.Len(t, prompts, 2)
+	for _, p := range prompts {
+		assert.True(t, utf8.ValidString(p))
+		assert.True(t, strings.HasSuffix(p, strings.Repeat("a", 7999)))
+	}
+}
+
+func TestFileContractStore_RoundTrip(t *testing.T) {
+	store, err := NewFileContractStore(t.TempDir())
+	require.NoError(t, err)
+
+	value := 12000.0
+	effective := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	full := Contract{
+		ID:            "doc_full_1",
+		Title:         "Full",
+		EffectiveDate: &effective,
+		Value:         &value,
+		Currency:      "USD",
+		Clauses:       []Clause{{Type: "termination", Content: "thirty days notice", RiskLevel: "low"}},
+		AnalyzedAt:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	bare := Contract{ID: "doc_bare_2", Title: "Bare", AnalyzedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+
+	require.NoError(t, store.Save(full))
+	require.NoError(t, store.Save(bare))
+
+	got, err := store.Load("doc_full_1")
+	require.NoError(t, err)
+	require.NotNil(t, got.EffectiveDate)
+	assert.True(t, effective.Equal(*got.EffectiveDate))
+	require.NotNil(t, got.Value)
+	assert.Equal(t, value, *got.Value)
+	assert.Equal(t, full.Clauses, got.Clauses)
+
+	got, err = store.Load("doc_bare_2")
+	require.NoError(t, err)
+	assert.Nil(t, got.EffectiveDate)
+	assert.Nil(t, got.ExpiryDate)
+	assert.Nil(t, got.Value)
+
+	list, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "doc_bare_2", list[0].ID)
+
+	_, err = store.Load("missing")
+	assert.Error(t, err)
+	_, err = store.Load("../escape")
+	assert.Error(t, err)
+}
+
+func TestContractWorker_ReadsThroughStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileContractStore(dir)
+	require.NoError(t, err)
+
+	w := NewContractWorkerState()
+	w.SetStore(store)
+	contract := parseContract(t, w, sampleContract)
+
+	// A fresh worker (as after a restart) finds the contract through the store
+	restarted := NewContractWorkerState()
+	restarted.SetStore(store)
+
+	out, err := restarted.Execute(context.Background(), "contract_get", []byte(`{"contract_id":"`+contract.ID+`"}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), contract.ID)
+
+	out, err = restarted.Execute(context.Background(), "contract_list", []byte(`{}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), contract.ID)
+}
+
+func TestContractWorker_ConcurrentReads(t *testing.T) {
+	store, err := NewFileContractStore(t.TempDir())
+	require.NoError(t, err)
+	w := NewContractWorkerState()
+	w.SetStore(store)
+	var ids []string
+	for i := 0; i < 5; i++ {
+		ids = append(ids, parseContract(t, w, sampleContract).ID)
+	}
+
+	// A fresh worker fills its cache from the store while serving concurrent reads
+	restarted := NewContractWorkerState()
+	restarted.SetStore(store)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out, err := restarted.Execute(ctx, "contract_get", []byte(`{"contract_id":"`+ids[i%len(ids)]+`"}`))
+			assert.NoError(t, err)
+			assert.Contains(t, string(out), ids[i%len(ids)])
+			_, err = restarted.Execute(ctx, "contract_list", []byte(`{}`))
+			assert.NoError(t, err)
+			_, err = restarted.Execute(ctx, "contract_expiring", []byte(`{}`))
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestContractExpiring(t *testing.T) {
+	w := NewContractWorkerState()
+	days := func(n int) *time.Time {
+		t := time.Now().AddDate(0, 0, n)
+		return &t
+	}
+	w.Contracts["later"] = Contract{ID: "later", ExpiryDate: days(20)}
+	w.Contracts["soon"] = Contract{ID: "soon", ExpiryDate: days(5)}
+	w.Contracts["far"] = Contract{ID: "far", ExpiryDate: days(90)}
+	w.Contracts["past"] = Contract{ID: "past", ExpiryDate: days(-3)}
+	w.Contracts["undated"] = Contract{ID: "undated"}
+	now := time.Now()
+	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	w.Contracts["today"] = Contract{ID: "today", ExpiryDate: &startOfToday}
+
+	out, err := w.Execute(context.Background(), "contract_expiring", []byte(`{"within_days": 30}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Contracts []struct {
+			ID            string `json:"id"`
+			DaysRemaining int    `json:"days_remaining"`
+		} `json:"contracts"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.Len(t, resp.Contracts, 3)
+	assert.Equal(t, "today", resp.Contracts[0].ID)
+	assert.Equal(t, 0, resp.Contracts[0].DaysRemaining)
+	assert.Equal(t, "soon", resp.Contracts[1].ID)
+	assert.Equal(t, 5, resp.Contracts[1].DaysRemaining)
+	assert.Equal(t, "later", resp.Contracts[2].ID)
+	assert.Equal(t, 20, resp.Contracts[2].DaysRemaining)
+
+	// The window is counted in whole days, so day 5 falls within 5 days
+	out, err = w.Execute(context.Background(), "contract_expiring", []byte(`{"within_days": 5}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.Len(t, resp.Contracts, 2)
+	assert.Equal(t, "soon", resp.Contracts[1].ID)
+}
+
+func TestParseFlexibleDate(t *testing.T) {
+	want := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	w := NewContractWorkerState()
+
+	for _, input := range []string{
+		"2024-03-04",
+		"03-04-2024",
+		"03/04/2024",
+		"3/4/2024",
+		"4 March 2024",
+		"4 Mar 2024",
+		"March 4, 2024",
+		"Mar 4, 2024",
+	} {
+		got, ok := w.parseFlexibleDate(input)
+		if assert.True(t, ok, input) {
+			assert.True(t, want.Equal(got), "%s parsed as %s", input, got)
+		}
+	}
+
+	dayFirst := NewContractWorkerState()
+	dayFirst.DayFirstDates = true
+	got, ok := dayFirst.parseFlexibleDate("04/03/2024")
+	require.True(t, ok)
+	assert.True(t, want.Equal(got))
+
+	_, ok = w.parseFlexibleDate("sometime next spring")
+	assert.False(t, ok)
+	_, ok = w.parseFlexibleDate("13/45/2024")
+	assert.False(t, ok)
+}
+
+func TestExtractDates_ISOAndDayMonth(t *testing.T) {
+	w := NewContractWorkerState()
+	effective, expiry := w.extractDates("Effective date: 2024-01-15. This agreement expires on 31 December 2025.")
+
+	require.NotNil(t, effective)
+	assert.Equal(t, "2024-01-15", effective.Format("2006-01-02"))
+	require.NotNil(t, expiry)
+	assert.Equal(t, "2025-12-31", expiry.Format("2006-01-02"))
+}
+
+func TestContractValueSummary(t *testing.T) {
+	w := NewContractWorkerState()
+	value := func(v float64) *float64 { return &v }
+	w.Contracts["a"] = Contract{ID: "a", Value: value(1000), Currency: "USD"}
+	w.Contracts["b"] = Contract{ID: "b", Value: value(500), Currency: "USD"}
+	w.Contracts["c"] = Contract{ID: "c", Value: value(1000), Currency: "EUR"}
+	w.Contracts["d"] = Contract{ID: "d", Value: value(200), Currency: "GBP"}
+	w.Contracts["e"] = Contract{ID: "e"}
+
+	out, err := w.Execute(context.Background(), "contract_value_summary", []byte(`{"base":"USD","rates":{"EUR":1.08}}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		ContractCount int                `json:"contract_count"`
+		Subtotals     map[string]float64 `json:"subtotals"`
+		GrandTotal    float64            `json:"grand_total"`
+		Unconverted   []struct {
+			ID       string `json:"id"`
+			Currency string `json:"currency"`
+		} `json:"unconverted"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+
+	assert.Equal(t, 4, resp.ContractCount)
+	assert.Equal(t, 1500.0, resp.Subtotals["USD"])
+	assert.Equal(t, 1000.0, resp.Subtotals["EUR"])
+	assert.InDelta(t, 2580.0, resp.GrandTotal, 0.001)
+	require.Len(t, resp.Unconverted, 1)
+	assert.Equal(t, "d", resp.Unconverted[0].ID)
+	assert.Equal(t, "GBP", resp.Unconverted[0].Currency)
+}
+
+func TestContractGapCheck(t *testing.T) {
+	w := NewContractWorkerState()
+	w.Contracts["c1"] = Contract{ID: "c1", Clauses: []Clause{
+		{Type: "termination"},
+		{Type: "limitation_of_liability"},
+		{Type: "confidentiality"},
+	}}
+
+	out, err := w.Execute(context.Background(), "contract_gap_check", []byte(`{"contract_id":"c1"}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Missing  []string `json:"missing"`
+		Complete bool     `json:"complete"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, []string{"governing_law", "dispute_resolution"}, resp.Missing)
+	assert.False(t, resp.Complete)
+
+	out, err = w.Execute(context.Background(), "contract_gap_check", []byte(`{"contract_id":"c1","required":["termination","confidentiality"]}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Empty(t, resp.Missing)
+	assert.True(t, resp.Complete)
+
+	_, err = w.Execute(context.Background(), "contract_gap_check", []byte(`{"contract_id":"missing"}`))
+	assert.Error(t, err)
+}
+
+func TestContractRiskScoreWeights(t *testing.T) {
+	w := NewContractWorkerState()
+	w.Contracts["c1"] = Contract{ID: "c1", Risks: []Risk{
+		{Severity: "high"},
+		{Severity: "high"},
+	}}
+
+	var resp struct {
+		Score     float64 `json:"score"`
+		RiskLevel string  `json:"risk_level"`
+	}
+
+	out, err := w.Execute(context.Background(), "contract_risk_score", []byte(`{"contract_id":"c1"}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 70.0, resp.Score)
+	assert.Equal(t, "medium", resp.RiskLevel)
+
+	out, err = w.Execute(context.Background(), "contract_risk_score", []byte(`{"contract_id":"c1","weights":{"high":35}}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 30.0, resp.Score)
+	assert.Equal(t, "critical", resp.RiskLevel)
+
+	// Configured weights apply to compare as well
+	w.RiskWeights = map[string]float64{"high": 35}
+	assert.Equal(t, 30.0, w.calculateRiskScore(w.Contracts["c1"].Risks, w.riskWeights(nil)))
+}
+
+func TestNewContractWorkerState_CopiesDefaultRiskWeights(t *testing.T) {
+	w := NewContractWorkerState()
+	w.RiskWeights["high"] = 50
+	assert.Equal(t, 15.0, DefaultRiskWeights["high"])
+	assert.Equal(t, 15.0, NewContractWorkerState().RiskWeights["high"])
+}
+
+func TestNewContractWorkerState_CopiesDefaultRequiredClauses(t *testing.T) {
+	w := NewContractWorkerState()
+	w.RequiredClauses[0] = "changed"
+	assert.NotEqual(t, "changed", DefaultRequiredClauses[0])
+	assert.Equal(t, DefaultRequiredClauses, NewContractWorkerState().RequiredClauses)
+}
+
+func TestExtractClauses_RecordsOffsets(t *testing.T) {
+	w := NewContractWorkerState()
+	clauses := w.extractClauses(sampleContract)
+
+	var termination *Clause
+	for i := range clauses {
+		if clauses[i].Type == "termination" {
+			termination = &clauses[i]
+			break
+		}
+	}
+	require.NotNil(t, termination)
+	assert.NotZero(t, termination.StartChar)
+	assert.Greater(t, termination.EndChar, termination.StartChar)
+	assert.Equal(t, termination.Content, sampleContract[termination.StartChar:termination.EndChar])
+	assert.Contains(t, termination.Content, "thirty days written notice")
+}
+
+func TestContractQA_ReturnsSupportingClauses(t *testing.T) {
+	w := NewContractWorkerState()
+	w.Contracts["c1"] = Contract{ID: "c1", Clauses: []Clause{
+		{Type: "termination", Content: "Either party may terminate this agreement with thirty days written notice."},
+		{Type: "payment", Content: "Invoices are payable within forty five days of receipt."},
+	}}
+	w.SetLLMCaller(&fakeLLM{response: "Either party can terminate with thirty days written notice."})
+
+	out, err := w.Execute(context.Background(), "contract_qa", []byte(`{"contract_id":"c1","question":"How do we get out of this?"}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Sources []Clause `json:"sources"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.Len(t, resp.Sources, 1)
+	assert.Equal(t, "termination", resp.Sources[0].Type)
+}
+
+func TestContractParseBatch(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte(sampleContract), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte(sampleContract), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("ignored"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "c.txt"), 0755)) // unreadable as a file
+
+	w := NewContractWorkerState()
+	input, _ := json.Marshal(map[string]string{"dir": dir})
+	out, err := w.Execute(context.Background(), "contract_parse_batch", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		Total   int           `json:"total"`
+		Failed  int           `json:"failed"`
+		Results []batchResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 3, resp.Total)
+	assert.Equal(t, 1, resp.Failed)
+	assert.NotEmpty(t, resp.Results[0].ContractID)
+	assert.Positive(t, resp.Results[0].ClauseCount)
+	assert.NotEmpty(t, resp.Results[2].Error)
+	assert.Len(t, w.Contracts, 2)
+}
+
+func TestContractParseBatch_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte(sampleContract), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w := NewContractWorkerState()
+	input, _ := json.Marshal(map[string]string{"dir": dir})
+	_, err := w.Execute(ctx, "contract_parse_batch", input)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, w.Contracts)
+}
+
+func TestContractParse_LoadsSourceFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.txt")
+	require.NoError(t, os.WriteFile(path, []byte(sampleContract), 0644))
+
+	w := NewContractWorkerState()
+	input, _ := json.Marshal(map[string]string{"source": path, "title": "Services"})
+	out, err := w.Execute(context.Background(), "contract_parse", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		ContractID  string `json:"contract_id"`
+		ClauseCount int    `json:"clause_count"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Positive(t, resp.ClauseCount)
+	assert.Equal(t, sampleContract, w.Contracts[resp.ContractID].RawText)
+	assert.Equal(t, path, w.Contracts[resp.ContractID].Source)
+}
+
+func TestContractParse_SourceErrors(t *testing.T) {
+	dir := t.TempDir()
+	big := filepath.Join(dir, "big.txt")
+	require.NoError(t, os.WriteFile(big, []byte(sampleContract), 0644))
+	pdf := filepath.Join(dir, "scan.pdf")
+	require.NoError(t, os.WriteFile(pdf, []byte("%PDF-1.4"), 0644))
+
+	w := NewContractWorkerState()
+	w.MaxFileBytes = 16
+
+	parse := func(path string) error {
+		input, _ := json.Marshal(map[string]string{"source": path})
+		_, err := w.Execute(context.Background(), "contract_parse", input)
+		return err
+	}
+
+	assert.ErrorContains(t, parse(big), "too large")
+	assert.ErrorContains(t, parse(pdf), "pdf extraction not supported")
+	assert.ErrorContains(t, parse(filepath.Join(dir, "missing.txt")), "failed to read source")
+
+	w.PDFExtractor = func(path string) (string, error) { return sampleContract, nil }
+	assert.NoError(t, parse(pdf))
+}
@@ -1,38 +1,60 @@
 package workers
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
+	"mime/quotedprintable"
 	"net/mail"
+	"net/textproto"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
-	"net/textproto"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
 )
 
 type EmailParserWorker struct {
 	maildirPath string
+
+	// LLMCaller, when set, extracts tasks with a model; the keyword heuristic is the fallback
+	LLMCaller LLMCaller
 }
 
+// maxTaskExtractionChars caps how much of an email body is sent to the LLM
+const maxTaskExtractionChars = 8000
+
 func NewEmailParserWorker(maildirPath string) *EmailParserWorker {
 	return &EmailParserWorker{maildirPath: maildirPath}
 }
 
+// SetLLMCaller sets the LLM caller used by email_extract_tasks
+func (w *EmailParserWorker) SetLLMCaller(caller LLMCaller) {
+	w.LLMCaller = caller
+}
+
 func (w *EmailParserWorker) GetTools() []ToolDef {
 	return []ToolDef{
-		{Name: "email_parse_file", Description: "Parse an email file (.eml, .emlx, or Maildir message) and extract structured data"},
-		{Name: "email_parse_raw", Description: "Parse raw email content and extract structured data"},
+		{Name: "email_parse_file", Description: "Parse an email file (.eml, .emlx, or Maildir message) and extract structured data, optionally saving attachments"},
+		{Name: "email_parse_raw", Description: "Parse raw email content and extract structured data, optionally saving attachments"},
 		{Name: "email_extract_tasks", Description: "Extract actionable tasks from email content"},
-		{Name: "email_search_by_subject", Description: "Search emails by subject pattern in Maildir"},
+		{Name: "email_search_by_subject", Description: "Search emails by subject pattern in a Maildir folder, or all folders if recursive"},
 		{Name: "email_list_recent", Description: "List recent emails in a Maildir folder"},
+		{Name: "email_list_folders", Description: "List Maildir folders with their message counts"},
+		{Name: "email_build_threads", Description: "Group the messages in a Maildir folder into conversation threads"},
 	}
 }
 
@@ -48,6 +70,10 @@ func (w *EmailParserWorker) Execute(ctx context.Context, name string, input json
 		return w.searchBySubject(ctx, input)
 	case "email_list_recent":
 		return w.listRecent(ctx, input)
+	case "email_list_folders":
+		return w.listFolders(ctx, input)
+	case "email_build_threads":
+		return w.buildThreads(ctx, input)
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", name)
 	}
@@ -76,6 +102,9 @@ type Attachment struct {
 	Size        int64  `json:"size"`
 	ContentID   string `json:"content_id,omitempty"`
 	ContentHash string `json:"content_hash,omitempty"`
+	Path        string `json:"path,omitempty"` // where the attachment was saved, if requested
+
+	data []byte
 }
 
 type TaskExtraction struct {
@@ -92,12 +121,14 @@ type EmailTask struct {
 	Description string   `json:"description"`
 	ActionItems []string `json:"action_items,omitempty"`
 	AssignedTo  string   `json:"assigned_to,omitempty"`
+	DueDate     string   `json:"due_date,omitempty"`
 	Context     string   `json:"context,omitempty"`
 }
 
 func (w *EmailParserWorker) parseFile(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
-		Path string `json:"path"`
+		Path              string `json:"path"`
+		SaveAttachmentsTo string `json:"save_attachments_to,omitempty"`
 	}
 	if err := json.Unmarshal(input, &req); err != nil {
 		return nil, err
@@ -108,18 +139,96 @@ func (w *EmailParserWorker) parseFile(ctx context.Context, input json.RawMessage
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	if message, ok := emlxMessage(data); ok {
+		data = message
+	}
 
 	email, err := w.parseEmail(string(data))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse email: %w", err)
 	}
+	if req.SaveAttachmentsTo != "" {
+		dir, err := w.attachmentDir(req.SaveAttachmentsTo)
+		if err != nil {
+			return nil, err
+		}
+		if err := saveAttachments(email, dir); err != nil {
+			return nil, err
+		}
+	}
 
 	return json.Marshal(email)
 }
 
+// attachmentDir resolves dir against the maildir path, rejecting directories outside it
+func (w *EmailParserWorker) attachmentDir(dir string) (string, error) {
+	if w.maildirPath == "" {
+		return "", fmt.Errorf("saving attachments requires a maildir path")
+	}
+	resolved, err := pathWithin(w.maildirPath, dir)
+	if errors.Is(err, errPathOutsideBase) {
+		return "", fmt.Errorf("attachment directory outside maildir path: %s", dir)
+	}
+	return resolved, err
+}
+
+// saveAttachments writes each attachment's decoded bytes under dir and records the path.
+// Filenames come from the sender, so only their base name is used and an existing file
+// is never overwritten.
+func saveAttachments(email *EmailData, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+
+	for i := range email.Attachments {
+		att := &email.Attachments[i]
+		name := filepath.Base(filepath.Clean("/" + strings.ReplaceAll(att.Filename, "\\", "/")))
+		if name == "/" || name == "." || strings.HasPrefix(name, ".") {
+			name = "attachment-" + att.ContentHash
+		}
+
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			ext := filepath.Ext(name)
+			path = filepath.Join(dir, strings.TrimSuffix(name, ext)+"-"+att.ContentHash+ext)
+		}
+
+		if err := os.WriteFile(path, att.data, 0644); err != nil {
+			return fmt.Errorf("failed to save attachment %s: %w", name, err)
+		}
+		att.Path = path
+	}
+	return nil
+}
+
+// emlxMessage extracts the RFC822 message from an Apple Mail .emlx file, which wraps
+// it in a leading byte-count line and a trailing property list. ok is false when data
+// doesn't start with a byte count.
+func emlxMessage(data []byte) (message []byte, ok bool) {
+	nl := bytes.IndexByte(data, '\n')
+	if nl <= 0 {
+		return nil, false
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(string(data[:nl])))
+	if err != nil || length < 0 {
+		return nil, false
+	}
+
+	message = data[nl+1:]
+	if length <= len(message) {
+		return message[:length], true
+	}
+	// The count is wrong (e.g. the file was edited); cut at the plist instead
+	if i := bytes.LastIndex(message, []byte("<?xml")); i >= 0 {
+		message = message[:i]
+	}
+	return message, true
+}
+
 func (w *EmailParserWorker) parseRaw(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
-		Content string `json:"content"`
+		Content           string `json:"content"`
+		SaveAttachmentsTo string `json:"save_attachments_to,omitempty"`
 	}
 	if err := json.Unmarshal(input, &req); err != nil {
 		return nil, err
@@ -129,6 +238,15 @@ func (w *EmailParserWorker) parseRaw(ctx context.Context, input json.RawMessage)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse email: %w", err)
 	}
+	if req.SaveAttachmentsTo != "" {
+		dir, err := w.attachmentDir(req.SaveAttachmentsTo)
+		if err != nil {
+			return nil, err
+		}
+		if err := saveAttachments(email, dir); err != nil {
+			return nil, err
+		}
+	}
 
 	return json.Marshal(email)
 }
@@ -150,7 +268,7 @@ func (w *EmailParserWorker) parseEmail(content string) (*EmailData, error) {
 	}
 
 	email.MessageID = msg.Header.Get("Message-Id")
-	email.Subject = msg.Header.Get("Subject")
+	email.Subject = decodeHeader(msg.Header.Get("Subject"))
 	
 	// Parse date
 	if dateStr := msg.Header.Get("Date"); dateStr != "" {
@@ -178,7 +296,7 @@ func (w *EmailParserWorker) parseEmail(content string) (*EmailData, error) {
 	}
 
 	body, _ := io.ReadAll(msg.Body)
-	w.parseBodyParts(contentType, string(body), email)
+	w.parseBodyParts(contentType, textproto.MIMEHeader(msg.Header), string(body), email)
 
 	// Generate ID hash
 	h := sha256.New()
@@ -215,7 +333,7 @@ func (w *EmailParserWorker) parseSimpleEmail(content string) *EmailData {
 	return email
 }
 
-func (w *EmailParserWorker) parseBodyParts(contentType string, body string, email *EmailData) {
+func (w *EmailParserWorker) parseBodyParts(contentType string, header textproto.MIMEHeader, body string, email *EmailData) {
 	mediaType, params, _ := mime.ParseMediaType(contentType)
 	
 	if strings.HasPrefix(mediaType, "multipart/") {
@@ -245,12 +363,13 @@ func (w *EmailParserWorker) parseBodyParts(contentType string, body string, emai
 			w.handlePart(partContentType, part.Header, string(partBody), email)
 		}
 	} else {
-		w.handlePart(contentType, textproto.MIMEHeader{}, body, email)
+		w.handlePart(contentType, header, body, email)
 	}
 }
 
 func (w *EmailParserWorker) handlePart(contentType string, header textproto.MIMEHeader, body string, email *EmailData) {
 	mediaType, _, _ := mime.ParseMediaType(contentType)
+	body = decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
 	
 	switch {
 	case strings.HasPrefix(mediaType, "text/plain"):
@@ -262,7 +381,7 @@ func (w *EmailParserWorker) handlePart(contentType string, header textproto.MIME
 		}
 	case strings.HasPrefix(mediaType, "multipart/"):
 		// Recursively parse
-		w.parseBodyParts(contentType, body, email)
+		w.parseBodyParts(contentType, header, body, email)
 	default:
 		// Attachment
 		filename := ""
@@ -285,45 +404,179 @@ func (w *EmailParserWorker) handlePart(contentType string, header textproto.MIME
 			ContentType: mediaType,
 			Size:        int64(len(body)),
 			ContentHash: base64.URLEncoding.EncodeToString(h.Sum(nil))[:16],
+			data:        []byte(body),
 		})
 	}
 }
 
+// decodeTransferEncoding undoes a base64 or quoted-printable Content-Transfer-Encoding,
+// returning the body unchanged for identity or unknown encodings and on malformed input.
+// multipart.Reader already decodes quoted-printable parts and drops their header.
+func decodeTransferEncoding(encoding, body string) string {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+		if err != nil {
+			return body
+		}
+		return string(decoded)
+	case "quoted-printable":
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+		if err != nil {
+			return body
+		}
+		return string(decoded)
+	default:
+		return body
+	}
+}
+
 func (w *EmailParserWorker) parseAddressList(addrStr string) []string {
 	if addrStr == "" {
 		return nil
 	}
+	// Split before decoding: a decoded display name may itself contain a comma
 	addresses := strings.Split(addrStr, ",")
 	for i, addr := range addresses {
-		addresses[i] = strings.TrimSpace(addr)
+		addresses[i] = decodeHeader(strings.TrimSpace(addr))
 	}
 	return addresses
 }
 
-func (w *EmailParserWorker) htmlToText(html string) string {
-	// Simple HTML to text conversion
-	// Remove script and style tags
-	reStyle := regexp.MustCompile(`(?i)<(script|style)[^>]*>[^<]*</(script|style)>`)
-	html = reStyle.ReplaceAllString(html, "")
-	
-	// Convert breaks and paragraphs to newlines
-	html = strings.ReplaceAll(html, "<br>", "\n")
-	html = strings.ReplaceAll(html, "<br/>", "\n")
-	html = strings.ReplaceAll(html, "<p>", "\n\n")
-	html = strings.ReplaceAll(html, "</p>", "")
-	
-	// Remove remaining HTML tags
-	re := regexp.MustCompile(`<[^>]+>`)
-	html = re.ReplaceAllString(html, "")
-	
-	// Decode common entities
-	html = strings.ReplaceAll(html, "&amp;", "&")
-	html = strings.ReplaceAll(html, "&lt;", "<")
-	html = strings.ReplaceAll(html, "&gt;", ">")
-	html = strings.ReplaceAll(html, "&quot;", "\"")
-	html = strings.ReplaceAll(html, "&#39;", "'")
-	
-	return strings.TrimSpace(html)
+// headerDecoder decodes encoded-words in any charset x/net/html/charset knows, such as
+// windows-1252 and Shift_JIS, not just the UTF-8 and ISO-8859-1 mime handles itself
+var headerDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// decodeHeader decodes RFC 2047 encoded-words such as =?UTF-8?B?...?=, returning the
+// value unchanged if it uses an unsupported charset or is malformed
+func decodeHeader(value string) string {
+	decoded, err := headerDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// htmlBlockElements start and end on their own line when rendered as text
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Ul: true, atom.Ol: true,
+	atom.Table: true, atom.Tr: true, atom.Blockquote: true, atom.Pre: true,
+	atom.Section: true, atom.Article: true, atom.Header: true, atom.Footer: true,
+	atom.Hr: true,
+}
+
+var (
+	htmlSpacePattern     = regexp.MustCompile(`[ \r\f\v]+`)
+	htmlBlankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText renders an HTML body as plain text, keeping block structure, list
+// bullets, table cells, and link targets as "text (url)"
+func (w *EmailParserWorker) htmlToText(body string) string {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return strings.TrimSpace(body)
+	}
+
+	var b strings.Builder
+	newline := func() {
+		if s := b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			b.WriteString("\n")
+		}
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			// Tabs are reserved for separating table cells
+			b.WriteString(strings.NewReplacer("\n", " ", "\t", " ").Replace(n.Data))
+			return
+		case html.ElementNode:
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Head:
+				return
+			case atom.Br:
+				b.WriteString("\n")
+				return
+			case atom.Li:
+				newline()
+				b.WriteString("- ")
+			case atom.Td, atom.Th:
+				for prev := n.PrevSibling; prev != nil; prev = prev.PrevSibling {
+					if prev.Type == html.ElementNode {
+						b.WriteString("\t")
+						break
+					}
+				}
+			}
+			if htmlBlockElements[n.DataAtom] {
+				newline()
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+
+		if n.Type != html.ElementNode {
+			return
+		}
+		switch {
+		case n.DataAtom == atom.A:
+			if href := htmlAttr(n, "href"); href != "" && !strings.HasPrefix(href, "#") && href != htmlNodeText(n) {
+				b.WriteString(" (" + href + ")")
+			}
+		case n.DataAtom == atom.Li:
+			newline()
+		case htmlBlockElements[n.DataAtom]:
+			newline()
+			// Paragraphs and headings are followed by a blank line
+			switch n.DataAtom {
+			case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				b.WriteString("\n")
+			}
+		}
+	}
+	walk(doc)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		cells := strings.Split(htmlSpacePattern.ReplaceAllString(line, " "), "\t")
+		for j, cell := range cells {
+			cells[j] = strings.Trim(cell, " ")
+		}
+		lines[i] = strings.Join(cells, "\t")
+	}
+	text := htmlBlankLinePattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// htmlAttr returns the value of an element's attribute, or "" if it has none
+func htmlAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// htmlNodeText returns the whitespace-collapsed text inside a node
+func htmlNodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
 }
 
 func (w *EmailParserWorker) extractTasks(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -355,15 +608,64 @@ func (w *EmailParserWorker) extractTasks(ctx context.Context, input json.RawMess
 		EmailSubject: req.Subject,
 		From:         req.From,
 		Date:         req.Date,
-		Tasks:        w.extractTasksFromText(req.Subject, bodyText),
 		Summary:      w.generateSummary(bodyText),
 		Urgency:      w.classifyUrgency(req.Subject, bodyText),
 		DueDate:      w.extractDueDate(req.Subject, bodyText),
 	}
 
+	// Prefer the model's tasks; fall back to keywords when it is unset, fails, or finds none
+	if w.LLMCaller != nil {
+		extraction.Tasks = w.extractTasksLLM(ctx, req.Subject, bodyText)
+	}
+	if len(extraction.Tasks) == 0 {
+		extraction.Tasks = w.extractTasksFromText(req.Subject, bodyText)
+	}
+
 	return json.Marshal(extraction)
 }
 
+// extractTasksLLM asks the LLM for the email's tasks. Any failure, including malformed
+// JSON, is logged and yields no tasks so the keyword heuristic takes over.
+func (w *EmailParserWorker) extractTasksLLM(ctx context.Context, subject, body string) []EmailTask {
+	prompt := fmt.Sprintf(`Extract the actionable tasks from this email. Respond with only a JSON array of objects with the fields
+"description" (a short imperative sentence), "action_items" (an array of concrete steps), "assigned_to" (a person, if named)
+and "due_date" (as written in the email, if any). Respond with [] if the email asks for nothing.
+
+Subject: %s
+
+%s`, subject, truncateUTF8(body, maxTaskExtractionChars))
+
+	resp, err := w.LLMCaller.Call(ctx, prompt, "You are an assistant extracting tasks from emails. Output valid JSON only.")
+	if err != nil {
+		fmt.Printf("Warning: LLM task extraction failed: %v\n", err)
+		return nil
+	}
+
+	// Models often wrap the array in prose or code fences; keep only the outermost brackets
+	start, end := strings.Index(resp, "["), strings.LastIndex(resp, "]")
+	if start < 0 || end <= start {
+		fmt.Printf("Warning: LLM task extraction returned no JSON array\n")
+		return nil
+	}
+
+	var raw []EmailTask
+	if err := json.Unmarshal([]byte(resp[start:end+1]), &raw); err != nil {
+		fmt.Printf("Warning: LLM task extraction returned malformed JSON: %v\n", err)
+		return nil
+	}
+
+	var tasks []EmailTask
+	for _, t := range raw {
+		t.Description = strings.TrimSpace(t.Description)
+		if t.Description == "" {
+			continue
+		}
+		t.Context = subject
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
 func (w *EmailParserWorker) extractTasksFromText(subject, body string) []EmailTask {
 	tasks := []EmailTask{}
 
@@ -469,9 +771,10 @@ func (w *EmailParserWorker) extractDueDate(subject, body string) string {
 
 func (w *EmailParserWorker) searchBySubject(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
-		Pattern string `json:"pattern"`
-		Folder  string `json:"folder,omitempty"`
-		Limit   int    `json:"limit,omitempty"`
+		Pattern   string `json:"pattern"`
+		Folder    string `json:"folder,omitempty"`
+		Limit     int    `json:"limit,omitempty"`
+		Recursive bool   `json:"recursive,omitempty"` // search every folder instead of just Folder
 	}
 	if err := json.Unmarshal(input, &req); err != nil {
 		return nil, err
@@ -484,24 +787,36 @@ func (w *EmailParserWorker) searchBySubject(ctx context.Context, input json.RawM
 		req.Limit = 50
 	}
 
-	folderPath := filepath.Join(w.maildirPath, req.Folder, "cur")
-	entries, err := os.ReadDir(folderPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read folder: %w", err)
-	}
-
 	re, err := regexp.Compile(req.Pattern)
 	if err != nil {
 		return nil, fmt.Errorf("invalid pattern: %w", err)
 	}
 
+	folders := []string{req.Folder}
+	if req.Recursive {
+		if folders, err = w.maildirFolders(); err != nil {
+			return nil, err
+		}
+	}
+
+	var paths []string
+	for _, folder := range folders {
+		folderPaths, err := maildirMessages(filepath.Join(w.maildirPath, folder))
+		if err != nil {
+			if req.Recursive {
+				continue
+			}
+			return nil, err
+		}
+		paths = append(paths, folderPaths...)
+	}
+
 	matches := []*EmailData{}
-	for _, entry := range entries {
+	for _, path := range paths {
 		if len(matches) >= req.Limit {
 			break
 		}
-		
-		path := filepath.Join(folderPath, entry.Name())
+
 		data, err := os.ReadFile(path)
 		if err != nil {
 			continue
@@ -537,10 +852,9 @@ func (w *EmailParserWorker) listRecent(ctx context.Context, input json.RawMessag
 		req.Limit = 20
 	}
 
-	folderPath := filepath.Join(w.maildirPath, req.Folder, "cur")
-	entries, err := os.ReadDir(folderPath)
+	paths, err := maildirMessages(filepath.Join(w.maildirPath, req.Folder))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read folder: %w", err)
+		return nil, err
 	}
 
 	type fileWithTime struct {
@@ -550,15 +864,15 @@ func (w *EmailParserWorker) listRecent(ctx context.Context, input json.RawMessag
 	}
 
 	files := []fileWithTime{}
-	for _, entry := range entries {
-		info, err := entry.Info()
+	for _, path := range paths {
+		info, err := os.Stat(path)
 		if err != nil {
 			continue
 		}
 		files = append(files, fileWithTime{
-			name: entry.Name(),
+			name: filepath.Base(path),
 			info: info,
-			path: filepath.Join(folderPath, entry.Name()),
+			path: path,
 		})
 	}
 
@@ -593,6 +907,222 @@ func (w *EmailParserWorker) listRecent(ctx context.Context, input json.RawMessag
 	return json.Marshal(emails)
 }
 
+// maildirMessages returns the message files in a Maildir folder's cur and new
+// directories. Freshly delivered mail stays in new until a client reads it.
+func maildirMessages(folderPath string) ([]string, error) {
+	var paths []string
+	found := false
+	for _, sub := range []string{"cur", "new"} {
+		dir := filepath.Join(folderPath, sub)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		found = true
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				paths = append(paths, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("failed to read folder: %s is not a Maildir folder", folderPath)
+	}
+	return paths, nil
+}
+
+// isMaildirFolder reports whether dir has a cur or new subdirectory
+func isMaildirFolder(dir string) bool {
+	for _, sub := range []string{"cur", "new"} {
+		if info, err := os.Stat(filepath.Join(dir, sub)); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// maildirFolders returns every folder under maildirPath relative to it, "." being
+// maildirPath itself
+func (w *EmailParserWorker) maildirFolders() ([]string, error) {
+	var folders []string
+	err := filepath.WalkDir(w.maildirPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		switch d.Name() {
+		case "cur", "new", "tmp":
+			return filepath.SkipDir
+		}
+		if isMaildirFolder(path) {
+			rel, err := filepath.Rel(w.maildirPath, path)
+			if err != nil {
+				return err
+			}
+			folders = append(folders, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	return folders, nil
+}
+
+func (w *EmailParserWorker) listFolders(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	folders, err := w.maildirFolders()
+	if err != nil {
+		return nil, err
+	}
+
+	type folderInfo struct {
+		Name     string `json:"name"`
+		Messages int    `json:"messages"`
+		New      int    `json:"new"`
+	}
+
+	infos := []folderInfo{}
+	for _, folder := range folders {
+		info := folderInfo{Name: folder}
+		if entries, err := os.ReadDir(filepath.Join(w.maildirPath, folder, "cur")); err == nil {
+			info.Messages += len(entries)
+		}
+		if entries, err := os.ReadDir(filepath.Join(w.maildirPath, folder, "new")); err == nil {
+			info.Messages += len(entries)
+			info.New = len(entries)
+		}
+		infos = append(infos, info)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"folders": infos,
+		"count":   len(infos),
+	})
+}
+
+// EmailThread is one conversation reconstructed from a folder
+type EmailThread struct {
+	RootSubject  string    `json:"root_subject"`
+	MessageIDs   []string  `json:"message_ids"` // oldest first
+	Participants []string  `json:"participants"`
+	StartedAt    time.Time `json:"started_at"`
+	LastAt       time.Time `json:"last_at"`
+}
+
+// replyPrefixPattern matches the reply and forward markers stacked at the start of a subject
+var replyPrefixPattern = regexp.MustCompile(`(?i)^\s*((re|fwd?|aw|wg)(\[\d+\])?\s*:\s*)+`)
+
+// normalizeSubject strips Re:/Fwd: prefixes so replies group with their original
+func normalizeSubject(subject string) string {
+	return strings.ToLower(strings.TrimSpace(replyPrefixPattern.ReplaceAllString(subject, "")))
+}
+
+// buildThreads links messages that share a reference chain (Message-Id, In-Reply-To,
+// References). Messages with no references join the thread of an earlier message with
+// the same normalized subject.
+func (w *EmailParserWorker) buildThreads(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		Folder string `json:"folder,omitempty"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+
+	if req.Folder == "" {
+		req.Folder = "INBOX"
+	}
+
+	paths, err := maildirMessages(filepath.Join(w.maildirPath, req.Folder))
+	if err != nil {
+		return nil, err
+	}
+
+	var emails []*EmailData
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		email, err := w.parseEmail(string(data))
+		if err != nil {
+			continue
+		}
+		if email.MessageID == "" {
+			email.MessageID = email.ID
+		}
+		emails = append(emails, email)
+	}
+	sort.SliceStable(emails, func(i, j int) bool { return emails[i].Date.Before(emails[j].Date) })
+
+	// Union-find over message ids, including referenced ids missing from the folder,
+	// so two replies to an absent message still land in one thread
+	parent := map[string]string{}
+	var find func(id string) string
+	find = func(id string) string {
+		p, ok := parent[id]
+		if !ok || p == id {
+			parent[id] = id
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	union := func(a, b string) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	bySubject := map[string]string{}
+	for _, email := range emails {
+		refs := append([]string{}, email.References...)
+		if email.InReplyTo != "" {
+			refs = append(refs, email.InReplyTo)
+		}
+		find(email.MessageID)
+		for _, ref := range refs {
+			union(email.MessageID, strings.TrimSpace(ref))
+		}
+
+		subject := normalizeSubject(email.Subject)
+		if first, ok := bySubject[subject]; ok && len(refs) == 0 && subject != "" {
+			union(first, email.MessageID)
+		} else if !ok {
+			bySubject[subject] = email.MessageID
+		}
+	}
+
+	threadsByRoot := map[string]*EmailThread{}
+	participants := map[string]map[string]bool{}
+	threads := []*EmailThread{}
+	for _, email := range emails {
+		root := find(email.MessageID)
+		thread, ok := threadsByRoot[root]
+		if !ok {
+			thread = &EmailThread{RootSubject: email.Subject, StartedAt: email.Date}
+			threadsByRoot[root] = thread
+			participants[root] = map[string]bool{}
+			threads = append(threads, thread)
+		}
+		thread.MessageIDs = append(thread.MessageIDs, email.MessageID)
+		thread.LastAt = email.Date
+		for _, addr := range email.From {
+			if !participants[root][addr] {
+				participants[root][addr] = true
+				thread.Participants = append(thread.Participants, addr)
+			}
+		}
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"threads": threads,
+		"count":   len(threads),
+	})
+}
+
 func (w *EmailParserWorker) resolvePath(path string) string {
 	if filepath.IsAbs(path) {
 		return path
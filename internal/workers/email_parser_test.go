@@ -0,0 +1,381 @@
+package workers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleTaskEmail = "From: Alice <alice@example.com>\r\n" +
+	"To: Bob <bob@example.com>\r\n" +
+	"Subject: Q3 invoice\r\n" +
+	"\r\n" +
+	"Hi Bob, please review the attached invoice and send it to finance by Friday.\r\n"
+
+func extractTestTasks(t *testing.T, w *EmailParserWorker, content string) TaskExtraction {
+	input, _ := json.Marshal(map[string]string{"content": content})
+	out, err := w.Execute(context.Background(), "email_extract_tasks", input)
+	require.NoError(t, err)
+
+	var extraction TaskExtraction
+	require.NoError(t, json.Unmarshal(out, &extraction))
+	return extraction
+}
+
+func TestExtractTasks_UsesLLM(t *testing.T) {
+	w := NewEmailParserWorker(t.TempDir())
+	w.SetLLMCaller(&fakeLLM{response: "Sure:\n```json\n[" +
+		`{"description": "Review the Q3 invoice", "action_items": ["Check totals"], "assigned_to": "Bob", "due_date": "Friday"},` +
+		`{"description": "  "}` +
+		"]\n```"})
+
+	extraction := extractTestTasks(t, w, sampleTaskEmail)
+	require.Len(t, extraction.Tasks, 1)
+	task := extraction.Tasks[0]
+	assert.Equal(t, "Review the Q3 invoice", task.Description)
+	assert.Equal(t, []string{"Check totals"}, task.ActionItems)
+	assert.Equal(t, "Bob", task.AssignedTo)
+	assert.Equal(t, "Friday", task.DueDate)
+	assert.Equal(t, "Q3 invoice", task.Context)
+}
+
+func TestExtractTasks_LLMPromptCutOnRuneBoundary(t *testing.T) {
+	w := NewEmailParserWorker(t.TempDir())
+	var prompt string
+	w.SetLLMCaller(promptLLM(func(p string) string {
+		prompt = p
+		return "[]"
+	}))
+
+	// The body limit falls in the middle of the "€"
+	body := strings.Repeat("a", maxTaskExtractionChars-1) + "€ please review"
+	extractTestTasks(t, w, "Subject: long\r\n\r\n"+body)
+	assert.True(t, utf8.ValidString(prompt))
+	assert.True(t, strings.HasSuffix(prompt, strings.Repeat("a", maxTaskExtractionChars-1)))
+}
+
+func TestExtractTasks_FallsBackToKeywords(t *testing.T) {
+	for name, llm := range map[string]LLMCaller{
+		"no llm":    nil,
+		"error":     &fakeLLM{err: errors.New("offline")},
+		"malformed": &fakeLLM{response: `[{"description": `},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := NewEmailParserWorker(t.TempDir())
+			w.SetLLMCaller(llm)
+
+			extraction := extractTestTasks(t, w, sampleTaskEmail)
+			require.NotEmpty(t, extraction.Tasks)
+			assert.Equal(t, "Action required: Review", extraction.Tasks[0].Description)
+		})
+	}
+}
+
+func TestParseFile_Emlx(t *testing.T) {
+	message := "From: alice@example.com\nSubject: Apple Mail\n\nHello from Mail.app\n"
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>flags</key><integer>8590195713</integer></dict></plist>
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "1234.emlx")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf("%d\n%s%s", len(message), message, plist)), 0644))
+
+	w := NewEmailParserWorker(dir)
+	out, err := w.Execute(context.Background(), "email_parse_file", json.RawMessage(`{"path":"1234.emlx"}`))
+	require.NoError(t, err)
+
+	var email EmailData
+	require.NoError(t, json.Unmarshal(out, &email))
+	assert.Equal(t, "Apple Mail", email.Subject)
+	assert.Equal(t, []string{"alice@example.com"}, email.From)
+	assert.Equal(t, "Hello from Mail.app\n", email.BodyText)
+}
+
+func TestEmlxMessage(t *testing.T) {
+	_, ok := emlxMessage([]byte("From: alice@example.com\n\nbody"))
+	assert.False(t, ok, "plain RFC822 is not emlx")
+
+	message, ok := emlxMessage([]byte("999\nSubject: x\n\nbody\n<?xml version=\"1.0\"?><plist/>"))
+	require.True(t, ok)
+	assert.Equal(t, "Subject: x\n\nbody\n", string(message))
+}
+
+func parseTestEmail(t *testing.T, content string) EmailData {
+	w := NewEmailParserWorker(t.TempDir())
+	input, _ := json.Marshal(map[string]string{"content": content})
+	out, err := w.Execute(context.Background(), "email_parse_raw", input)
+	require.NoError(t, err)
+
+	var email EmailData
+	require.NoError(t, json.Unmarshal(out, &email))
+	return email
+}
+
+func TestParseEmail_DecodesEncodedWords(t *testing.T) {
+	email := parseTestEmail(t, "From: =?UTF-8?Q?Jos=C3=A9_Garc=C3=ADa?= <jose@example.com>\r\n"+
+		"To: =?UTF-8?B?TcO8bGxlcg==?= <muller@example.com>, bob@example.com\r\n"+
+		"Subject: =?UTF-8?B?UmVjaG51bmcgZsO8cg==?= =?UTF-8?Q?_M=C3=A4rz?=\r\n"+
+		"\r\n"+
+		"body\r\n")
+
+	assert.Equal(t, "Rechnung für März", email.Subject)
+	assert.Equal(t, []string{"José García <jose@example.com>"}, email.From)
+	assert.Equal(t, []string{"Müller <muller@example.com>", "bob@example.com"}, email.To)
+}
+
+func TestParseEmail_DecodesEncodedWordsInOtherCharsets(t *testing.T) {
+	email := parseTestEmail(t, "From: =?windows-1252?Q?Fran=E7ois_=93Frank=94?= <francois@example.com>\r\n"+
+		"Subject: =?ISO-2022-JP?B?GyRCRnxLXDhsGyhC?=\r\n"+
+		"\r\n"+
+		"body\r\n")
+
+	assert.Equal(t, "日本語", email.Subject)
+	assert.Equal(t, []string{"François “Frank” <francois@example.com>"}, email.From)
+}
+
+func TestParseEmail_DecodesQuotedPrintableBody(t *testing.T) {
+	email := parseTestEmail(t, "Subject: qp\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"Content-Transfer-Encoding: quoted-printable\r\n"+
+		"\r\n"+
+		"Caf=C3=A9 au lait, a long line that was soft=\r\n"+
+		" wrapped\r\n")
+
+	assert.Equal(t, "Café au lait, a long line that was soft wrapped\r\n", email.BodyText)
+}
+
+func TestParseEmail_DecodesMultipartParts(t *testing.T) {
+	pdf := []byte("%PDF-1.4 invoice bytes")
+	email := parseTestEmail(t, "Subject: invoice\r\n"+
+		"Content-Type: multipart/mixed; boundary=XYZ\r\n"+
+		"\r\n"+
+		"--XYZ\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"Content-Transfer-Encoding: quoted-printable\r\n"+
+		"\r\n"+
+		"Total: 10=E2=82=AC\r\n"+
+		"--XYZ\r\n"+
+		"Content-Type: application/pdf; name=invoice.pdf\r\n"+
+		"Content-Disposition: attachment; filename=invoice.pdf\r\n"+
+		"Content-Transfer-Encoding: base64\r\n"+
+		"\r\n"+
+		base64.StdEncoding.EncodeToString(pdf)+"\r\n"+
+		"--XYZ--\r\n")
+
+	assert.Equal(t, "Total: 10€", email.BodyText)
+	require.Len(t, email.Attachments, 1)
+	att := email.Attachments[0]
+	assert.Equal(t, "invoice.pdf", att.Filename)
+	assert.Equal(t, int64(len(pdf)), att.Size)
+
+	sum := sha256.Sum256(pdf)
+	assert.Equal(t, base64.URLEncoding.EncodeToString(sum[:])[:16], att.ContentHash)
+}
+
+func TestParseRaw_SavesAttachments(t *testing.T) {
+	pdf := []byte("%PDF-1.4 invoice bytes")
+	content := "Subject: invoice\r\n" +
+		"Content-Type: multipart/mixed; boundary=XYZ\r\n" +
+		"\r\n" +
+		"--XYZ\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"See attached.\r\n" +
+		"--XYZ\r\n" +
+		"Content-Type: application/pdf\r\n" +
+		"Content-Disposition: attachment; filename=\"../../invoice.pdf\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		base64.StdEncoding.EncodeToString(pdf) + "\r\n" +
+		"--XYZ--\r\n"
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "attachments")
+	w := NewEmailParserWorker(root)
+	input, _ := json.Marshal(map[string]string{"content": content, "save_attachments_to": "attachments"})
+	out, err := w.Execute(context.Background(), "email_parse_raw", input)
+	require.NoError(t, err)
+
+	var email EmailData
+	require.NoError(t, json.Unmarshal(out, &email))
+	require.Len(t, email.Attachments, 1)
+	assert.Equal(t, filepath.Join(dir, "invoice.pdf"), email.Attachments[0].Path)
+
+	saved, err := os.ReadFile(email.Attachments[0].Path)
+	require.NoError(t, err)
+	assert.Equal(t, pdf, saved)
+}
+
+func TestParseRaw_AttachmentDirMustBeInMaildir(t *testing.T) {
+	content := "Subject: x\r\nContent-Type: application/pdf; name=a.pdf\r\n\r\nbytes\r\n"
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+	w := NewEmailParserWorker(root)
+
+	for _, dir := range []string{outside, "../escape", "link/attachments"} {
+		input, _ := json.Marshal(map[string]string{"content": content, "save_attachments_to": dir})
+		_, err := w.Execute(context.Background(), "email_parse_raw", input)
+		assert.ErrorContains(t, err, "outside maildir path", dir)
+	}
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestParseRaw_AttachmentsMetadataOnlyByDefault(t *testing.T) {
+	email := parseTestEmail(t, "Subject: x\r\n"+
+		"Content-Type: application/pdf; name=a.pdf\r\n"+
+		"\r\n"+
+		"bytes\r\n")
+
+	require.Len(t, email.Attachments, 1)
+	assert.Empty(t, email.Attachments[0].Path)
+}
+
+// writeTestMaildirMessage writes a message with the given subject into folder/sub
+func writeTestMaildirMessage(t *testing.T, root, folder, sub, name, subject string) {
+	dir := filepath.Join(root, folder, sub)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	content := "From: alice@example.com\r\nSubject: " + subject + "\r\n\r\nbody\r\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func emailSubjects(t *testing.T, out []byte) []string {
+	var emails []EmailData
+	require.NoError(t, json.Unmarshal(out, &emails))
+	var subjects []string
+	for _, e := range emails {
+		subjects = append(subjects, e.Subject)
+	}
+	return subjects
+}
+
+func TestMaildir_ReadsCurAndNew(t *testing.T) {
+	root := t.TempDir()
+	writeTestMaildirMessage(t, root, "INBOX", "cur", "1", "Invoice read")
+	writeTestMaildirMessage(t, root, "INBOX", "new", "2", "Invoice unread")
+	writeTestMaildirMessage(t, root, "Archive", "cur", "3", "Invoice archived")
+	w := NewEmailParserWorker(root)
+
+	out, err := w.Execute(context.Background(), "email_search_by_subject", json.RawMessage(`{"pattern":"Invoice"}`))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Invoice read", "Invoice unread"}, emailSubjects(t, out))
+
+	out, err = w.Execute(context.Background(), "email_search_by_subject", json.RawMessage(`{"pattern":"Invoice","recursive":true}`))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Invoice read", "Invoice unread", "Invoice archived"}, emailSubjects(t, out))
+
+	out, err = w.Execute(context.Background(), "email_list_recent", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Invoice read", "Invoice unread"}, emailSubjects(t, out))
+}
+
+func TestListFolders(t *testing.T) {
+	root := t.TempDir()
+	writeTestMaildirMessage(t, root, "INBOX", "cur", "1", "a")
+	writeTestMaildirMessage(t, root, "INBOX", "new", "2", "b")
+	writeTestMaildirMessage(t, root, filepath.Join("Archive", "2024"), "cur", "3", "c")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "not-a-folder"), 0755))
+
+	out, err := NewEmailParserWorker(root).Execute(context.Background(), "email_list_folders", json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Folders []struct {
+			Name     string `json:"name"`
+			Messages int    `json:"messages"`
+			New      int    `json:"new"`
+		} `json:"folders"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.Len(t, resp.Folders, 2)
+	assert.Equal(t, filepath.Join("Archive", "2024"), resp.Folders[0].Name)
+	assert.Equal(t, 1, resp.Folders[0].Messages)
+	assert.Equal(t, "INBOX", resp.Folders[1].Name)
+	assert.Equal(t, 2, resp.Folders[1].Messages)
+	assert.Equal(t, 1, resp.Folders[1].New)
+}
+
+func writeTestThreadMessage(t *testing.T, root, name, headers string) {
+	dir := filepath.Join(root, "INBOX", "cur")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(headers+"\r\nbody\r\n"), 0644))
+}
+
+func TestBuildThreads(t *testing.T) {
+	root := t.TempDir()
+	writeTestThreadMessage(t, root, "1", "Message-Id: <a@x>\r\nFrom: alice@example.com\r\nSubject: Contract renewal\r\nDate: Mon, 01 Jan 2024 09:00:00 +0000\r\n")
+	writeTestThreadMessage(t, root, "3", "Message-Id: <c@x>\r\nFrom: alice@example.com\r\nSubject: Re: Re: Contract renewal\r\nDate: Mon, 01 Jan 2024 11:00:00 +0000\r\nIn-Reply-To: <b@x>\r\nReferences: <a@x> <b@x>\r\n")
+	writeTestThreadMessage(t, root, "2", "Message-Id: <b@x>\r\nFrom: bob@example.com\r\nSubject: Re: Contract renewal\r\nDate: Mon, 01 Jan 2024 10:00:00 +0000\r\nIn-Reply-To: <a@x>\r\n")
+	writeTestThreadMessage(t, root, "4", "Message-Id: <d@x>\r\nFrom: carol@example.com\r\nSubject: Lunch\r\nDate: Mon, 01 Jan 2024 12:00:00 +0000\r\n")
+
+	out, err := NewEmailParserWorker(root).Execute(context.Background(), "email_build_threads", json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Threads []EmailThread `json:"threads"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.Len(t, resp.Threads, 2)
+
+	assert.Equal(t, "Contract renewal", resp.Threads[0].RootSubject)
+	assert.Equal(t, []string{"<a@x>", "<b@x>", "<c@x>"}, resp.Threads[0].MessageIDs)
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com"}, resp.Threads[0].Participants)
+
+	assert.Equal(t, "Lunch", resp.Threads[1].RootSubject)
+	assert.Equal(t, []string{"<d@x>"}, resp.Threads[1].MessageIDs)
+}
+
+func TestBuildThreads_SubjectFallback(t *testing.T) {
+	root := t.TempDir()
+	writeTestThreadMessage(t, root, "1", "Message-Id: <a@x>\r\nSubject: Lunch\r\nDate: Mon, 01 Jan 2024 09:00:00 +0000\r\n")
+	writeTestThreadMessage(t, root, "2", "Message-Id: <b@x>\r\nSubject: RE: Fwd: lunch\r\nDate: Mon, 01 Jan 2024 10:00:00 +0000\r\n")
+
+	out, err := NewEmailParserWorker(root).Execute(context.Background(), "email_build_threads", json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Threads []EmailThread `json:"threads"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.Len(t, resp.Threads, 1)
+	assert.Equal(t, []string{"<a@x>", "<b@x>"}, resp.Threads[0].MessageIDs)
+}
+
+func TestNormalizeSubject(t *testing.T) {
+	assert.Equal(t, "budget", normalizeSubject("Re: FWD: re[2]: Budget "))
+	assert.Equal(t, "regarding budget", normalizeSubject("Regarding budget"))
+}
+
+func TestHTMLToText(t *testing.T) {
+	w := NewEmailParserWorker(t.TempDir())
+	text := w.htmlToText(`<html><head><title>ignored</title><style>p { color: red }</style></head>
+<body>
+<h1>Weekly update</h1>
+<p>Hi team,<br>see the <a href="https://example.com/report">full report</a> &amp; notes.</p>
+<ul><li>Ship v2</li><li>Fix   login</li></ul>
+<table>
+  <tr> <td>Hours</td> <td>12</td> </tr>
+</table>
+<script>alert("x")</script>
+<p>Visit <a href="https://example.com">https://example.com</a></p>
+</body></html>`)
+
+	assert.Equal(t, "Weekly update\n\n"+
+		"Hi team,\nsee the full report (https://example.com/report) & notes.\n\n"+
+		"- Ship v2\n- Fix login\n\n"+
+		"Hours\t12\n\n"+
+		"Visit https://example.com", text)
+}
@@ -4,20 +4,28 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/mail"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type GitWorker struct {
-	basePath string
+	basePath     string
+	allowedRepos []string
 }
 
-func NewGitWorker(basePath string) *GitWorker {
-	return &GitWorker{basePath: basePath}
+// NewGitWorker creates a git worker for repositories under basePath. allowedRepos
+// limits which repositories it operates on, by name relative to basePath or by
+// absolute path; empty or "*" allows all.
+func NewGitWorker(basePath string, allowedRepos []string) *GitWorker {
+	return &GitWorker{basePath: basePath, allowedRepos: allowedRepos}
 }
 
 func (w *GitWorker) GetTools() []ToolDef {
@@ -31,6 +39,15 @@ func (w *GitWorker) GetTools() []ToolDef {
 		{Name: "pull", Description: "Pull from remote"},
 		{Name: "branch", Description: "Manage branches"},
 		{Name: "checkout", Description: "Checkout a branch or commit"},
+		{Name: "blame", Description: "Show who last changed each line of a file"},
+		{Name: "show", Description: "Show a commit's metadata and diff"},
+		{Name: "remote", Description: "List, add, remove, or change remotes"},
+		{Name: "stash", Description: "Stash uncommitted changes"},
+		{Name: "stash_pop", Description: "Restore the latest or a given stash"},
+		{Name: "stash_list", Description: "List stashes"},
+		{Name: "worktree_add", Description: "Check out a branch in a new worktree"},
+		{Name: "worktree_list", Description: "List a repository's worktrees"},
+		{Name: "worktree_remove", Description: "Remove a worktree"},
 	}
 }
 
@@ -54,6 +71,24 @@ func (w *GitWorker) Execute(ctx context.Context, name string, input json.RawMess
 		return w.branch(ctx, input)
 	case "checkout", "git_checkout":
 		return w.checkout(ctx, input)
+	case "blame", "git_blame":
+		return w.blame(ctx, input)
+	case "show", "git_show":
+		return w.show(ctx, input)
+	case "remote", "git_remote":
+		return w.remote(ctx, input)
+	case "stash", "git_stash":
+		return w.stash(ctx, input)
+	case "stash_pop", "git_stash_pop":
+		return w.stashPop(ctx, input)
+	case "stash_list", "git_stash_list":
+		return w.stashList(ctx, input)
+	case "worktree_add", "git_worktree_add":
+		return w.worktreeAdd(ctx, input)
+	case "worktree_list", "git_worktree_list":
+		return w.worktreeList(ctx, input)
+	case "worktree_remove", "git_worktree_remove":
+		return w.worktreeRemove(ctx, input)
 	default:
 		return nil, nil
 	}
@@ -80,6 +115,10 @@ func (w *GitWorker) clone(ctx context.Context, input json.RawMessage) ([]byte, e
 		path = strings.TrimSuffix(name, ".git")
 	}
 
+	if err := w.checkRepoAllowed(w.resolveRepoPath(path)); err != nil {
+		return nil, err
+	}
+
 	args := []string{"clone"}
 	if req.Branch != "" {
 		args = append(args, "-b", req.Branch)
@@ -108,6 +147,9 @@ func (w *GitWorker) status(ctx context.Context, input json.RawMessage) ([]byte,
 	json.Unmarshal(input, &req)
 
 	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
 	cmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
 	cmd.Dir = repoPath
 	out, err := cmd.CombinedOutput()
@@ -149,6 +191,9 @@ func (w *GitWorker) log(ctx context.Context, input json.RawMessage) ([]byte, err
 	}
 
 	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
 	args := []string{"log", fmt.Sprintf("-%d", count), "--pretty=format:%h|%s|%an|%ai"}
 	if req.Branch != "" {
 		args = append(args, req.Branch)
@@ -192,6 +237,9 @@ func (w *GitWorker) diff(ctx context.Context, input json.RawMessage) ([]byte, er
 	json.Unmarshal(input, &req)
 
 	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
 	args := []string{"diff"}
 	if req.Target != "" {
 		args = append(args, req.Target)
@@ -214,9 +262,12 @@ func (w *GitWorker) diff(ctx context.Context, input json.RawMessage) ([]byte, er
 }
 
 type CommitInput struct {
-	Repo    string   `json:"repo"`
-	Message string   `json:"message"`
-	Files   []string `json:"files"`
+	Repo        string   `json:"repo"`
+	Message     string   `json:"message"`
+	Files       []string `json:"files"`
+	AuthorName  string   `json:"author_name"`  // overrides the configured user.name
+	AuthorEmail string   `json:"author_email"` // overrides the configured user.email
+	Sign        bool     `json:"sign"`         // GPG-sign the commit
 }
 
 func (w *GitWorker) commit(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -227,7 +278,25 @@ func (w *GitWorker) commit(ctx context.Context, input json.RawMessage) ([]byte,
 		return nil, fmt.Errorf("message is required")
 	}
 
+	// Set the identity per command so commits don't take the server's git config
+	var identity []string
+	if req.AuthorName != "" {
+		if strings.ContainsAny(req.AuthorName, "<>\n") {
+			return nil, fmt.Errorf("invalid author_name: %q", req.AuthorName)
+		}
+		identity = append(identity, "-c", "user.name="+req.AuthorName)
+	}
+	if req.AuthorEmail != "" {
+		if addr, err := mail.ParseAddress(req.AuthorEmail); err != nil || addr.Address != req.AuthorEmail {
+			return nil, fmt.Errorf("invalid author_email: %q", req.AuthorEmail)
+		}
+		identity = append(identity, "-c", "user.email="+req.AuthorEmail)
+	}
+
 	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
 
 	if len(req.Files) > 0 {
 		for _, f := range req.Files {
@@ -245,21 +314,27 @@ func (w *GitWorker) commit(ctx context.Context, input json.RawMessage) ([]byte,
 		}
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "commit", "-m", req.Message)
-	cmd.Dir = repoPath
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("%s: %s", err, string(out))
+	args := append(identity, "commit", "-m", req.Message)
+	if req.Sign {
+		args = append(args, "-S")
+	}
+	if _, err := runGit(ctx, repoPath, args...); err != nil {
+		return nil, err
 	}
 
-	return json.Marshal(map[string]string{
+	result := map[string]string{
 		"status":  "committed",
 		"message": req.Message,
-	})
+	}
+	if author, err := runGit(ctx, repoPath, "log", "-1", "--format=%an <%ae>"); err == nil {
+		result["author"] = strings.TrimSpace(author)
+	}
+	return json.Marshal(result)
 }
 
 type PushInput struct {
 	Repo   string `json:"repo"`
+	Remote string `json:"remote"` // defaults to origin
 	Branch string `json:"branch"`
 	Force  bool   `json:"force"`
 }
@@ -268,13 +343,23 @@ func (w *GitWorker) push(ctx context.Context, input json.RawMessage) ([]byte, er
 	var req PushInput
 	json.Unmarshal(input, &req)
 
+	if req.Remote != "" && !validRemoteName(req.Remote) {
+		return nil, fmt.Errorf("invalid remote name: %s", req.Remote)
+	}
+
 	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
 	args := []string{"push"}
 	if req.Force {
 		args = append(args, "-f")
 	}
+	if req.Remote != "" || req.Branch != "" {
+		args = append(args, remoteOrOrigin(req.Remote))
+	}
 	if req.Branch != "" {
-		args = append(args, "origin", req.Branch)
+		args = append(args, req.Branch)
 	}
 
 	cmd := exec.CommandContext(ctx, "git", args...)
@@ -291,36 +376,476 @@ func (w *GitWorker) push(ctx context.Context, input json.RawMessage) ([]byte, er
 }
 
 type PullInput struct {
-	Repo   string `json:"repo"`
-	Branch string `json:"branch"`
+	Repo      string `json:"repo"`
+	Remote    string `json:"remote"` // defaults to origin
+	Branch    string `json:"branch"`
+	Autostash bool   `json:"autostash"` // stash local changes first and restore them after
 }
 
 func (w *GitWorker) pull(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req PullInput
 	json.Unmarshal(input, &req)
 
+	if req.Remote != "" && !validRemoteName(req.Remote) {
+		return nil, fmt.Errorf("invalid remote name: %s", req.Remote)
+	}
+
 	repoPath := w.resolveRepoPath(req.Repo)
-	args := []string{"pull", "origin"}
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
+	args := []string{"pull", remoteOrOrigin(req.Remote)}
 	if req.Branch != "" {
 		args = append(args, req.Branch)
 	} else {
 		args = append(args, "HEAD")
 	}
 
+	stashed := false
+	if req.Autostash {
+		var err error
+		if stashed, err = pushStash(ctx, repoPath, "autostash before pull", true); err != nil {
+			return nil, err
+		}
+	}
+
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = repoPath
 	out, err := cmd.CombinedOutput()
 	if err != nil {
+		if stashed {
+			// Put the changes back even though the pull failed
+			if _, popErr := runGit(ctx, repoPath, "stash", "pop"); popErr != nil {
+				return nil, fmt.Errorf("%s: %s (changes remain stashed: %v)", err, string(out), popErr)
+			}
+		}
 		return nil, fmt.Errorf("%s: %s", err, string(out))
 	}
 
+	if stashed {
+		if _, err := runGit(ctx, repoPath, "stash", "pop"); err != nil {
+			return nil, fmt.Errorf("pulled, but restoring stashed changes failed; they remain in the stash: %w", err)
+		}
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"status":  "pulled",
+		"repo":    req.Repo,
+		"output":  string(out),
+		"stashed": stashed,
+	})
+}
+
+type BlameInput struct {
+	Repo      string `json:"repo"`
+	File      string `json:"file"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"` // defaults to the end of the file
+}
+
+// BlameLine attributes one line of a file to the commit that last changed it
+type BlameLine struct {
+	Commit      string `json:"commit"`
+	Author      string `json:"author"`
+	AuthorEmail string `json:"author_email"`
+	Date        string `json:"date"`
+	LineNumber  int    `json:"line_number"`
+	Line        string `json:"line"`
+}
+
+func (w *GitWorker) blame(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req BlameInput
+	json.Unmarshal(input, &req)
+
+	if req.File == "" {
+		return nil, fmt.Errorf("file is required")
+	}
+	if req.StartLine < 0 || req.EndLine < 0 || (req.EndLine > 0 && req.EndLine < req.StartLine) {
+		return nil, fmt.Errorf("invalid line range: %d-%d", req.StartLine, req.EndLine)
+	}
+
+	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
+	filePath, err := pathWithin(repoPath, req.File)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s", req.File)
+	}
+	if info, err := os.Stat(filePath); err != nil || info.IsDir() {
+		return nil, fmt.Errorf("file not found: %s", req.File)
+	}
+
+	args := []string{"blame", "--line-porcelain"}
+	if req.StartLine > 0 || req.EndLine > 0 {
+		start := max(req.StartLine, 1)
+		lineRange := fmt.Sprintf("%d,", start)
+		if req.EndLine > 0 {
+			lineRange += strconv.Itoa(req.EndLine)
+		}
+		args = append(args, "-L", lineRange)
+	}
+	args = append(args, "--", filePath)
+
+	out, err := runGit(ctx, repoPath, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := parseBlame(out)
+	return json.Marshal(map[string]interface{}{
+		"repo":  req.Repo,
+		"file":  req.File,
+		"lines": lines,
+	})
+}
+
+// parseBlame reads git blame --line-porcelain output, where every line gets a
+// "<commit> <orig line> <final line>" header, its commit's attributes, and the
+// line itself prefixed by a tab
+func parseBlame(out string) []BlameLine {
+	lines := []BlameLine{}
+	var current BlameLine
+	var authorTime int64
+	var authorTZ string
+	for _, line := range strings.Split(out, "\n") {
+		if content, ok := strings.CutPrefix(line, "\t"); ok {
+			if authorTime > 0 {
+				current.Date = blameTime(authorTime, authorTZ)
+			}
+			current.Line = content
+			lines = append(lines, current)
+			current, authorTime, authorTZ = BlameLine{}, 0, ""
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-mail":
+			current.AuthorEmail = strings.Trim(value, "<>")
+		case "author-time":
+			authorTime, _ = strconv.ParseInt(value, 10, 64)
+		case "author-tz":
+			authorTZ = value
+		default:
+			if fields := strings.Fields(line); current.Commit == "" && len(fields) >= 3 && len(fields[0]) == 40 {
+				current.Commit = fields[0]
+				current.LineNumber, _ = strconv.Atoi(fields[2])
+			}
+		}
+	}
+	return lines
+}
+
+// blameTime formats a Unix time in the author's +hhmm zone as RFC 3339
+func blameTime(unix int64, tz string) string {
+	t := time.Unix(unix, 0).UTC()
+	if len(tz) == 5 {
+		hours, _ := strconv.Atoi(tz[1:3])
+		minutes, _ := strconv.Atoi(tz[3:5])
+		offset := hours*3600 + minutes*60
+		if tz[0] == '-' {
+			offset = -offset
+		}
+		t = t.In(time.FixedZone(tz, offset))
+	}
+	return t.Format(time.RFC3339)
+}
+
+type ShowInput struct {
+	Repo   string `json:"repo"`
+	Commit string `json:"commit"`
+}
+
+// gitRevPattern allows hashes and ref expressions such as HEAD~2 or main^, but not
+// anything git could read as an option
+var gitRevPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._/@{}~^-]*$`)
+
+func (w *GitWorker) show(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req ShowInput
+	json.Unmarshal(input, &req)
+
+	if req.Commit == "" {
+		req.Commit = "HEAD"
+	}
+	if !gitRevPattern.MatchString(req.Commit) {
+		return nil, fmt.Errorf("invalid commit: %s", req.Commit)
+	}
+
+	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
+
+	out, err := runGit(ctx, repoPath, "show", "--patch", "--format=%H%x00%an%x00%ae%x00%aI%x00%B%x00", req.Commit, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(out, "\x00", 6)
+	if len(parts) != 6 {
+		return nil, fmt.Errorf("unexpected git show output for %s", req.Commit)
+	}
+
 	return json.Marshal(map[string]string{
-		"status": "pulled",
+		"commit":       parts[0],
+		"author":       parts[1],
+		"author_email": parts[2],
+		"date":         parts[3],
+		"message":      strings.TrimSpace(parts[4]),
+		"diff":         strings.TrimLeft(parts[5], "\n"),
+	})
+}
+
+type RemoteInput struct {
+	Repo   string `json:"repo"`
+	Action string `json:"action"` // list (default), add, remove, or set-url
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+}
+
+// Remote is one remote from git remote -v
+type Remote struct {
+	Name     string `json:"name"`
+	FetchURL string `json:"fetch_url"`
+	PushURL  string `json:"push_url"`
+}
+
+var remoteNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func validRemoteName(name string) bool {
+	return remoteNamePattern.MatchString(name) && !strings.Contains(name, "..") && !strings.HasSuffix(name, ".lock")
+}
+
+func remoteOrOrigin(remote string) string {
+	if remote == "" {
+		return "origin"
+	}
+	return remote
+}
+
+func (w *GitWorker) remote(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req RemoteInput
+	json.Unmarshal(input, &req)
+
+	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
+
+	action := req.Action
+	if action == "" {
+		action = "list"
+	}
+	if action != "list" && !validRemoteName(req.Name) {
+		return nil, fmt.Errorf("invalid remote name: %q", req.Name)
+	}
+	if (action == "add" || action == "set-url") && (req.URL == "" || strings.HasPrefix(req.URL, "-")) {
+		return nil, fmt.Errorf("invalid remote url: %q", req.URL)
+	}
+
+	var args []string
+	switch action {
+	case "list":
+		out, err := runGit(ctx, repoPath, "remote", "-v")
+		if err != nil {
+			return nil, err
+		}
+		remotes := parseRemotes(out)
+		return json.Marshal(map[string]interface{}{
+			"repo":    req.Repo,
+			"remotes": remotes,
+			"count":   len(remotes),
+		})
+	case "add":
+		args = []string{"remote", "add", req.Name, req.URL}
+	case "remove":
+		args = []string{"remote", "remove", req.Name}
+	case "set-url":
+		args = []string{"remote", "set-url", req.Name, req.URL}
+	default:
+		return nil, fmt.Errorf("unknown action: %s", action)
+	}
+
+	if _, err := runGit(ctx, repoPath, args...); err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]string{
+		"status": action,
+		"remote": req.Name,
+	})
+}
+
+// parseRemotes reads "name<TAB>url (fetch|push)" lines, in first-seen order
+func parseRemotes(out string) []Remote {
+	remotes := []Remote{}
+	index := map[string]int{}
+	for _, line := range strings.Split(out, "\n") {
+		name, rest, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		url, kind := rest, ""
+		if i := strings.LastIndex(rest, " ("); i >= 0 {
+			url, kind = rest[:i], rest[i+1:]
+		}
+		i, seen := index[name]
+		if !seen {
+			i = len(remotes)
+			index[name] = i
+			remotes = append(remotes, Remote{Name: name})
+		}
+		switch kind {
+		case "(fetch)":
+			remotes[i].FetchURL = url
+		case "(push)":
+			remotes[i].PushURL = url
+		}
+	}
+	return remotes
+}
+
+type StashInput struct {
+	Repo             string `json:"repo"`
+	Message          string `json:"message"`
+	IncludeUntracked bool   `json:"include_untracked"`
+}
+
+func (w *GitWorker) stash(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req StashInput
+	json.Unmarshal(input, &req)
+
+	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
+	stashed, err := pushStash(ctx, repoPath, req.Message, req.IncludeUntracked)
+	if err != nil {
+		return nil, err
+	}
+
+	status := "stashed"
+	if !stashed {
+		status = "nothing to stash"
+	}
+	return json.Marshal(map[string]string{
+		"status": status,
 		"repo":   req.Repo,
-		"output": string(out),
 	})
 }
 
+// pushStash stashes local changes, reporting false when there were none to stash
+func pushStash(ctx context.Context, repoPath, message string, includeUntracked bool) (bool, error) {
+	before, err := runGit(ctx, repoPath, "rev-parse", "-q", "--verify", "refs/stash")
+	if err != nil {
+		before = ""
+	}
+
+	args := []string{"stash", "push"}
+	if includeUntracked {
+		args = append(args, "--include-untracked")
+	}
+	if message != "" {
+		args = append(args, "-m", message)
+	}
+	if _, err := runGit(ctx, repoPath, args...); err != nil {
+		return false, err
+	}
+
+	after, _ := runGit(ctx, repoPath, "rev-parse", "-q", "--verify", "refs/stash")
+	return after != "" && after != before, nil
+}
+
+type StashPopInput struct {
+	Repo string `json:"repo"`
+	Ref  string `json:"ref"` // e.g. stash@{1}; defaults to the latest
+}
+
+func (w *GitWorker) stashPop(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req StashPopInput
+	json.Unmarshal(input, &req)
+
+	args := []string{"stash", "pop"}
+	if req.Ref != "" {
+		if !stashRefPattern.MatchString(req.Ref) {
+			return nil, fmt.Errorf("invalid stash ref: %s", req.Ref)
+		}
+		args = append(args, req.Ref)
+	}
+	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, repoPath, args...); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]string{
+		"status": "restored",
+		"repo":   req.Repo,
+	})
+}
+
+var stashRefPattern = regexp.MustCompile(`^stash@\{\d+\}$`)
+
+type StashListInput struct {
+	Repo string `json:"repo"`
+}
+
+// StashEntry is one line of git stash list
+type StashEntry struct {
+	Ref     string `json:"ref"`
+	Message string `json:"message"`
+	Branch  string `json:"branch"`
+}
+
+func (w *GitWorker) stashList(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req StashListInput
+	json.Unmarshal(input, &req)
+
+	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
+	out, err := runGit(ctx, repoPath, "stash", "list", "--format=%gd%x00%gs")
+	if err != nil {
+		return nil, err
+	}
+
+	stashes := parseStashList(out)
+	return json.Marshal(map[string]interface{}{
+		"repo":    req.Repo,
+		"stashes": stashes,
+		"count":   len(stashes),
+	})
+}
+
+// parseStashList reads "ref NUL subject" lines, where the subject is "WIP on
+// <branch>: <commit>" for unnamed stashes or "On <branch>: <message>"
+func parseStashList(out string) []StashEntry {
+	stashes := []StashEntry{}
+	for _, line := range strings.Split(out, "\n") {
+		ref, subject, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		entry := StashEntry{Ref: ref, Message: subject}
+		if where, message, ok := strings.Cut(subject, ": "); ok {
+			where = strings.TrimPrefix(where, "WIP ")
+			if branch, ok := strings.CutPrefix(where, "On "); ok {
+				entry.Branch = branch
+			} else if branch, ok := strings.CutPrefix(where, "on "); ok {
+				entry.Branch = branch
+			}
+			entry.Message = message
+		}
+		stashes = append(stashes, entry)
+	}
+	return stashes
+}
+
 type BranchInput struct {
 	Repo   string `json:"repo"`
 	Name   string `json:"name"`
@@ -333,6 +858,9 @@ func (w *GitWorker) branch(ctx context.Context, input json.RawMessage) ([]byte,
 	json.Unmarshal(input, &req)
 
 	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
 
 	action := req.Action
 	if action == "" {
@@ -412,6 +940,9 @@ func (w *GitWorker) checkout(ctx context.Context, input json.RawMessage) ([]byte
 	}
 
 	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
 	args := []string{"checkout"}
 	if req.Create {
 		args = append(args, "-b")
@@ -431,6 +962,222 @@ func (w *GitWorker) checkout(ctx context.Context, input json.RawMessage) ([]byte
 	})
 }
 
+type WorktreeAddInput struct {
+	Repo   string `json:"repo"`
+	Path   string `json:"path"` // relative to the base path
+	Branch string `json:"branch"`
+	Create bool   `json:"create"` // create branch, from base or HEAD
+	Base   string `json:"base"`
+}
+
+func (w *GitWorker) worktreeAdd(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req WorktreeAddInput
+	json.Unmarshal(input, &req)
+
+	if req.Path == "" || req.Branch == "" {
+		return nil, fmt.Errorf("path and branch are required")
+	}
+
+	worktreePath, err := w.sandboxPath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"worktree", "add"}
+	if req.Create {
+		args = append(args, "-b", req.Branch, worktreePath)
+		if req.Base != "" {
+			args = append(args, req.Base)
+		}
+	} else {
+		args = append(args, worktreePath, req.Branch)
+	}
+
+	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, repoPath, args...); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]string{
+		"status": "created",
+		"path":   worktreePath,
+		"branch": req.Branch,
+	})
+}
+
+type WorktreeListInput struct {
+	Repo string `json:"repo"`
+}
+
+// Worktree is one entry of git worktree list --porcelain
+type Worktree struct {
+	Path     string `json:"path"`
+	Head     string `json:"head,omitempty"`
+	Branch   string `json:"branch,omitempty"`
+	Detached bool   `json:"detached,omitempty"`
+	Bare     bool   `json:"bare,omitempty"`
+	Locked   bool   `json:"locked,omitempty"`
+	Prunable bool   `json:"prunable,omitempty"`
+}
+
+func (w *GitWorker) worktreeList(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req WorktreeListInput
+	json.Unmarshal(input, &req)
+
+	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
+	out, err := runGit(ctx, repoPath, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+
+	worktrees := parseWorktrees(out)
+	return json.Marshal(map[string]interface{}{
+		"repo":      req.Repo,
+		"worktrees": worktrees,
+		"count":     len(worktrees),
+	})
+}
+
+// parseWorktrees reads porcelain output: blank-line separated blocks of
+// "attribute value" lines, each starting with the worktree path
+func parseWorktrees(out string) []Worktree {
+	worktrees := []Worktree{}
+	var current *Worktree
+	for _, line := range strings.Split(out, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, Worktree{Path: value})
+			current = &worktrees[len(worktrees)-1]
+		case "HEAD":
+			if current != nil {
+				current.Head = value
+			}
+		case "branch":
+			if current != nil {
+				current.Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		case "detached":
+			if current != nil {
+				current.Detached = true
+			}
+		case "bare":
+			if current != nil {
+				current.Bare = true
+			}
+		case "locked":
+			if current != nil {
+				current.Locked = true
+			}
+		case "prunable":
+			if current != nil {
+				current.Prunable = true
+			}
+		}
+	}
+	return worktrees
+}
+
+type WorktreeRemoveInput struct {
+	Repo  string `json:"repo"`
+	Path  string `json:"path"`
+	Force bool   `json:"force"`
+}
+
+func (w *GitWorker) worktreeRemove(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req WorktreeRemoveInput
+	json.Unmarshal(input, &req)
+
+	if req.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	worktreePath, err := w.sandboxPath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"worktree", "remove"}
+	if req.Force {
+		args = append(args, "--force")
+	}
+	args = append(args, worktreePath)
+
+	repoPath := w.resolveRepoPath(req.Repo)
+	if err := w.checkRepoAllowed(repoPath); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, repoPath, args...); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]string{
+		"status": "removed",
+		"path":   worktreePath,
+	})
+}
+
+// runGit runs git in dir and returns its combined output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", err, string(out))
+	}
+	return string(out), nil
+}
+
+// sandboxPath resolves p against basePath, rejecting paths that escape it
+func (w *GitWorker) sandboxPath(p string) (string, error) {
+	if w.basePath == "" {
+		return "", fmt.Errorf("git base path not configured")
+	}
+	resolved, err := pathWithin(w.basePath, p)
+	if errors.Is(err, errPathOutsideBase) {
+		return "", fmt.Errorf("path outside git base path: %s", p)
+	}
+	return resolved, err
+}
+
+// checkRepoAllowed returns an error unless the repository at path, or one containing
+// it, is in the allowlist. Symlinks are resolved on both sides, so a link can't
+// smuggle a disallowed repository in under an allowed name.
+func (w *GitWorker) checkRepoAllowed(path string) error {
+	if len(w.allowedRepos) == 0 {
+		return nil
+	}
+
+	resolved, err := resolveExisting(path)
+	if err != nil {
+		return fmt.Errorf("repo not allowed: %s: %w", path, err)
+	}
+
+	for _, allowed := range w.allowedRepos {
+		if allowed == "*" {
+			return nil
+		}
+		// Relative entries name repositories under the base path
+		if !filepath.IsAbs(allowed) {
+			allowed = filepath.Join(w.basePath, allowed)
+		}
+		allowed, err := resolveExisting(allowed)
+		if err != nil {
+			continue
+		}
+		if resolved == allowed || strings.HasPrefix(resolved, allowed+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("repo not allowed: %s", path)
+}
+
 func (w *GitWorker) resolveRepoPath(repo string) string {
 	if repo == "" {
 		return w.basePath
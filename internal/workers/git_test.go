@@ -0,0 +1,318 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitCmd runs git in dir, failing the test on error
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return string(out)
+}
+
+// newTestGitRepo creates a repository named name under base with one commit on main
+func newTestGitRepo(t *testing.T, base, name string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := filepath.Join(base, name)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+	gitCmd(t, dir, "config", "user.name", "Test User")
+	gitCmd(t, dir, "config", "user.email", "test@example.com")
+	gitCmd(t, dir, "config", "commit.gpgsign", "false")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\n"), 0644))
+	gitCmd(t, dir, "add", "README.md")
+	gitCmd(t, dir, "commit", "-q", "-m", "Initial commit")
+	return dir
+}
+
+func TestGitWorktrees(t *testing.T) {
+	base := t.TempDir()
+	newTestGitRepo(t, base, "app")
+	w := NewGitWorker(base, nil)
+	ctx := context.Background()
+
+	out, err := w.Execute(ctx, "git_worktree_add", json.RawMessage(`{"repo":"app","path":"app-feature","branch":"feature","create":true}`))
+	require.NoError(t, err)
+	var added map[string]string
+	require.NoError(t, json.Unmarshal(out, &added))
+	assert.Equal(t, "feature", added["branch"])
+	assert.FileExists(t, filepath.Join(added["path"], "README.md"))
+
+	out, err = w.Execute(ctx, "git_worktree_list", json.RawMessage(`{"repo":"app"}`))
+	require.NoError(t, err)
+	var listed struct {
+		Worktrees []Worktree `json:"worktrees"`
+	}
+	require.NoError(t, json.Unmarshal(out, &listed))
+	require.Len(t, listed.Worktrees, 2)
+	assert.Equal(t, "main", listed.Worktrees[0].Branch)
+	assert.Equal(t, added["path"], listed.Worktrees[1].Path)
+	assert.Equal(t, "feature", listed.Worktrees[1].Branch)
+	assert.Len(t, listed.Worktrees[1].Head, 40)
+
+	_, err = w.Execute(ctx, "git_worktree_remove", json.RawMessage(`{"repo":"app","path":"app-feature"}`))
+	require.NoError(t, err)
+	assert.NoDirExists(t, added["path"])
+
+	_, err = w.Execute(ctx, "git_worktree_add", json.RawMessage(`{"repo":"app","path":"../outside","branch":"main"}`))
+	assert.ErrorContains(t, err, "path outside git base path")
+}
+
+func TestParseWorktrees(t *testing.T) {
+	out := "worktree /src/app\nHEAD 1111111111111111111111111111111111111111\nbranch refs/heads/main\n\n" +
+		"worktree /src/app-fix\nHEAD 2222222222222222222222222222222222222222\ndetached\nlocked on usb drive\n\n"
+	assert.Equal(t, []Worktree{
+		{Path: "/src/app", Head: "1111111111111111111111111111111111111111", Branch: "main"},
+		{Path: "/src/app-fix", Head: "2222222222222222222222222222222222222222", Detached: true, Locked: true},
+	}, parseWorktrees(out))
+}
+
+func TestGitCommit_ExplicitAuthor(t *testing.T) {
+	base := t.TempDir()
+	repo := newTestGitRepo(t, base, "app")
+	w := NewGitWorker(base, nil)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("draft\n"), 0644))
+	out, err := w.Execute(context.Background(), "git_commit", json.RawMessage(
+		`{"repo":"app","message":"Add notes","author_name":"Release Bot","author_email":"bot@example.org"}`))
+	require.NoError(t, err)
+	var resp map[string]string
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "Release Bot <bot@example.org>", resp["author"])
+
+	log := gitCmd(t, repo, "log", "-1", "--format=%an|%ae|%cn|%s")
+	assert.Equal(t, "Release Bot|bot@example.org|Release Bot|Add notes\n", log)
+
+	// The repository's own identity is untouched
+	assert.Equal(t, "Test User\n", gitCmd(t, repo, "config", "user.name"))
+
+	for _, input := range []string{
+		`{"repo":"app","message":"x","author_email":"not-an-email"}`,
+		`{"repo":"app","message":"x","author_email":"Bot <bot@example.org>"}`,
+		`{"repo":"app","message":"x","author_name":"Evil\nName"}`,
+	} {
+		_, err := w.Execute(context.Background(), "git_commit", json.RawMessage(input))
+		assert.ErrorContains(t, err, "invalid author", input)
+	}
+}
+
+func TestGitBlameAndShow(t *testing.T) {
+	base := t.TempDir()
+	repo := newTestGitRepo(t, base, "app")
+	w := NewGitWorker(base, nil)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	gitCmd(t, repo, "add", "main.go")
+	gitCmd(t, repo, "-c", "user.name=Jane Doe", "-c", "user.email=jane@example.com", "commit", "-q", "-m", "Add main")
+	head := strings.TrimSpace(gitCmd(t, repo, "rev-parse", "HEAD"))
+
+	out, err := w.Execute(ctx, "git_blame", json.RawMessage(`{"repo":"app","file":"main.go","start_line":3,"end_line":3}`))
+	require.NoError(t, err)
+	var blamed struct {
+		Lines []BlameLine `json:"lines"`
+	}
+	require.NoError(t, json.Unmarshal(out, &blamed))
+	require.Len(t, blamed.Lines, 1)
+	assert.Equal(t, "Jane Doe", blamed.Lines[0].Author)
+	assert.Equal(t, "jane@example.com", blamed.Lines[0].AuthorEmail)
+	assert.Equal(t, head, blamed.Lines[0].Commit)
+	assert.Equal(t, 3, blamed.Lines[0].LineNumber)
+	assert.Equal(t, "func main() {}", blamed.Lines[0].Line)
+	assert.NotEmpty(t, blamed.Lines[0].Date)
+
+	_, err = w.Execute(ctx, "git_blame", json.RawMessage(`{"repo":"app","file":"missing.go"}`))
+	assert.ErrorContains(t, err, "file not found: missing.go")
+	_, err = w.Execute(ctx, "git_blame", json.RawMessage(`{"repo":"app","file":"../../etc/passwd"}`))
+	assert.ErrorContains(t, err, "file not found")
+
+	out, err = w.Execute(ctx, "git_show", json.RawMessage(`{"repo":"app","commit":"HEAD"}`))
+	require.NoError(t, err)
+	var shown map[string]string
+	require.NoError(t, json.Unmarshal(out, &shown))
+	assert.Equal(t, head, shown["commit"])
+	assert.Equal(t, "Jane Doe", shown["author"])
+	assert.Equal(t, "Add main", shown["message"])
+	assert.Contains(t, shown["diff"], "+func main() {}")
+
+	_, err = w.Execute(ctx, "git_show", json.RawMessage(`{"repo":"app","commit":"--output=/tmp/x"}`))
+	assert.ErrorContains(t, err, "invalid commit")
+}
+
+// newTestGitClone creates a bare origin from a fresh repository and clones it to
+// name under base
+func newTestGitClone(t *testing.T, base, name string) (clone, origin string) {
+	t.Helper()
+	upstream := newTestGitRepo(t, t.TempDir(), "upstream")
+	origin = filepath.Join(t.TempDir(), "origin.git")
+	gitCmd(t, base, "clone", "-q", "--bare", upstream, origin)
+	gitCmd(t, base, "clone", "-q", origin, name)
+	clone = filepath.Join(base, name)
+	gitCmd(t, clone, "config", "user.name", "Test User")
+	gitCmd(t, clone, "config", "user.email", "test@example.com")
+	return clone, origin
+}
+
+func TestGitStash_ListAndPop(t *testing.T) {
+	base := t.TempDir()
+	repo := newTestGitRepo(t, base, "app")
+	w := NewGitWorker(base, nil)
+	ctx := context.Background()
+
+	out, err := w.Execute(ctx, "git_stash", json.RawMessage(`{"repo":"app"}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "nothing to stash")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte("edited\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "new.txt"), []byte("untracked\n"), 0644))
+	out, err = w.Execute(ctx, "git_stash", json.RawMessage(`{"repo":"app","message":"half done","include_untracked":true}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"status":"stashed"`)
+	assert.NoFileExists(t, filepath.Join(repo, "new.txt"))
+
+	out, err = w.Execute(ctx, "git_stash_list", json.RawMessage(`{"repo":"app"}`))
+	require.NoError(t, err)
+	var listed struct {
+		Stashes []StashEntry `json:"stashes"`
+	}
+	require.NoError(t, json.Unmarshal(out, &listed))
+	assert.Equal(t, []StashEntry{{Ref: "stash@{0}", Message: "half done", Branch: "main"}}, listed.Stashes)
+
+	_, err = w.Execute(ctx, "git_stash_pop", json.RawMessage(`{"repo":"app","ref":"stash@{0}; rm -rf"}`))
+	assert.ErrorContains(t, err, "invalid stash ref")
+
+	_, err = w.Execute(ctx, "git_stash_pop", json.RawMessage(`{"repo":"app"}`))
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(repo, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "edited\n", string(data))
+	assert.FileExists(t, filepath.Join(repo, "new.txt"))
+}
+
+func TestGitPull_Autostash(t *testing.T) {
+	base := t.TempDir()
+	repo, _ := newTestGitClone(t, base, "app")
+	w := NewGitWorker(base, nil)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte("local edit\n"), 0644))
+	out, err := w.Execute(context.Background(), "git_pull", json.RawMessage(`{"repo":"app","autostash":true}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"stashed":true`)
+
+	data, err := os.ReadFile(filepath.Join(repo, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "local edit\n", string(data))
+	assert.Empty(t, gitCmd(t, repo, "stash", "list"))
+}
+
+func TestParseStashList(t *testing.T) {
+	out := "stash@{0}\x00WIP on main: 1a2b3c4 Initial commit\nstash@{1}\x00On feature/x: before rebase\n"
+	assert.Equal(t, []StashEntry{
+		{Ref: "stash@{0}", Message: "1a2b3c4 Initial commit", Branch: "main"},
+		{Ref: "stash@{1}", Message: "before rebase", Branch: "feature/x"},
+	}, parseStashList(out))
+}
+
+func TestGitRemote_AddListAndPullFrom(t *testing.T) {
+	base := t.TempDir()
+	repo, origin := newTestGitClone(t, base, "app")
+	w := NewGitWorker(base, nil)
+	ctx := context.Background()
+
+	// A fork with one extra commit
+	fork := filepath.Join(t.TempDir(), "fork")
+	gitCmd(t, base, "clone", "-q", origin, fork)
+	gitCmd(t, fork, "-c", "user.name=Fork", "-c", "user.email=fork@example.com", "commit", "-q", "--allow-empty", "-m", "Fork change")
+
+	input, _ := json.Marshal(map[string]string{"repo": "app", "action": "add", "name": "fork", "url": fork})
+	_, err := w.Execute(ctx, "git_remote", input)
+	require.NoError(t, err)
+
+	out, err := w.Execute(ctx, "git_remote", json.RawMessage(`{"repo":"app"}`))
+	require.NoError(t, err)
+	var listed struct {
+		Remotes []Remote `json:"remotes"`
+	}
+	require.NoError(t, json.Unmarshal(out, &listed))
+	assert.ElementsMatch(t, []Remote{
+		{Name: "origin", FetchURL: origin, PushURL: origin},
+		{Name: "fork", FetchURL: fork, PushURL: fork},
+	}, listed.Remotes)
+
+	_, err = w.Execute(ctx, "git_pull", json.RawMessage(`{"repo":"app","remote":"fork","branch":"main"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Fork change\n", gitCmd(t, repo, "log", "-1", "--format=%s"))
+
+	_, err = w.Execute(ctx, "git_remote", json.RawMessage(`{"repo":"app","action":"remove","name":"fork"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "origin\n", gitCmd(t, repo, "remote"))
+
+	for _, input := range []string{
+		`{"repo":"app","action":"add","name":"--upload-pack=x","url":"u"}`,
+		`{"repo":"app","action":"add","name":"a/../b","url":"u"}`,
+		`{"repo":"app","action":"add","name":"ok","url":"--upload-pack=touch /tmp/x"}`,
+	} {
+		_, err := w.Execute(ctx, "git_remote", json.RawMessage(input))
+		assert.ErrorContains(t, err, "invalid remote", input)
+	}
+	_, err = w.Execute(ctx, "git_push", json.RawMessage(`{"repo":"app","remote":"-f"}`))
+	assert.ErrorContains(t, err, "invalid remote name")
+}
+
+func TestGitWorker_AllowedRepos(t *testing.T) {
+	base := t.TempDir()
+	newTestGitRepo(t, base, "app")
+	newTestGitRepo(t, base, "secrets")
+	ctx := context.Background()
+
+	w := NewGitWorker(base, []string{"app"})
+	_, err := w.Execute(ctx, "git_status", json.RawMessage(`{"repo":"app"}`))
+	assert.NoError(t, err)
+	_, err = w.Execute(ctx, "git_log", json.RawMessage(`{"repo":"app/docs/../"}`))
+	assert.NoError(t, err)
+
+	for _, tc := range []struct{ tool, input string }{
+		{"git_status", `{"repo":"secrets"}`},
+		{"git_log", `{"repo":"app/../secrets"}`},
+		{"git_status", `{"repo":"` + filepath.Join(base, "secrets") + `"}`},
+		{"git_status", `{}`},
+		{"git_stash_list", `{"repo":"secrets"}`},
+		{"git_clone", `{"url":"https://example.com/secrets.git"}`},
+		{"git_clone", `{"url":"https://example.com/app.git","path":"/tmp/app"}`},
+	} {
+		_, err := w.Execute(ctx, tc.tool, json.RawMessage(tc.input))
+		assert.ErrorContains(t, err, "repo not allowed", tc.input)
+	}
+
+	// A symlink under an allowed name doesn't reach a disallowed repository
+	require.NoError(t, os.Symlink(filepath.Join(base, "secrets"), filepath.Join(base, "app", "link")))
+	_, err = w.Execute(ctx, "git_status", json.RawMessage(`{"repo":"app/link"}`))
+	assert.ErrorContains(t, err, "repo not allowed")
+
+	// Absolute paths in the allowlist work too
+	w = NewGitWorker(base, []string{filepath.Join(base, "secrets")})
+	_, err = w.Execute(ctx, "git_status", json.RawMessage(`{"repo":"secrets"}`))
+	assert.NoError(t, err)
+
+	w = NewGitWorker(base, []string{"*"})
+	for _, repo := range []string{"app", "secrets"} {
+		_, err := w.Execute(ctx, "git_status", json.RawMessage(`{"repo":"`+repo+`"}`))
+		assert.NoError(t, err, repo)
+	}
+}
@@ -1,12 +1,14 @@
 package workers
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -27,6 +29,7 @@ func NewLMStudioWorker(baseURL string) *LMStudioWorker {
 func (w *LMStudioWorker) GetTools() []ToolDef {
 	return []ToolDef{
 		{Name: "chat", Description: "Chat completion using LM Studio"},
+		{Name: "chat_stream", Description: "Streamed chat completion, returned as the assembled text with timing stats"},
 		{Name: "generate", Description: "Text generation using LM Studio"},
 		{Name: "embed", Description: "Generate embeddings using LM Studio"},
 		{Name: "models", Description: "List available models"},
@@ -42,6 +45,8 @@ func (w *LMStudioWorker) Execute(ctx context.Context, name string, input json.Ra
 	switch name {
 	case "chat", "lmstudio_chat":
 		return w.chat(ctx, input)
+	case "chat_stream", "lmstudio_chat_stream":
+		return w.chatStream(ctx, input)
 	case "generate", "lmstudio_generate":
 		return w.generate(ctx, input)
 	case "embed", "lmstudio_embed":
@@ -64,8 +69,21 @@ func (w *LMStudioWorker) Execute(ctx context.Context, name string, input json.Ra
 }
 
 type LMStudioChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string             `json:"role"`
+	Content    string             `json:"content"`
+	ToolCalls  []LMStudioToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string             `json:"tool_call_id,omitempty"` // set on role "tool" replies
+}
+
+// LMStudioToolCall is a function call requested by the model. Arguments is the
+// JSON-encoded argument object as the model produced it.
+type LMStudioToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 type LMStudioChatRequest struct {
@@ -74,6 +92,10 @@ type LMStudioChatRequest struct {
 	MaxTokens   int                   `json:"max_tokens,omitempty"`
 	Temperature float64               `json:"temperature,omitempty"`
 	TopP        float64               `json:"top_p,omitempty"`
+	// Tools and ToolChoice are passed through unchanged in the OpenAI format
+	Tools      json.RawMessage `json:"tools,omitempty"`
+	ToolChoice json.RawMessage `json:"tool_choice,omitempty"`
+	Stream     bool            `json:"stream,omitempty"`
 }
 
 type LMStudioChatResponse struct {
@@ -99,12 +121,101 @@ func (w *LMStudioWorker) chat(ctx context.Context, input json.RawMessage) ([]byt
 		return nil, err
 	}
 
+	result, err := w.chatCompletion(ctx, req, autoLoadRequested(input))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// applyChatDefaults fills in the model and token limit a chat request leaves unset
+func applyChatDefaults(req *LMStudioChatRequest) {
 	if req.Model == "" {
 		req.Model = "local-model"
 	}
 	if req.MaxTokens == 0 {
 		req.MaxTokens = 512
 	}
+}
+
+// chatCompletion sends req to /v1/chat/completions, filling in the default model and
+// token limit. The reply is decoded as a single body, so streaming is always off here.
+func (w *LMStudioWorker) chatCompletion(ctx context.Context, req LMStudioChatRequest, autoLoad bool) (*LMStudioChatResponse, error) {
+	requested := req.Model
+	applyChatDefaults(&req)
+	req.Stream = false
+
+	var result LMStudioChatResponse
+	err := w.withAutoLoad(ctx, requested, autoLoad, func() error {
+		return postJSON(ctx, w.httpClient, w.baseURL+"/v1/chat/completions", req, &result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// autoLoadRequested reports whether a request wants models loaded on demand, which
+// is the default unless it sets auto_load to false
+func autoLoadRequested(input json.RawMessage) bool {
+	var opts struct {
+		AutoLoad *bool `json:"auto_load"`
+	}
+	json.Unmarshal(input, &opts)
+	return opts.AutoLoad == nil || *opts.AutoLoad
+}
+
+// isModelNotLoaded reports whether LM Studio rejected a request because the model
+// isn't in memory
+func isModelNotLoaded(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "not loaded") || strings.Contains(msg, "no models loaded")
+}
+
+// withAutoLoad runs call and, if it fails because model isn't loaded, loads the model
+// and tries once more. Requests that don't name a model are left alone.
+func (w *LMStudioWorker) withAutoLoad(ctx context.Context, model string, autoLoad bool, call func() error) error {
+	err := call()
+	if err == nil || !autoLoad || model == "" || !isModelNotLoaded(err) {
+		return err
+	}
+
+	loadInput, _ := json.Marshal(LoadModelRequest{Model: model})
+	if _, loadErr := w.loadModel(ctx, loadInput); loadErr != nil {
+		return fmt.Errorf("%w (auto-load failed: %v)", err, loadErr)
+	}
+	return call()
+}
+
+// LMStudioChatChunk is one server-sent event of a streamed chat completion
+type LMStudioChatChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// chatStream requests a streamed completion and assembles the deltas, since tool
+// results are returned whole. Timing stats show how the stream arrived.
+func (w *LMStudioWorker) chatStream(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req LMStudioChatRequest
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+
+	applyChatDefaults(&req)
+	req.Stream = true
 
 	body, _ := json.Marshal(req)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", w.baseURL+"/v1/chat/completions", bytes.NewReader(body))
@@ -112,7 +223,9 @@ func (w *LMStudioWorker) chat(ctx context.Context, input json.RawMessage) ([]byt
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
 
+	start := time.Now()
 	resp, err := w.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, err
@@ -124,12 +237,130 @@ func (w *LMStudioWorker) chat(ctx context.Context, input json.RawMessage) ([]byt
 		return nil, fmt.Errorf("LM Studio error: %s", string(b))
 	}
 
-	var result LMStudioChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+	var (
+		content          strings.Builder
+		model            = req.Model
+		finishReason     string
+		chunks           int
+		contentChunks    int
+		malformed        int
+		completionTokens int
+		firstToken       time.Duration
+		done             bool
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// Events are "data: <json>" lines; blank lines and ":" comments carry nothing
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+
+		var chunk LMStudioChatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			malformed++
+			continue
+		}
+		chunks++
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			completionTokens = chunk.Usage.CompletionTokens
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Index != 0 {
+				continue
+			}
+			if choice.Delta.Content != "" {
+				if content.Len() == 0 {
+					firstToken = time.Since(start)
+				}
+				contentChunks++
+				content.WriteString(choice.Delta.Content)
+			}
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	duration := time.Since(start)
+
+	// Without usage in the stream, each content chunk is roughly one token
+	if completionTokens == 0 {
+		completionTokens = contentChunks
+	}
+	var tokensPerSecond float64
+	if generating := duration - firstToken; generating > 0 && completionTokens > 0 {
+		tokensPerSecond = float64(completionTokens) / generating.Seconds()
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"model":                  model,
+		"content":                content.String(),
+		"finish_reason":          finishReason,
+		"completed":              done || finishReason != "",
+		"chunks":                 chunks,
+		"malformed_chunks":       malformed,
+		"completion_tokens":      completionTokens,
+		"time_to_first_token_ms": firstToken.Milliseconds(),
+		"duration_ms":            duration.Milliseconds(),
+		"tokens_per_second":      tokensPerSecond,
+	})
+}
+
+// LMStudioProvider adapts an LMStudioWorker to the orchestrator's LLMProvider
+type LMStudioProvider struct {
+	worker *LMStudioWorker
+}
+
+func NewLMStudioProvider(worker *LMStudioWorker) *LMStudioProvider {
+	return &LMStudioProvider{worker: worker}
+}
+
+func (p *LMStudioProvider) Call(ctx context.Context, model, systemPrompt, userPrompt string, temperature float64, maxTokens int) (string, error) {
+	var messages []LMStudioChatMessage
+	if systemPrompt != "" {
+		messages = append(messages, LMStudioChatMessage{Role: "system", Content: systemPrompt})
 	}
+	messages = append(messages, LMStudioChatMessage{Role: "user", Content: userPrompt})
 
-	return json.Marshal(result)
+	result, err := p.worker.chatCompletion(ctx, LMStudioChatRequest{
+		Model:       model,
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+	}, true)
+	if err != nil {
+		return "", err
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("LM Studio returned no choices")
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+// LMStudioCaller adapts an LMStudioWorker to the LLMCaller used by the RAG worker
+type LMStudioCaller struct {
+	provider *LMStudioProvider
+	model    string
+}
+
+func NewLMStudioCaller(worker *LMStudioWorker, model string) *LMStudioCaller {
+	return &LMStudioCaller{provider: NewLMStudioProvider(worker), model: model}
+}
+
+func (c *LMStudioCaller) Call(ctx context.Context, prompt string, systemPrompt string) (string, error) {
+	return c.provider.Call(ctx, c.model, systemPrompt, prompt, 0, 0)
 }
 
 type LMStudioGenerateRequest struct {
@@ -163,6 +394,7 @@ func (w *LMStudioWorker) generate(ctx context.Context, input json.RawMessage) ([
 		return nil, err
 	}
 
+	requested := req.Model
 	if req.Model == "" {
 		req.Model = "local-model"
 	}
@@ -170,26 +402,11 @@ func (w *LMStudioWorker) generate(ctx context.Context, input json.RawMessage) ([
 		req.MaxTokens = 512
 	}
 
-	body, _ := json.Marshal(req)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", w.baseURL+"/v1/completions", bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := w.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("LM Studio error: %s", string(b))
-	}
-
 	var result LMStudioGenerateResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	err := w.withAutoLoad(ctx, requested, autoLoadRequested(input), func() error {
+		return postJSON(ctx, w.httpClient, w.baseURL+"/v1/completions", req, &result)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -221,34 +438,42 @@ func (w *LMStudioWorker) embed(ctx context.Context, input json.RawMessage) ([]by
 		return nil, err
 	}
 
+	requested := req.Model
 	if req.Model == "" {
 		req.Model = "local-embedding"
 	}
 
-	body, _ := json.Marshal(req)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", w.baseURL+"/v1/embeddings", bytes.NewReader(body))
+	var result LMStudioEmbedResponse
+	err := w.withAutoLoad(ctx, requested, autoLoadRequested(input), func() error {
+		return postJSON(ctx, w.httpClient, w.baseURL+"/v1/embeddings", req, &result)
+	})
 	if err != nil {
 		return nil, err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := w.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return json.Marshal(result)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("LM Studio error: %s", string(b))
-	}
+// LMStudioEmbedder is an HTTPEmbedder against an LMStudioWorker's server that loads
+// the model on demand, as the worker's own tools do
+type LMStudioEmbedder struct {
+	*HTTPEmbedder
+	worker *LMStudioWorker
+	model  string // as requested; HTTPEmbedder substitutes a default for ""
+}
 
-	var result LMStudioEmbedResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
-	}
+func NewLMStudioEmbedder(worker *LMStudioWorker, model string) *LMStudioEmbedder {
+	return &LMStudioEmbedder{HTTPEmbedder: NewHTTPEmbedder(worker.baseURL, model), worker: worker, model: model}
+}
 
-	return json.Marshal(result)
+func (e *LMStudioEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	err := e.worker.withAutoLoad(ctx, e.model, true, func() error {
+		var err error
+		vectors, err = e.HTTPEmbedder.Embed(ctx, texts)
+		return err
+	})
+	return vectors, err
 }
 
 func (w *LMStudioWorker) listModels(ctx context.Context) ([]byte, error) {
@@ -359,6 +584,9 @@ func (w *LMStudioWorker) loadModel(ctx context.Context, input json.RawMessage) (
 	defer resp.Body.Close()
 
 	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("LM Studio error: %s", string(b))
+	}
 	return b, nil
 }
 
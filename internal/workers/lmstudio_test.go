@@ -0,0 +1,250 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLMStudioChat_ToolCalls(t *testing.T) {
+	var received map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"model": "qwen2.5-7b-instruct",
+			"choices": [{
+				"index": 0,
+				"message": {
+					"role": "assistant",
+					"content": null,
+					"tool_calls": [{
+						"id": "call_1",
+						"type": "function",
+						"function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}
+					}]
+				},
+				"finish_reason": "tool_calls"
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	w := NewLMStudioWorker(server.URL)
+	out, err := w.Execute(context.Background(), "lmstudio_chat", json.RawMessage(`{
+		"model": "qwen2.5-7b-instruct",
+		"messages": [{"role": "user", "content": "Weather in Paris?"}],
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}],
+		"tool_choice": "auto"
+	}`))
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `[{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}]`, string(received["tools"]))
+	assert.JSONEq(t, `"auto"`, string(received["tool_choice"]))
+
+	var resp LMStudioChatResponse
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.Len(t, resp.Choices, 1)
+	assert.Equal(t, "tool_calls", resp.Choices[0].FinishReason)
+	calls := resp.Choices[0].Message.ToolCalls
+	require.Len(t, calls, 1)
+	assert.Equal(t, "call_1", calls[0].ID)
+	assert.Equal(t, "get_weather", calls[0].Function.Name)
+	assert.JSONEq(t, `{"city":"Paris"}`, calls[0].Function.Arguments)
+}
+
+func TestLMStudioChat_OmitsToolsWhenUnset(t *testing.T) {
+	var received map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "hi"}}]}`))
+	}))
+	defer server.Close()
+
+	w := NewLMStudioWorker(server.URL)
+	_, err := w.Execute(context.Background(), "chat", json.RawMessage(`{"messages": [{"role": "user", "content": "hello"}]}`))
+	require.NoError(t, err)
+	assert.NotContains(t, received, "tools")
+	assert.NotContains(t, received, "tool_choice")
+}
+
+func TestLMStudioChat_IgnoresStreamFlag(t *testing.T) {
+	var received map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "hi"}}]}`))
+	}))
+	defer server.Close()
+
+	w := NewLMStudioWorker(server.URL)
+	_, err := w.Execute(context.Background(), "chat", json.RawMessage(`{"messages": [{"role": "user", "content": "hello"}], "stream": true}`))
+	require.NoError(t, err)
+	assert.NotContains(t, received, "stream")
+}
+
+func TestLMStudioProvider_Call(t *testing.T) {
+	var received LMStudioChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte(`{"choices": [{"index": 0, "message": {"role": "assistant", "content": "Paris"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	var provider LLMProvider = NewLMStudioProvider(NewLMStudioWorker(server.URL))
+	out, err := provider.Call(context.Background(), "qwen2.5-7b-instruct", "Answer in one word.", "Capital of France?", 0.2, 64)
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", out)
+
+	assert.Equal(t, "qwen2.5-7b-instruct", received.Model)
+	assert.Equal(t, []LMStudioChatMessage{
+		{Role: "system", Content: "Answer in one word."},
+		{Role: "user", Content: "Capital of France?"},
+	}, received.Messages)
+	assert.Equal(t, 0.2, received.Temperature)
+	assert.Equal(t, 64, received.MaxTokens)
+}
+
+func TestLMStudioChatStream_AssemblesDeltas(t *testing.T) {
+	var received LMStudioChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(": keep-alive\n\n" +
+			`data: {"model":"qwen","choices":[{"index":0,"delta":{"role":"assistant"}}]}` + "\n\n" +
+			`data: {"model":"qwen","choices":[{"index":0,"delta":{"content":"Hello"}}]}` + "\n\n" +
+			"data: {not json\n\n" +
+			`data: {"model":"qwen","choices":[{"index":0,"delta":{"content":", world"},"finish_reason":"stop"}]}` + "\n\n" +
+			"data: [DONE]\n\n" +
+			`data: {"model":"qwen","choices":[{"index":0,"delta":{"content":" ignored"}}]}` + "\n\n"))
+	}))
+	defer server.Close()
+
+	w := NewLMStudioWorker(server.URL)
+	out, err := w.Execute(context.Background(), "lmstudio_chat_stream", json.RawMessage(`{"messages": [{"role": "user", "content": "hello"}]}`))
+	require.NoError(t, err)
+	assert.True(t, received.Stream)
+
+	var resp struct {
+		Model            string `json:"model"`
+		Content          string `json:"content"`
+		FinishReason     string `json:"finish_reason"`
+		Completed        bool   `json:"completed"`
+		Chunks           int    `json:"chunks"`
+		MalformedChunks  int    `json:"malformed_chunks"`
+		CompletionTokens int    `json:"completion_tokens"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "qwen", resp.Model)
+	assert.Equal(t, "Hello, world", resp.Content)
+	assert.Equal(t, "stop", resp.FinishReason)
+	assert.True(t, resp.Completed)
+	assert.Equal(t, 3, resp.Chunks)
+	assert.Equal(t, 1, resp.MalformedChunks)
+	// The role-only chunk carries no content, so it isn't counted as a token
+	assert.Equal(t, 2, resp.CompletionTokens)
+}
+
+func TestLMStudioChat_AutoLoadsModel(t *testing.T) {
+	loaded := false
+	var loadedModel string
+	chats := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/load":
+			var req map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			loadedModel = req["model_name"]
+			loaded = true
+			w.Write([]byte(`{"status":"loaded"}`))
+		case "/v1/chat/completions":
+			chats++
+			if !loaded {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"Model qwen2.5-7b-instruct is not loaded"}`))
+				return
+			}
+			w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ready"}}]}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	w := NewLMStudioWorker(server.URL)
+	out, err := w.Execute(context.Background(), "chat", json.RawMessage(`{"model": "qwen2.5-7b-instruct", "messages": [{"role": "user", "content": "hi"}]}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"content":"ready"`)
+	assert.Equal(t, "qwen2.5-7b-instruct", loadedModel)
+	assert.Equal(t, 2, chats)
+}
+
+func TestLMStudioChat_AutoLoadDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/load" {
+			t.Error("model should not be loaded when auto_load is false")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"Model qwen2.5-7b-instruct is not loaded"}`))
+	}))
+	defer server.Close()
+
+	w := NewLMStudioWorker(server.URL)
+	_, err := w.Execute(context.Background(), "chat", json.RawMessage(`{"model": "qwen2.5-7b-instruct", "auto_load": false, "messages": [{"role": "user", "content": "hi"}]}`))
+	assert.ErrorContains(t, err, "not loaded")
+}
+
+func TestLMStudioEmbedder_OrdersByIndex(t *testing.T) {
+	var received LMStudioEmbedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte(`{"object": "list", "data": [
+			{"object": "embedding", "index": 1, "embedding": [0.25, -1.5]},
+			{"object": "embedding", "index": 0, "embedding": [0.5, 2]}
+		]}`))
+	}))
+	defer server.Close()
+
+	var embedder Embedder = NewLMStudioEmbedder(NewLMStudioWorker(server.URL), "nomic-embed-text")
+	vectors, err := embedder.Embed(context.Background(), []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, "nomic-embed-text", received.Model)
+	assert.Equal(t, []string{"first", "second"}, received.Input)
+	assert.Equal(t, [][]float32{{0.5, 2}, {0.25, -1.5}}, vectors)
+
+	_, err = embedder.Embed(context.Background(), []string{"only one"})
+	assert.ErrorContains(t, err, "2 vectors for 1 inputs")
+}
+
+func TestLMStudioEmbedder_AutoLoadsModel(t *testing.T) {
+	loaded := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/load":
+			loaded = true
+			w.Write([]byte(`{"status":"loaded"}`))
+		case "/v1/embeddings":
+			if !loaded {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"Model nomic-embed-text is not loaded"}`))
+				return
+			}
+			w.Write([]byte(`{"data": [{"index": 0, "embedding": [1, 0]}]}`))
+		}
+	}))
+	defer server.Close()
+
+	vectors, err := NewLMStudioEmbedder(NewLMStudioWorker(server.URL), "nomic-embed-text").Embed(context.Background(), []string{"text"})
+	require.NoError(t, err)
+	assert.True(t, loaded)
+	assert.Equal(t, [][]float32{{1, 0}}, vectors)
+}
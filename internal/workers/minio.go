@@ -1,7 +1,11 @@
 package workers
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,50 +18,108 @@ import (
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/tags"
 )
 
 type MinIOWorker struct {
-	client *minio.Client
+	client MinIOClient
 	bucket string
+
+	// allowedBuckets limits which buckets requests may touch; "*" or an empty list allows all
+	allowedBuckets []string
+}
+
+// MinIOClient is the subset of *minio.Client the worker uses, so tests can substitute a fake
+type MinIOClient interface {
+	PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, opts minio.PutObjectOptions) (minio.UploadInfo, error)
+	GetObject(ctx context.Context, bucketName, objectName string, opts minio.GetObjectOptions) (*minio.Object, error)
+	FGetObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.GetObjectOptions) error
+	ListObjects(ctx context.Context, bucketName string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo
+	RemoveObject(ctx context.Context, bucketName, objectName string, opts minio.RemoveObjectOptions) error
+	RemoveObjects(ctx context.Context, bucketName string, objectsCh <-chan minio.ObjectInfo, opts minio.RemoveObjectsOptions) <-chan minio.RemoveObjectError
+	PresignedGetObject(ctx context.Context, bucketName, objectName string, expires time.Duration, reqParams url.Values) (*url.URL, error)
+	PresignedPutObject(ctx context.Context, bucketName, objectName string, expires time.Duration) (*url.URL, error)
+	PresignedPostPolicy(ctx context.Context, p *minio.PostPolicy) (*url.URL, map[string]string, error)
+	BucketExists(ctx context.Context, bucketName string) (bool, error)
+	MakeBucket(ctx context.Context, bucketName string, opts minio.MakeBucketOptions) error
+	ListBuckets(ctx context.Context) ([]minio.BucketInfo, error)
+	StatObject(ctx context.Context, bucketName, objectName string, opts minio.StatObjectOptions) (minio.ObjectInfo, error)
+	CopyObject(ctx context.Context, dst minio.CopyDestOptions, src minio.CopySrcOptions) (minio.UploadInfo, error)
+	PutObjectTagging(ctx context.Context, bucketName, objectName string, otags *tags.Tags, opts minio.PutObjectTaggingOptions) error
+	GetObjectTagging(ctx context.Context, bucketName, objectName string, opts minio.GetObjectTaggingOptions) (*tags.Tags, error)
 }
 
+const (
+	// MaxInlineUploadBytes caps the decoded size of content sent to minio_upload_bytes
+	MaxInlineUploadBytes = 10 << 20
+
+	// defaultStreamPartSize bounds memory for uploads of unknown size; without it the
+	// client buffers parts sized for a 5 TiB object
+	defaultStreamPartSize = 16 << 20
+)
+
 type MinIOConfig struct {
 	Endpoint  string `json:"endpoint"`
 	AccessKey string `json:"access_key"`
 	SecretKey string `json:"secret_key"`
 	Bucket    string `json:"bucket"`
 	UseSSL    bool   `json:"use_ssl"`
+	Region    string `json:"region,omitempty"` // skips the bucket location lookup when set
+
+	AllowedBuckets []string `json:"allowed_buckets,omitempty"`
 }
 
 func NewMinIOWorker(cfg MinIOConfig) (*MinIOWorker, error) {
 	minioClient, err := minio.New(cfg.Endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
 		Secure: cfg.UseSSL,
+		Region: cfg.Region,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
 	}
 
 	return &MinIOWorker{
-		client: minioClient,
-		bucket: cfg.Bucket,
+		client:         minioClient,
+		bucket:         cfg.Bucket,
+		allowedBuckets: cfg.AllowedBuckets,
 	}, nil
 }
 
+// checkBucketAllowed rejects buckets outside the configured allowlist
+func (w *MinIOWorker) checkBucketAllowed(bucket string) error {
+	if len(w.allowedBuckets) == 0 {
+		return nil
+	}
+	for _, allowed := range w.allowedBuckets {
+		if allowed == "*" || allowed == bucket {
+			return nil
+		}
+	}
+	return fmt.Errorf("bucket not allowed: %s", bucket)
+}
+
 func (w *MinIOWorker) GetTools() []ToolDef {
 	return []ToolDef{
-		{Name: "minio_upload_file", Description: "Upload a file to MinIO/S3"},
+		{Name: "minio_upload_file", Description: "Upload a file to MinIO/S3, optionally streaming with a size of -1 and a custom part size"},
+		{Name: "minio_upload_bytes", Description: "Upload small base64-encoded content to MinIO/S3 without a local file"},
 		{Name: "minio_download_file", Description: "Download a file from MinIO/S3"},
 		{Name: "minio_list_objects", Description: "List objects in a bucket/prefix"},
 		{Name: "minio_delete_object", Description: "Delete an object from MinIO/S3"},
+		{Name: "minio_delete_objects", Description: "Bulk delete objects by prefix or key list, with an optional dry run"},
 		{Name: "minio_get_url", Description: "Get presigned URL for an object"},
+		{Name: "minio_presigned_post", Description: "Get a presigned POST URL and form fields for browser uploads with size and content-type limits"},
 		{Name: "minio_bucket_exists", Description: "Check if bucket exists"},
 		{Name: "minio_make_bucket", Description: "Create a new bucket"},
 		{Name: "minio_list_buckets", Description: "List all buckets"},
 		{Name: "minio_get_object_info", Description: "Get object metadata"},
+		{Name: "minio_set_tags", Description: "Replace an object's tags"},
+		{Name: "minio_get_tags", Description: "Get an object's tags"},
+		{Name: "minio_set_metadata", Description: "Update an object's user metadata by copying it in place"},
 		{Name: "minio_copy_object", Description: "Copy object within MinIO"},
 		{Name: "minio_move_object", Description: "Move/rename object in MinIO"},
 		{Name: "minio_sync_directory", Description: "Sync local directory to MinIO"},
+		{Name: "minio_sync_from", Description: "Download objects under a prefix to a local directory, skipping unchanged files"},
 	}
 }
 
@@ -65,14 +127,20 @@ func (w *MinIOWorker) Execute(ctx context.Context, name string, input json.RawMe
 	switch name {
 	case "minio_upload_file":
 		return w.uploadFile(ctx, input)
+	case "minio_upload_bytes":
+		return w.uploadBytes(ctx, input)
 	case "minio_download_file":
 		return w.downloadFile(ctx, input)
 	case "minio_list_objects":
 		return w.listObjects(ctx, input)
 	case "minio_delete_object":
 		return w.deleteObject(ctx, input)
+	case "minio_delete_objects":
+		return w.deleteObjects(ctx, input)
 	case "minio_get_url":
 		return w.getPresignedURL(ctx, input)
+	case "minio_presigned_post":
+		return w.getPresignedPost(ctx, input)
 	case "minio_bucket_exists":
 		return w.bucketExists(ctx, input)
 	case "minio_make_bucket":
@@ -81,12 +149,20 @@ func (w *MinIOWorker) Execute(ctx context.Context, name string, input json.RawMe
 		return w.listBuckets(ctx, input)
 	case "minio_get_object_info":
 		return w.getObjectInfo(ctx, input)
+	case "minio_set_tags":
+		return w.setTags(ctx, input)
+	case "minio_get_tags":
+		return w.getTags(ctx, input)
+	case "minio_set_metadata":
+		return w.setMetadata(ctx, input)
 	case "minio_copy_object":
 		return w.copyObject(ctx, input)
 	case "minio_move_object":
 		return w.moveObject(ctx, input)
 	case "minio_sync_directory":
 		return w.syncDirectory(ctx, input)
+	case "minio_sync_from":
+		return w.syncFrom(ctx, input)
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", name)
 	}
@@ -100,6 +176,9 @@ func (w *MinIOWorker) uploadFile(ctx context.Context, input json.RawMessage) ([]
 		Bucket      string            `json:"bucket,omitempty"`
 		ContentType string            `json:"content_type,omitempty"`
 		Metadata    map[string]string `json:"metadata,omitempty"`
+		Size        *int64            `json:"size,omitempty"`      // overrides the file size; -1 streams until EOF
+		PartSize    uint64            `json:"part_size,omitempty"` // multipart part size in bytes
+		Concurrency uint              `json:"concurrency,omitempty"`
 	}
 	if err := json.Unmarshal(input, &req); err != nil {
 		return nil, err
@@ -109,6 +188,9 @@ func (w *MinIOWorker) uploadFile(ctx context.Context, input json.RawMessage) ([]
 	if bucket == "" {
 		bucket = w.bucket
 	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
 
 	// Detect content type if not provided
 	contentType := req.ContentType
@@ -130,10 +212,21 @@ func (w *MinIOWorker) uploadFile(ctx context.Context, input json.RawMessage) ([]
 		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
 
-	uploadInfo, err := w.client.PutObject(ctx, bucket, req.ObjectName, file, stat.Size(), minio.PutObjectOptions{
-		ContentType: contentType,
+	size := stat.Size()
+	if req.Size != nil {
+		size = *req.Size
+	}
+	opts := minio.PutObjectOptions{
+		ContentType:  contentType,
 		UserMetadata: req.Metadata,
-	})
+		PartSize:     req.PartSize,
+		NumThreads:   req.Concurrency,
+	}
+	if size < 0 && opts.PartSize == 0 {
+		opts.PartSize = defaultStreamPartSize
+	}
+
+	uploadInfo, err := w.client.PutObject(ctx, bucket, req.ObjectName, file, size, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload: %w", err)
 	}
@@ -149,6 +242,64 @@ func (w *MinIOWorker) uploadFile(ctx context.Context, input json.RawMessage) ([]
 	return json.Marshal(result)
 }
 
+// Upload inline base64 content to MinIO
+func (w *MinIOWorker) uploadBytes(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		ObjectName  string            `json:"object_name"`
+		Content     string            `json:"content"` // base64
+		Bucket      string            `json:"bucket,omitempty"`
+		ContentType string            `json:"content_type,omitempty"`
+		Metadata    map[string]string `json:"metadata,omitempty"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+
+	if req.ObjectName == "" {
+		return nil, fmt.Errorf("object_name required")
+	}
+	if base64.StdEncoding.DecodedLen(len(req.Content)) > MaxInlineUploadBytes {
+		return nil, fmt.Errorf("content exceeds %d bytes; use minio_upload_file", MaxInlineUploadBytes)
+	}
+	data, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 content: %w", err)
+	}
+
+	bucket := req.Bucket
+	if bucket == "" {
+		bucket = w.bucket
+	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
+
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(req.ObjectName))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+
+	uploadInfo, err := w.client.PutObject(ctx, bucket, req.ObjectName, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType:  contentType,
+		UserMetadata: req.Metadata,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload: %w", err)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"bucket":       bucket,
+		"object_name":  req.ObjectName,
+		"etag":         uploadInfo.ETag,
+		"size":         uploadInfo.Size,
+		"content_type": contentType,
+		"metadata":     req.Metadata,
+	})
+}
+
 // Download file from MinIO
 func (w *MinIOWorker) downloadFile(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
@@ -164,6 +315,9 @@ func (w *MinIOWorker) downloadFile(ctx context.Context, input json.RawMessage) (
 	if bucket == "" {
 		bucket = w.bucket
 	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
 
 	object, err := w.client.GetObject(ctx, bucket, req.ObjectName, minio.GetObjectOptions{})
 	if err != nil {
@@ -213,6 +367,9 @@ func (w *MinIOWorker) listObjects(ctx context.Context, input json.RawMessage) ([
 	if bucket == "" {
 		bucket = w.bucket
 	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
 
 	if req.MaxKeys == 0 {
 		req.MaxKeys = 1000
@@ -261,6 +418,9 @@ func (w *MinIOWorker) deleteObject(ctx context.Context, input json.RawMessage) (
 	if bucket == "" {
 		bucket = w.bucket
 	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
 
 	opts := minio.RemoveObjectOptions{}
 	if req.VersionID != "" {
@@ -279,6 +439,88 @@ func (w *MinIOWorker) deleteObject(ctx context.Context, input json.RawMessage) (
 	})
 }
 
+// Delete many objects in bulk
+func (w *MinIOWorker) deleteObjects(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		Bucket string   `json:"bucket,omitempty"`
+		Prefix string   `json:"prefix,omitempty"`
+		Keys   []string `json:"keys,omitempty"`
+		DryRun bool     `json:"dry_run,omitempty"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+
+	// An empty prefix would match the whole bucket, so one of the two must be given
+	if (req.Prefix == "") == (len(req.Keys) == 0) {
+		return nil, fmt.Errorf("exactly one of prefix or keys required")
+	}
+
+	bucket := req.Bucket
+	if bucket == "" {
+		bucket = w.bucket
+	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
+
+	keys := req.Keys
+	if req.Prefix != "" {
+		for object := range w.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{
+			Prefix:    req.Prefix,
+			Recursive: true,
+		}) {
+			if object.Err != nil {
+				return nil, fmt.Errorf("failed to list objects: %w", object.Err)
+			}
+			keys = append(keys, object.Key)
+		}
+	}
+
+	if req.DryRun {
+		return json.Marshal(map[string]interface{}{
+			"bucket":  bucket,
+			"dry_run": true,
+			"keys":    keys,
+			"count":   len(keys),
+		})
+	}
+
+	objectsCh := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objectsCh)
+		for _, key := range keys {
+			select {
+			case objectsCh <- minio.ObjectInfo{Key: key}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Drain every result so the client's goroutines finish
+	failures := []map[string]interface{}{}
+	for rErr := range w.client.RemoveObjects(ctx, bucket, objectsCh, minio.RemoveObjectsOptions{}) {
+		if rErr.Err == nil {
+			continue
+		}
+		failures = append(failures, map[string]interface{}{
+			"object": rErr.ObjectName,
+			"error":  rErr.Err.Error(),
+		})
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"bucket":        bucket,
+		"deleted":       len(keys) - len(failures),
+		"errors":        len(failures),
+		"errors_detail": failures,
+	})
+}
+
 // Get presigned URL
 func (w *MinIOWorker) getPresignedURL(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
@@ -295,6 +537,9 @@ func (w *MinIOWorker) getPresignedURL(ctx context.Context, input json.RawMessage
 	if bucket == "" {
 		bucket = w.bucket
 	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
 	if req.Expiry == 0 {
 		req.Expiry = 15 * time.Minute
 	}
@@ -327,6 +572,83 @@ func (w *MinIOWorker) getPresignedURL(ctx context.Context, input json.RawMessage
 	})
 }
 
+// Get presigned POST policy for browser form uploads
+func (w *MinIOWorker) getPresignedPost(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		ObjectName        string `json:"object_name,omitempty"`
+		KeyPrefix         string `json:"key_prefix,omitempty"` // allow any key with this prefix instead of one object
+		Bucket            string `json:"bucket,omitempty"`
+		ExpirySeconds     int    `json:"expiry_seconds,omitempty"`
+		MinSize           int64  `json:"min_size,omitempty"`
+		MaxSize           int64  `json:"max_size,omitempty"`
+		ContentType       string `json:"content_type,omitempty"`
+		ContentTypePrefix string `json:"content_type_prefix,omitempty"` // e.g. "image/"
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+
+	if (req.ObjectName == "") == (req.KeyPrefix == "") {
+		return nil, fmt.Errorf("exactly one of object_name or key_prefix required")
+	}
+
+	bucket := req.Bucket
+	if bucket == "" {
+		bucket = w.bucket
+	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
+
+	expiry := 15 * time.Minute
+	if req.ExpirySeconds > 0 {
+		expiry = time.Duration(req.ExpirySeconds) * time.Second
+	}
+	expiresAt := time.Now().UTC().Add(expiry)
+
+	policy := minio.NewPostPolicy()
+	if err := policy.SetBucket(bucket); err != nil {
+		return nil, err
+	}
+	if req.ObjectName != "" {
+		if err := policy.SetKey(req.ObjectName); err != nil {
+			return nil, err
+		}
+	} else if err := policy.SetKeyStartsWith(req.KeyPrefix); err != nil {
+		return nil, err
+	}
+	if err := policy.SetExpires(expiresAt); err != nil {
+		return nil, err
+	}
+	if req.MaxSize > 0 {
+		if err := policy.SetContentLengthRange(req.MinSize, req.MaxSize); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case req.ContentType != "":
+		if err := policy.SetContentType(req.ContentType); err != nil {
+			return nil, err
+		}
+	case req.ContentTypePrefix != "":
+		if err := policy.SetContentTypeStartsWith(req.ContentTypePrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	postURL, fields, err := w.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate POST policy: %w", err)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"bucket":     bucket,
+		"url":        postURL.String(),
+		"fields":     fields,
+		"expires_at": expiresAt,
+	})
+}
+
 // Check if bucket exists
 func (w *MinIOWorker) bucketExists(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
@@ -340,6 +662,9 @@ func (w *MinIOWorker) bucketExists(ctx context.Context, input json.RawMessage) (
 	if bucket == "" {
 		bucket = w.bucket
 	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
 
 	exists, err := w.client.BucketExists(ctx, bucket)
 	if err != nil {
@@ -365,6 +690,9 @@ func (w *MinIOWorker) makeBucket(ctx context.Context, input json.RawMessage) ([]
 	if req.Bucket == "" {
 		return nil, fmt.Errorf("bucket name required")
 	}
+	if err := w.checkBucketAllowed(req.Bucket); err != nil {
+		return nil, err
+	}
 
 	err := w.client.MakeBucket(ctx, req.Bucket, minio.MakeBucketOptions{
 		Region: req.Location,
@@ -388,6 +716,9 @@ func (w *MinIOWorker) listBuckets(ctx context.Context, input json.RawMessage) ([
 
 	bucketList := []map[string]interface{}{}
 	for _, bucket := range buckets {
+		if w.checkBucketAllowed(bucket.Name) != nil {
+			continue
+		}
 		bucketList = append(bucketList, map[string]interface{}{
 			"name":         bucket.Name,
 			"creation_date": bucket.CreationDate,
@@ -414,6 +745,9 @@ func (w *MinIOWorker) getObjectInfo(ctx context.Context, input json.RawMessage)
 	if bucket == "" {
 		bucket = w.bucket
 	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
 
 	stat, err := w.client.StatObject(ctx, bucket, req.ObjectName, minio.StatObjectOptions{})
 	if err != nil {
@@ -431,6 +765,153 @@ func (w *MinIOWorker) getObjectInfo(ctx context.Context, input json.RawMessage)
 	})
 }
 
+// Set object tags
+func (w *MinIOWorker) setTags(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		ObjectName string            `json:"object_name"`
+		Bucket     string            `json:"bucket,omitempty"`
+		Tags       map[string]string `json:"tags"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+
+	if req.ObjectName == "" {
+		return nil, fmt.Errorf("object_name required")
+	}
+
+	bucket := req.Bucket
+	if bucket == "" {
+		bucket = w.bucket
+	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
+
+	// MapToObjectTags enforces the S3 limits: 10 tags, 128-char keys, 256-char values
+	objectTags, err := tags.MapToObjectTags(req.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tags: %w", err)
+	}
+
+	if err := w.client.PutObjectTagging(ctx, bucket, req.ObjectName, objectTags, minio.PutObjectTaggingOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to set tags: %w", err)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"bucket":      bucket,
+		"object_name": req.ObjectName,
+		"tags":        objectTags.ToMap(),
+	})
+}
+
+// Get object tags
+func (w *MinIOWorker) getTags(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		ObjectName string `json:"object_name"`
+		Bucket     string `json:"bucket,omitempty"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+
+	bucket := req.Bucket
+	if bucket == "" {
+		bucket = w.bucket
+	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
+
+	objectTags, err := w.client.GetObjectTagging(ctx, bucket, req.ObjectName, minio.GetObjectTaggingOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"bucket":      bucket,
+		"object_name": req.ObjectName,
+		"tags":        objectTags.ToMap(),
+	})
+}
+
+// minioPreservedHeaders are the standard headers, besides Content-Type, that a
+// metadata-replacing copy would otherwise drop
+var minioPreservedHeaders = []string{"Cache-Control", "Content-Disposition", "Content-Encoding", "Content-Language", "Expires"}
+
+// Update object metadata. S3 metadata is immutable, so the object is copied onto itself
+// with replacement metadata.
+func (w *MinIOWorker) setMetadata(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		ObjectName string            `json:"object_name"`
+		Bucket     string            `json:"bucket,omitempty"`
+		Metadata   map[string]string `json:"metadata"`
+		Replace    bool              `json:"replace,omitempty"` // drop existing keys instead of merging
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+
+	if req.ObjectName == "" {
+		return nil, fmt.Errorf("object_name required")
+	}
+
+	bucket := req.Bucket
+	if bucket == "" {
+		bucket = w.bucket
+	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
+
+	stat, err := w.client.StatObject(ctx, bucket, req.ObjectName, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	metadata := map[string]string{}
+	if !req.Replace {
+		for k, v := range stat.UserMetadata {
+			metadata[k] = v
+		}
+	}
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+
+	// Replacing metadata also replaces Content-Type and the other standard headers, so
+	// carry them over explicitly
+	dstMetadata := map[string]string{"Content-Type": stat.ContentType}
+	for _, header := range minioPreservedHeaders {
+		if v := stat.Metadata.Get(header); v != "" {
+			dstMetadata[header] = v
+		}
+	}
+	for k, v := range metadata {
+		dstMetadata[k] = v
+	}
+
+	uploadInfo, err := w.client.CopyObject(ctx, minio.CopyDestOptions{
+		Bucket:          bucket,
+		Object:          req.ObjectName,
+		ReplaceMetadata: true,
+		UserMetadata:    dstMetadata,
+	}, minio.CopySrcOptions{
+		Bucket: bucket,
+		Object: req.ObjectName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update metadata: %w", err)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"bucket":      bucket,
+		"object_name": req.ObjectName,
+		"etag":        uploadInfo.ETag,
+		"metadata":    metadata,
+	})
+}
+
 // Copy object
 func (w *MinIOWorker) copyObject(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
@@ -451,6 +932,11 @@ func (w *MinIOWorker) copyObject(ctx context.Context, input json.RawMessage) ([]
 	if dstBucket == "" {
 		dstBucket = w.bucket
 	}
+	for _, bucket := range []string{srcBucket, dstBucket} {
+		if err := w.checkBucketAllowed(bucket); err != nil {
+			return nil, err
+		}
+	}
 
 	srcOpts := minio.CopySrcOptions{
 		Bucket: srcBucket,
@@ -476,41 +962,70 @@ func (w *MinIOWorker) copyObject(ctx context.Context, input json.RawMessage) ([]
 	})
 }
 
-// Move object (copy + delete)
+// Move object (copy + delete). User metadata travels with the copy, and the source is
+// only deleted once the copy is confirmed.
 func (w *MinIOWorker) moveObject(ctx context.Context, input json.RawMessage) ([]byte, error) {
-	// Copy first
-	_, err := w.copyObject(ctx, input)
-	if err != nil {
-		return nil, err
-	}
-
 	var req struct {
-		SourceBucket string `json:"source_bucket,omitempty"`
-		SourceObject string `json:"source_object"`
+		SourceBucket      string `json:"source_bucket,omitempty"`
+		SourceObject      string `json:"source_object"`
+		DestinationBucket string `json:"dest_bucket,omitempty"`
+		DestinationObject string `json:"dest_object"`
 	}
 	if err := json.Unmarshal(input, &req); err != nil {
 		return nil, err
 	}
 
-	// Then delete source
 	srcBucket := req.SourceBucket
 	if srcBucket == "" {
 		srcBucket = w.bucket
 	}
+	dstBucket := req.DestinationBucket
+	if dstBucket == "" {
+		dstBucket = w.bucket
+	}
+	for _, bucket := range []string{srcBucket, dstBucket} {
+		if err := w.checkBucketAllowed(bucket); err != nil {
+			return nil, err
+		}
+	}
+	if srcBucket == dstBucket && req.SourceObject == req.DestinationObject {
+		return nil, fmt.Errorf("source and destination are the same object")
+	}
 
-	deleteReq, _ := json.Marshal(map[string]string{
-		"bucket": srcBucket,
-		"object_name": req.SourceObject,
+	uploadInfo, err := w.client.CopyObject(ctx, minio.CopyDestOptions{
+		Bucket:          dstBucket,
+		Object:          req.DestinationObject,
+		ReplaceMetadata: false,
+	}, minio.CopySrcOptions{
+		Bucket: srcBucket,
+		Object: req.SourceObject,
 	})
-
-	_, err = w.deleteObject(ctx, deleteReq)
 	if err != nil {
+		return nil, fmt.Errorf("failed to copy: %w", err)
+	}
+	if uploadInfo.ETag == "" {
+		return nil, fmt.Errorf("copy of %s/%s returned no ETag; source kept", srcBucket, req.SourceObject)
+	}
+
+	if err := w.client.RemoveObject(ctx, srcBucket, req.SourceObject, minio.RemoveObjectOptions{}); err != nil {
 		return nil, fmt.Errorf("copied but failed to delete source: %w", err)
 	}
 
-	return json.Marshal(map[string]interface{}{
-		"moved": true,
-	})
+	result := map[string]interface{}{
+		"moved":              true,
+		"source_bucket":      srcBucket,
+		"source_object":      req.SourceObject,
+		"destination_bucket": dstBucket,
+		"destination_object": req.DestinationObject,
+		"etag":               uploadInfo.ETag,
+		"size":               uploadInfo.Size,
+	}
+	if stat, err := w.client.StatObject(ctx, dstBucket, req.DestinationObject, minio.StatObjectOptions{}); err == nil {
+		result["size"] = stat.Size
+		result["content_type"] = stat.ContentType
+		result["metadata"] = stat.UserMetadata
+	}
+	return json.Marshal(result)
 }
 
 // Sync local directory to MinIO
@@ -530,6 +1045,9 @@ func (w *MinIOWorker) syncDirectory(ctx context.Context, input json.RawMessage)
 	if bucket == "" {
 		bucket = w.bucket
 	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
 
 	uploaded := []map[string]interface{}{}
 	errors := []map[string]interface{}{}
@@ -629,3 +1147,128 @@ func (w *MinIOWorker) syncDirectory(ctx context.Context, input json.RawMessage)
 		"errors_detail": errors,
 	})
 }
+
+// Sync objects under a prefix from MinIO to a local directory
+func (w *MinIOWorker) syncFrom(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		LocalPath string `json:"local_path"`
+		Prefix    string `json:"prefix,omitempty"`
+		Bucket    string `json:"bucket,omitempty"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+
+	if req.LocalPath == "" {
+		return nil, fmt.Errorf("local_path required")
+	}
+
+	bucket := req.Bucket
+	if bucket == "" {
+		bucket = w.bucket
+	}
+	if err := w.checkBucketAllowed(bucket); err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.Abs(req.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	downloaded := []map[string]interface{}{}
+	skipped := 0
+	failures := []map[string]interface{}{}
+
+	for object := range w.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{
+		Prefix:    req.Prefix,
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			failures = append(failures, map[string]interface{}{
+				"error": object.Err.Error(),
+			})
+			continue
+		}
+		// Keys ending in / are directory markers, not files
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(object.Key, req.Prefix), "/")
+		localPath := filepath.Join(root, filepath.FromSlash(rel))
+		if localPath == root || !strings.HasPrefix(localPath, root+string(filepath.Separator)) {
+			failures = append(failures, map[string]interface{}{
+				"object": object.Key,
+				"error":  "key escapes local_path",
+			})
+			continue
+		}
+
+		if localFileMatches(localPath, object) {
+			skipped++
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+			failures = append(failures, map[string]interface{}{
+				"object": object.Key,
+				"error":  err.Error(),
+			})
+			continue
+		}
+		if err := w.client.FGetObject(ctx, bucket, object.Key, localPath, minio.GetObjectOptions{}); err != nil {
+			failures = append(failures, map[string]interface{}{
+				"object": object.Key,
+				"path":   localPath,
+				"error":  err.Error(),
+			})
+			continue
+		}
+
+		downloaded = append(downloaded, map[string]interface{}{
+			"object_name": object.Key,
+			"local_path":  localPath,
+			"size":        object.Size,
+			"etag":        object.ETag,
+		})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"bucket":        bucket,
+		"local_path":    req.LocalPath,
+		"prefix":        req.Prefix,
+		"downloaded":    len(downloaded),
+		"skipped":       skipped,
+		"errors":        len(failures),
+		"files":         downloaded,
+		"errors_detail": failures,
+	})
+}
+
+// localFileMatches reports whether the file at path already holds the object. A
+// single-part ETag is the content's MD5; multipart ETags (containing "-") aren't, so
+// those fall back to comparing size and modification time.
+func localFileMatches(path string, object minio.ObjectInfo) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() != object.Size {
+		return false
+	}
+
+	etag := strings.Trim(object.ETag, `"`)
+	if strings.Contains(etag, "-") {
+		return !info.ModTime().Before(object.LastModified)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return false
+	}
+	return hex.EncodeToString(h.Sum(nil)) == etag
+}
@@ -0,0 +1,595 @@
+package workers
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeObject is an object held by fakeMinIO
+type fakeObject struct {
+	data        []byte
+	contentType string
+	headers     map[string]string // standard headers other than Content-Type
+	metadata    map[string]string
+	tags        map[string]string
+}
+
+// fakeMinIO is an in-memory MinIOClient keyed by bucket then object name
+type fakeMinIO struct {
+	mu      sync.Mutex
+	objects map[string]map[string]fakeObject
+
+	// lastPutSize and lastPutOpts record the most recent PutObject call
+	lastPutSize int64
+	lastPutOpts minio.PutObjectOptions
+
+	// downloads counts FGetObject calls
+	downloads int
+
+	// failRemove lists keys RemoveObjects reports as failing
+	failRemove map[string]bool
+}
+
+func newFakeMinIO() *fakeMinIO {
+	return &fakeMinIO{objects: map[string]map[string]fakeObject{}}
+}
+
+func newTestMinIOWorker() (*MinIOWorker, *fakeMinIO) {
+	fake := newFakeMinIO()
+	return &MinIOWorker{client: fake, bucket: "docs"}, fake
+}
+
+func fakeETag(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *fakeMinIO) put(bucket, name string, obj fakeObject) {
+	if f.objects[bucket] == nil {
+		f.objects[bucket] = map[string]fakeObject{}
+	}
+	f.objects[bucket][name] = obj
+}
+
+func (f *fakeMinIO) get(bucket, name string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[bucket][name]
+	return obj, ok
+}
+
+func (f *fakeMinIO) PutObject(ctx context.Context, bucketName, objectName string, reader io.Reader, objectSize int64, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastPutSize, f.lastPutOpts = objectSize, opts
+	f.put(bucketName, objectName, fakeObject{data: data, contentType: opts.ContentType, metadata: opts.UserMetadata})
+	return minio.UploadInfo{Bucket: bucketName, Key: objectName, ETag: fakeETag(data), Size: int64(len(data))}, nil
+}
+
+func (f *fakeMinIO) GetObject(ctx context.Context, bucketName, objectName string, opts minio.GetObjectOptions) (*minio.Object, error) {
+	return nil, fmt.Errorf("GetObject not supported by fake")
+}
+
+func (f *fakeMinIO) FGetObject(ctx context.Context, bucketName, objectName, filePath string, opts minio.GetObjectOptions) error {
+	obj, ok := f.get(bucketName, objectName)
+	if !ok {
+		return fmt.Errorf("object not found: %s/%s", bucketName, objectName)
+	}
+	f.mu.Lock()
+	f.downloads++
+	f.mu.Unlock()
+	return os.WriteFile(filePath, obj.data, 0644)
+}
+
+func (f *fakeMinIO) ListObjects(ctx context.Context, bucketName string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	f.mu.Lock()
+	var keys []string
+	for key := range f.objects[bucketName] {
+		if strings.HasPrefix(key, opts.Prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	infos := make([]minio.ObjectInfo, 0, len(keys))
+	for _, key := range keys {
+		obj := f.objects[bucketName][key]
+		infos = append(infos, minio.ObjectInfo{Key: key, Size: int64(len(obj.data)), ETag: fakeETag(obj.data), ContentType: obj.contentType})
+	}
+	f.mu.Unlock()
+
+	ch := make(chan minio.ObjectInfo, len(infos))
+	for _, info := range infos {
+		ch <- info
+	}
+	close(ch)
+	return ch
+}
+
+func (f *fakeMinIO) RemoveObject(ctx context.Context, bucketName, objectName string, opts minio.RemoveObjectOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects[bucketName], objectName)
+	return nil
+}
+
+func (f *fakeMinIO) RemoveObjects(ctx context.Context, bucketName string, objectsCh <-chan minio.ObjectInfo, opts minio.RemoveObjectsOptions) <-chan minio.RemoveObjectError {
+	errCh := make(chan minio.RemoveObjectError)
+	go func() {
+		defer close(errCh)
+		for object := range objectsCh {
+			if f.failRemove[object.Key] {
+				errCh <- minio.RemoveObjectError{ObjectName: object.Key, Err: fmt.Errorf("access denied")}
+				continue
+			}
+			f.mu.Lock()
+			delete(f.objects[bucketName], object.Key)
+			f.mu.Unlock()
+		}
+	}()
+	return errCh
+}
+
+func (f *fakeMinIO) PresignedGetObject(ctx context.Context, bucketName, objectName string, expires time.Duration, reqParams url.Values) (*url.URL, error) {
+	return url.Parse("http://minio.test/" + bucketName + "/" + objectName + "?X-Amz-Signature=get")
+}
+
+func (f *fakeMinIO) PresignedPutObject(ctx context.Context, bucketName, objectName string, expires time.Duration) (*url.URL, error) {
+	return url.Parse("http://minio.test/" + bucketName + "/" + objectName + "?X-Amz-Signature=put")
+}
+
+func (f *fakeMinIO) PresignedPostPolicy(ctx context.Context, p *minio.PostPolicy) (*url.URL, map[string]string, error) {
+	return nil, nil, fmt.Errorf("PresignedPostPolicy not supported by fake")
+}
+
+func (f *fakeMinIO) BucketExists(ctx context.Context, bucketName string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.objects[bucketName]
+	return ok, nil
+}
+
+func (f *fakeMinIO) MakeBucket(ctx context.Context, bucketName string, opts minio.MakeBucketOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.objects[bucketName] == nil {
+		f.objects[bucketName] = map[string]fakeObject{}
+	}
+	return nil
+}
+
+func (f *fakeMinIO) ListBuckets(ctx context.Context) ([]minio.BucketInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var buckets []minio.BucketInfo
+	for name := range f.objects {
+		buckets = append(buckets, minio.BucketInfo{Name: name})
+	}
+	return buckets, nil
+}
+
+func (f *fakeMinIO) StatObject(ctx context.Context, bucketName, objectName string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	obj, ok := f.get(bucketName, objectName)
+	if !ok {
+		return minio.ObjectInfo{}, fmt.Errorf("object not found: %s/%s", bucketName, objectName)
+	}
+	header := http.Header{}
+	for k, v := range obj.headers {
+		header.Set(k, v)
+	}
+	return minio.ObjectInfo{Key: objectName, Size: int64(len(obj.data)), ETag: fakeETag(obj.data), ContentType: obj.contentType, Metadata: header, UserMetadata: obj.metadata}, nil
+}
+
+func (f *fakeMinIO) CopyObject(ctx context.Context, dst minio.CopyDestOptions, src minio.CopySrcOptions) (minio.UploadInfo, error) {
+	obj, ok := f.get(src.Bucket, src.Object)
+	if !ok {
+		return minio.UploadInfo{}, fmt.Errorf("object not found: %s/%s", src.Bucket, src.Object)
+	}
+	if dst.ReplaceMetadata {
+		obj.headers = map[string]string{}
+		obj.metadata = map[string]string{}
+		for k, v := range dst.UserMetadata {
+			switch {
+			case k == "Content-Type":
+				obj.contentType = v
+			case slices.Contains(minioPreservedHeaders, k):
+				obj.headers[k] = v
+			default:
+				obj.metadata[k] = v
+			}
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.put(dst.Bucket, dst.Object, obj)
+	return minio.UploadInfo{Bucket: dst.Bucket, Key: dst.Object, ETag: fakeETag(obj.data), Size: int64(len(obj.data))}, nil
+}
+
+func (f *fakeMinIO) PutObjectTagging(ctx context.Context, bucketName, objectName string, otags *tags.Tags, opts minio.PutObjectTaggingOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[bucketName][objectName]
+	if !ok {
+		return fmt.Errorf("object not found: %s/%s", bucketName, objectName)
+	}
+	obj.tags = otags.ToMap()
+	f.objects[bucketName][objectName] = obj
+	return nil
+}
+
+func (f *fakeMinIO) GetObjectTagging(ctx context.Context, bucketName, objectName string, opts minio.GetObjectTaggingOptions) (*tags.Tags, error) {
+	obj, ok := f.get(bucketName, objectName)
+	if !ok {
+		return nil, fmt.Errorf("object not found: %s/%s", bucketName, objectName)
+	}
+	return tags.MapToObjectTags(obj.tags)
+}
+
+func TestMinIOUploadBytes(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+
+	input, _ := json.Marshal(map[string]any{
+		"object_name": "notes/hello.txt",
+		"content":     base64.StdEncoding.EncodeToString([]byte("hello world")),
+		"metadata":    map[string]string{"source": "adapter"},
+	})
+	out, err := w.Execute(context.Background(), "minio_upload_bytes", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		Bucket      string `json:"bucket"`
+		Size        int64  `json:"size"`
+		ContentType string `json:"content_type"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "docs", resp.Bucket)
+	assert.Equal(t, int64(11), resp.Size)
+	assert.Equal(t, "text/plain; charset=utf-8", resp.ContentType)
+
+	obj, ok := fake.get("docs", "notes/hello.txt")
+	require.True(t, ok)
+	assert.Equal(t, "hello world", string(obj.data))
+	assert.Equal(t, map[string]string{"source": "adapter"}, obj.metadata)
+}
+
+func TestMinIOUploadBytes_RejectsBadContent(t *testing.T) {
+	w, _ := newTestMinIOWorker()
+
+	_, err := w.Execute(context.Background(), "minio_upload_bytes", json.RawMessage(`{"object_name":"a","content":"not base64!"}`))
+	assert.ErrorContains(t, err, "invalid base64")
+
+	tooBig, _ := json.Marshal(map[string]string{"object_name": "a", "content": strings.Repeat("A", MaxInlineUploadBytes/3*4+8)})
+	_, err = w.Execute(context.Background(), "minio_upload_bytes", tooBig)
+	assert.ErrorContains(t, err, "exceeds")
+}
+
+func TestMinIOUploadFile_SizeOverride(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+	path := filepath.Join(t.TempDir(), "big.bin")
+	require.NoError(t, os.WriteFile(path, []byte("streamed content"), 0644))
+
+	input, _ := json.Marshal(map[string]any{"local_path": path, "object_name": "big.bin", "size": -1, "concurrency": 4})
+	_, err := w.Execute(context.Background(), "minio_upload_file", input)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), fake.lastPutSize)
+	assert.Equal(t, uint64(defaultStreamPartSize), fake.lastPutOpts.PartSize)
+	assert.Equal(t, uint(4), fake.lastPutOpts.NumThreads)
+
+	input, _ = json.Marshal(map[string]any{"local_path": path, "object_name": "big.bin", "part_size": 5 << 20})
+	_, err = w.Execute(context.Background(), "minio_upload_file", input)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("streamed content")), fake.lastPutSize)
+	assert.Equal(t, uint64(5<<20), fake.lastPutOpts.PartSize)
+
+	obj, ok := fake.get("docs", "big.bin")
+	require.True(t, ok)
+	assert.Equal(t, "streamed content", string(obj.data))
+}
+
+func TestMinIOAllowedBuckets(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+	w.allowedBuckets = []string{"docs"}
+	content := base64.StdEncoding.EncodeToString([]byte("x"))
+
+	for _, tc := range []struct {
+		tool  string
+		input string
+	}{
+		{"minio_upload_bytes", `{"bucket":"secrets","object_name":"a","content":"` + content + `"}`},
+		{"minio_list_objects", `{"bucket":"secrets"}`},
+		{"minio_delete_object", `{"bucket":"secrets","object_name":"a"}`},
+		{"minio_get_url", `{"bucket":"secrets","object_name":"a"}`},
+		{"minio_get_object_info", `{"bucket":"secrets","object_name":"a"}`},
+		{"minio_make_bucket", `{"bucket":"secrets"}`},
+		{"minio_copy_object", `{"source_object":"a","dest_bucket":"secrets","dest_object":"b"}`},
+		{"minio_move_object", `{"source_bucket":"secrets","source_object":"a","dest_object":"b"}`},
+		{"minio_sync_directory", `{"bucket":"secrets","local_path":"` + t.TempDir() + `"}`},
+	} {
+		_, err := w.Execute(context.Background(), tc.tool, json.RawMessage(tc.input))
+		assert.ErrorContains(t, err, "bucket not allowed: secrets", tc.tool)
+	}
+
+	// The default bucket is on the list
+	_, err := w.Execute(context.Background(), "minio_upload_bytes", json.RawMessage(`{"object_name":"a","content":"`+content+`"}`))
+	require.NoError(t, err)
+	_, ok := fake.get("docs", "a")
+	assert.True(t, ok)
+}
+
+func TestMinIOAllowedBuckets_Wildcard(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+	w.allowedBuckets = []string{"*"}
+
+	_, err := w.Execute(context.Background(), "minio_upload_bytes", json.RawMessage(`{"bucket":"anything","object_name":"a","content":"eA=="}`))
+	require.NoError(t, err)
+	_, ok := fake.get("anything", "a")
+	assert.True(t, ok)
+}
+
+func TestMinIOSyncFrom(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+	fake.put("docs", "backup/a.txt", fakeObject{data: []byte("alpha")})
+	fake.put("docs", "backup/nested/dir/b.txt", fakeObject{data: []byte("bravo")})
+	fake.put("docs", "other/c.txt", fakeObject{data: []byte("charlie")})
+
+	dir := t.TempDir()
+	input, _ := json.Marshal(map[string]string{"local_path": dir, "prefix": "backup/"})
+
+	var resp struct {
+		Downloaded int `json:"downloaded"`
+		Skipped    int `json:"skipped"`
+		Errors     int `json:"errors"`
+	}
+	out, err := w.Execute(context.Background(), "minio_sync_from", input)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 2, resp.Downloaded)
+	assert.Equal(t, 0, resp.Skipped)
+	assert.Equal(t, 0, resp.Errors)
+
+	data, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "alpha", string(data))
+	data, err = os.ReadFile(filepath.Join(dir, "nested", "dir", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "bravo", string(data))
+
+	// A second sync only fetches what changed
+	fake.put("docs", "backup/a.txt", fakeObject{data: []byte("ALPHA")})
+	out, err = w.Execute(context.Background(), "minio_sync_from", input)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 1, resp.Downloaded)
+	assert.Equal(t, 1, resp.Skipped)
+	assert.Equal(t, 3, fake.downloads)
+}
+
+func TestMinIOPresignedPost(t *testing.T) {
+	// Presigning is computed locally, so a real client works without a server once the region is known
+	w, err := NewMinIOWorker(MinIOConfig{
+		Endpoint:  "minio.test:9000",
+		AccessKey: "access",
+		SecretKey: "secret",
+		Bucket:    "uploads",
+		Region:    "us-east-1",
+	})
+	require.NoError(t, err)
+
+	out, err := w.Execute(context.Background(), "minio_presigned_post",
+		json.RawMessage(`{"key_prefix":"avatars/","max_size":1048576,"content_type_prefix":"image/","expiry_seconds":600}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Bucket string            `json:"bucket"`
+		URL    string            `json:"url"`
+		Fields map[string]string `json:"fields"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "uploads", resp.Bucket)
+	assert.Equal(t, "http://minio.test:9000/uploads/", resp.URL)
+	assert.NotEmpty(t, resp.Fields["policy"])
+	assert.NotEmpty(t, resp.Fields["x-amz-signature"])
+
+	policy, err := base64.StdEncoding.DecodeString(resp.Fields["policy"])
+	require.NoError(t, err)
+	assert.Contains(t, string(policy), `["content-length-range", 0, 1048576]`)
+	assert.Contains(t, string(policy), `["starts-with","$Content-Type","image/"]`)
+	assert.Contains(t, string(policy), `["starts-with","$key","avatars/"]`)
+}
+
+func TestMinIOPresignedPost_RequiresOneKey(t *testing.T) {
+	w, _ := newTestMinIOWorker()
+	_, err := w.Execute(context.Background(), "minio_presigned_post", json.RawMessage(`{}`))
+	assert.ErrorContains(t, err, "exactly one of object_name or key_prefix")
+}
+
+func TestMinIOTags_RoundTrip(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+	fake.put("docs", "invoice.pdf", fakeObject{data: []byte("pdf")})
+
+	_, err := w.Execute(context.Background(), "minio_set_tags",
+		json.RawMessage(`{"object_name":"invoice.pdf","tags":{"client":"acme","type":"invoice"}}`))
+	require.NoError(t, err)
+
+	out, err := w.Execute(context.Background(), "minio_get_tags", json.RawMessage(`{"object_name":"invoice.pdf"}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Tags map[string]string `json:"tags"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, map[string]string{"client": "acme", "type": "invoice"}, resp.Tags)
+}
+
+func TestMinIOTags_ValidatesLimits(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+	fake.put("docs", "a", fakeObject{data: []byte("x")})
+
+	tooMany := map[string]string{}
+	for i := 0; i < 11; i++ {
+		tooMany[fmt.Sprintf("k%d", i)] = "v"
+	}
+	for _, tagMap := range []map[string]string{
+		tooMany,
+		{strings.Repeat("k", 129): "v"},
+		{"k": strings.Repeat("v", 257)},
+	} {
+		input, _ := json.Marshal(map[string]any{"object_name": "a", "tags": tagMap})
+		_, err := w.Execute(context.Background(), "minio_set_tags", input)
+		assert.ErrorContains(t, err, "invalid tags")
+	}
+}
+
+func TestMinIOSetMetadata(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+	headers := map[string]string{"Cache-Control": "max-age=3600", "Content-Disposition": `attachment; filename="report.pdf"`}
+	fake.put("docs", "report.pdf", fakeObject{
+		data:        []byte("pdf"),
+		contentType: "application/pdf",
+		headers:     headers,
+		metadata:    map[string]string{"Client": "acme"},
+	})
+
+	_, err := w.Execute(context.Background(), "minio_set_metadata",
+		json.RawMessage(`{"object_name":"report.pdf","metadata":{"Status":"reviewed"}}`))
+	require.NoError(t, err)
+
+	obj, _ := fake.get("docs", "report.pdf")
+	assert.Equal(t, map[string]string{"Client": "acme", "Status": "reviewed"}, obj.metadata)
+	assert.Equal(t, "application/pdf", obj.contentType)
+	assert.Equal(t, headers, obj.headers)
+	assert.Equal(t, "pdf", string(obj.data))
+
+	_, err = w.Execute(context.Background(), "minio_set_metadata",
+		json.RawMessage(`{"object_name":"report.pdf","metadata":{"Status":"final"},"replace":true}`))
+	require.NoError(t, err)
+
+	obj, _ = fake.get("docs", "report.pdf")
+	assert.Equal(t, map[string]string{"Status": "final"}, obj.metadata)
+	assert.Equal(t, headers, obj.headers)
+}
+
+func TestMinIOMoveObject_KeepsMetadata(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+	fake.put("inbox", "scan.pdf", fakeObject{
+		data:        []byte("pdf"),
+		contentType: "application/pdf",
+		metadata:    map[string]string{"Client": "acme"},
+	})
+
+	out, err := w.Execute(context.Background(), "minio_move_object",
+		json.RawMessage(`{"source_bucket":"inbox","source_object":"scan.pdf","dest_object":"2024/scan.pdf"}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Moved             bool              `json:"moved"`
+		DestinationBucket string            `json:"destination_bucket"`
+		ETag              string            `json:"etag"`
+		Metadata          map[string]string `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.True(t, resp.Moved)
+	assert.Equal(t, "docs", resp.DestinationBucket)
+	assert.Equal(t, fakeETag([]byte("pdf")), resp.ETag)
+	assert.Equal(t, map[string]string{"Client": "acme"}, resp.Metadata)
+
+	obj, ok := fake.get("docs", "2024/scan.pdf")
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"Client": "acme"}, obj.metadata)
+	_, ok = fake.get("inbox", "scan.pdf")
+	assert.False(t, ok, "source should be deleted")
+}
+
+func TestMinIOMoveObject_FailedCopyKeepsSource(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+	fake.put("docs", "a", fakeObject{data: []byte("x")})
+
+	_, err := w.Execute(context.Background(), "minio_move_object", json.RawMessage(`{"source_object":"missing","dest_object":"b"}`))
+	assert.ErrorContains(t, err, "failed to copy")
+
+	_, err = w.Execute(context.Background(), "minio_move_object", json.RawMessage(`{"source_object":"a","dest_object":"a"}`))
+	assert.ErrorContains(t, err, "same object")
+	_, ok := fake.get("docs", "a")
+	assert.True(t, ok)
+}
+
+func TestMinIODeleteObjects(t *testing.T) {
+	w, fake := newTestMinIOWorker()
+	for _, key := range []string{"tmp/a", "tmp/b", "tmp/c", "keep/d"} {
+		fake.put("docs", key, fakeObject{data: []byte(key)})
+	}
+	fake.failRemove = map[string]bool{"tmp/c": true}
+
+	out, err := w.Execute(context.Background(), "minio_delete_objects", json.RawMessage(`{"prefix":"tmp/","dry_run":true}`))
+	require.NoError(t, err)
+	var dryRun struct {
+		Keys  []string `json:"keys"`
+		Count int      `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(out, &dryRun))
+	assert.Equal(t, []string{"tmp/a", "tmp/b", "tmp/c"}, dryRun.Keys)
+	_, ok := fake.get("docs", "tmp/a")
+	assert.True(t, ok, "dry run must not delete")
+
+	out, err = w.Execute(context.Background(), "minio_delete_objects", json.RawMessage(`{"prefix":"tmp/"}`))
+	require.NoError(t, err)
+	var resp struct {
+		Deleted      int `json:"deleted"`
+		Errors       int `json:"errors"`
+		ErrorsDetail []struct {
+			Object string `json:"object"`
+		} `json:"errors_detail"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 2, resp.Deleted)
+	assert.Equal(t, 1, resp.Errors)
+	require.Len(t, resp.ErrorsDetail, 1)
+	assert.Equal(t, "tmp/c", resp.ErrorsDetail[0].Object)
+
+	_, ok = fake.get("docs", "tmp/a")
+	assert.False(t, ok)
+	_, ok = fake.get("docs", "keep/d")
+	assert.True(t, ok)
+
+	out, err = w.Execute(context.Background(), "minio_delete_objects", json.RawMessage(`{"keys":["keep/d"]}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 1, resp.Deleted)
+	assert.Equal(t, 0, resp.Errors)
+}
+
+func TestMinIODeleteObjects_Guards(t *testing.T) {
+	w, _ := newTestMinIOWorker()
+	w.allowedBuckets = []string{"docs"}
+
+	_, err := w.Execute(context.Background(), "minio_delete_objects", json.RawMessage(`{}`))
+	assert.ErrorContains(t, err, "exactly one of prefix or keys")
+
+	_, err = w.Execute(context.Background(), "minio_delete_objects", json.RawMessage(`{"bucket":"secrets","keys":["a"]}`))
+	assert.ErrorContains(t, err, "bucket not allowed")
+}
@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,8 +22,14 @@ type OrchestratorWorkerState struct {
 	Runs           map[string]AgentRun
 	Workflows      map[string]Workflow
 	LLMProvider    LLMProvider
+	Providers      map[string]LLMProvider // run agents whose Provider names them; others use LLMProvider
 	MaxParallel    int
 	DefaultTimeout time.Duration
+	JudgeModel     string            // model used to grade outputs when a request doesn't name one
+	Store          OrchestratorStore // nil keeps state in memory only
+	Progress       ProgressSink      // receives orchestrator_evolve_stream updates
+	KnownTools     map[string]bool   // tool names agents may reference; nil skips validation
+	StrictTools    bool              // reject agents with unknown tools instead of warning
 	mu             sync.RWMutex
 }
 
@@ -69,7 +79,7 @@ type Workflow struct {
 type WorkflowStep struct {
 	StepID   string            `json:"step_id"`
 	AgentID  string            `json:"agent_id"`
-	Parallel bool              `json:"parallel"` // run with next step
+	Parallel bool              `json:"parallel"` // run concurrently with adjacent parallel steps
 	Inputs   map[string]string `json:"inputs"`   // from previous outputs
 }
 
@@ -80,10 +90,44 @@ type EvolutionConfig struct {
 	MutationRate    float64 `json:"mutation_rate"`
 	CrossoverRate   float64 `json:"crossover_rate"`
 	EliteCount      int     `json:"elite_count"`
-	FitnessFunction string  `json:"fitness_function"`
+	FitnessFunction string  `json:"fitness_function"` // see the Fitness* constants; default keyword_coverage
+	Seed            *int64  `json:"seed,omitempty"`   // makes a run reproducible; seeded from the clock when omitted
 }
 
-func NewOrchestratorWorkerState(maxParallel int, defaultTimeout time.Duration) *OrchestratorWorkerState {
+// FitnessFunc scores an agent's output on a task from 0.0 (useless) to 1.0 (ideal),
+// optionally explaining the score
+type FitnessFunc func(ctx context.Context, task, output string) (score float64, rationale string, err error)
+
+// Fitness functions selectable via EvolutionConfig.FitnessFunction
+const (
+	FitnessKeywordCoverage = "keyword_coverage"
+	FitnessLengthTarget    = "length_target"
+	FitnessLLMJudge        = "llm_judge"
+)
+
+// GenerationStats summarises one scored generation of an evolution run
+type GenerationStats struct {
+	Generation  int     `json:"generation"` // 0 is the initial population
+	BestFitness float64 `json:"best_fitness"`
+	MeanFitness float64 `json:"mean_fitness"`
+	BestAgentID string  `json:"best_agent_id"`
+}
+
+// ProgressSink receives per-generation updates from orchestrator_evolve_stream. ctx is
+// the tool call's context, so a sink can tell concurrent runs apart.
+type ProgressSink func(ctx context.Context, stats GenerationStats)
+
+// fitnessParams tune the built-in fitness functions
+type fitnessParams struct {
+	Keywords     []string `json:"keywords"`      // keyword_coverage; defaults to the task's significant words
+	TargetLength int      `json:"target_length"` // length_target, in characters; default 500
+	Rubric       string   `json:"rubric"`        // llm_judge grading criteria
+	JudgeModel   string   `json:"judge_model"`   // llm_judge model; defaults to the worker's JudgeModel
+}
+
+// NewOrchestratorWorkerState creates the worker, loading previously saved agents, runs,
+// and workflows when store is non-nil
+func NewOrchestratorWorkerState(maxParallel int, defaultTimeout time.Duration, store OrchestratorStore) *OrchestratorWorkerState {
 	if maxParallel == 0 {
 		maxParallel = 10
 	}
@@ -91,7 +135,7 @@ func NewOrchestratorWorkerState(maxParallel int, defaultTimeout time.Duration) *
 		defaultTimeout = 120 * time.Second
 	}
 
-	return &OrchestratorWorkerState{
+	w := &OrchestratorWorkerState{
 		Tools: []ToolDef{
 			// Agent management
 			{Name: "orchestrator_register_agent", Description: "Register a new agent genome"},
@@ -105,7 +149,12 @@ func NewOrchestratorWorkerState(maxParallel int, defaultTimeout time.Duration) *
 			// Evolution
 			{Name: "orchestrator_evaluate", Description: "Score agent output"},
 			{Name: "orchestrator_evolve", Description: "Create new agents via evolution"},
+			{Name: "orchestrator_evolve_stream", Description: "Evolve agents, reporting each generation's fitness to the progress sink"},
+			{Name: "orchestrator_judge", Description: "Score a run's output with an LLM judge"},
+			{Name: "orchestrator_compare_agents", Description: "Run two agents on the same input and have an LLM judge pick the better output"},
 			{Name: "orchestrator_get_result", Description: "Get result of a run"},
+			{Name: "orchestrator_list_runs", Description: "List past runs, newest first, optionally filtered by agent and status"},
+			{Name: "orchestrator_agent_stats", Description: "Get run counts, success rate and average duration per agent"},
 			// Workflows
 			{Name: "orchestrator_create_workflow", Description: "Create a workflow"},
 			{Name: "orchestrator_list_workflows", Description: "List workflows"},
@@ -115,7 +164,70 @@ func NewOrchestratorWorkerState(maxParallel int, defaultTimeout time.Duration) *
 		Workflows:      make(map[string]Workflow),
 		MaxParallel:    maxParallel,
 		DefaultTimeout: defaultTimeout,
+		Store:          store,
+	}
+	if err := w.loadStored(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
 	}
+	return w
+}
+
+// loadStored fills the in-memory maps from the store
+func (w *OrchestratorWorkerState) loadStored() error {
+	if w.Store == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	agents, err := w.Store.LoadAgents()
+	if err != nil {
+		return fmt.Errorf("failed to load stored agents: %w", err)
+	}
+	for _, a := range agents {
+		w.Agents[a.ID] = a
+	}
+
+	runs, err := w.Store.LoadRuns()
+	if err != nil {
+		return fmt.Errorf("failed to load stored runs: %w", err)
+	}
+	for _, r := range runs {
+		w.Runs[r.RunID] = r
+	}
+
+	workflows, err := w.Store.LoadWorkflows()
+	if err != nil {
+		return fmt.Errorf("failed to load stored workflows: %w", err)
+	}
+	for _, wf := range workflows {
+		w.Workflows[wf.ID] = wf
+	}
+	return nil
+}
+
+// saveAgent records an agent, writing through to the store when set. Callers hold w.mu.
+func (w *OrchestratorWorkerState) saveAgent(agent AgentGenome) error {
+	if w.Store != nil {
+		if err := w.Store.SaveAgent(agent); err != nil {
+			return fmt.Errorf("failed to persist agent: %w", err)
+		}
+	}
+	w.Agents[agent.ID] = agent
+	return nil
+}
+
+// saveRun records a run, writing through to the store when set. Callers hold w.mu.
+// Runs are saved from inside executions, so a failed write is logged rather than
+// failing the run.
+func (w *OrchestratorWorkerState) saveRun(run AgentRun) {
+	if w.Store != nil {
+		if err := w.Store.SaveRun(run); err != nil {
+			fmt.Printf("Warning: failed to persist run %s: %v\n", run.RunID, err)
+		}
+	}
+	w.Runs[run.RunID] = run
 }
 
 func (w *OrchestratorWorkerState) GetTools() []ToolDef {
@@ -144,9 +256,19 @@ func (w *OrchestratorWorkerState) Execute(ctx context.Context, name string, inpu
 	case "orchestrator_orchestrator_evaluate", "orchestrator_evaluate":
 		return w.evaluate(ctx, input)
 	case "orchestrator_orchestrator_evolve", "orchestrator_evolve":
-		return w.evolve(ctx, input)
+		return w.evolve(ctx, input, false)
+	case "orchestrator_orchestrator_evolve_stream", "orchestrator_evolve_stream":
+		return w.evolve(ctx, input, true)
+	case "orchestrator_orchestrator_judge", "orchestrator_judge":
+		return w.judgeRun(ctx, input)
+	case "orchestrator_orchestrator_compare_agents", "orchestrator_compare_agents":
+		return w.compareAgents(ctx, input)
 	case "orchestrator_orchestrator_get_result", "orchestrator_get_result":
 		return w.getResult(ctx, input)
+	case "orchestrator_orchestrator_list_runs", "orchestrator_list_runs":
+		return w.listRuns(ctx, input)
+	case "orchestrator_orchestrator_agent_stats", "orchestrator_agent_stats":
+		return w.agentStats(ctx, input)
 	// Workflows
 	case "orchestrator_orchestrator_create_workflow", "orchestrator_create_workflow":
 		return w.createWorkflow(ctx, input)
@@ -162,6 +284,36 @@ func (w *OrchestratorWorkerState) SetLLMProvider(provider LLMProvider) {
 	w.LLMProvider = provider
 }
 
+// SetProvider registers the provider that runs agents with the given provider name
+func (w *OrchestratorWorkerState) SetProvider(name string, provider LLMProvider) {
+	if w.Providers == nil {
+		w.Providers = make(map[string]LLMProvider)
+	}
+	w.Providers[name] = provider
+}
+
+// providerFor returns the provider registered under name, or the default provider
+func (w *OrchestratorWorkerState) providerFor(name string) LLMProvider {
+	if provider, ok := w.Providers[name]; ok {
+		return provider
+	}
+	return w.LLMProvider
+}
+
+// SetKnownTools enables validation of the tools agents reference at registration
+func (w *OrchestratorWorkerState) SetKnownTools(names []string) {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+	w.KnownTools = known
+}
+
+// SetProgressSink sets where orchestrator_evolve_stream reports each generation
+func (w *OrchestratorWorkerState) SetProgressSink(sink ProgressSink) {
+	w.Progress = sink
+}
+
 // --- Agent Management ---
 
 func (w *OrchestratorWorkerState) registerAgent(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -184,6 +336,11 @@ func (w *OrchestratorWorkerState) registerAgent(ctx context.Context, input json.
 		return nil, fmt.Errorf("name and model required")
 	}
 
+	unknown := w.unknownTools(req.Tools)
+	if len(unknown) > 0 && w.StrictTools {
+		return nil, fmt.Errorf("unknown tools: %s", strings.Join(unknown, ", "))
+	}
+
 	// Generate ID
 	agentID := generateAgentID(req.Name)
 
@@ -203,13 +360,35 @@ func (w *OrchestratorWorkerState) registerAgent(ctx context.Context, input json.
 	}
 
 	w.mu.Lock()
-	w.Agents[agentID] = agent
+	err := w.saveAgent(agent)
 	w.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
 
-	return json.Marshal(map[string]any{
+	result := map[string]any{
 		"agent_id": agentID,
 		"agent":    agent,
-	})
+	}
+	if len(unknown) > 0 {
+		result["unknown_tools"] = unknown
+		result["warning"] = fmt.Sprintf("agent references unknown tools: %s", strings.Join(unknown, ", "))
+	}
+	return json.Marshal(result)
+}
+
+// unknownTools returns the names not in KnownTools, or nil when validation is off
+func (w *OrchestratorWorkerState) unknownTools(tools []string) []string {
+	if w.KnownTools == nil {
+		return nil
+	}
+	var unknown []string
+	for _, tool := range tools {
+		if !w.KnownTools[tool] {
+			unknown = append(unknown, tool)
+		}
+	}
+	return unknown
 }
 
 func (w *OrchestratorWorkerState) listAgents(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -271,6 +450,11 @@ func (w *OrchestratorWorkerState) deleteAgent(ctx context.Context, input json.Ra
 		return nil, fmt.Errorf("agent not found: %s", req.AgentID)
 	}
 
+	if w.Store != nil {
+		if err := w.Store.DeleteAgent(req.AgentID); err != nil {
+			return nil, fmt.Errorf("failed to delete stored agent: %w", err)
+		}
+	}
 	delete(w.Agents, req.AgentID)
 	return json.Marshal(map[string]any{"deleted": true, "agent_id": req.AgentID})
 }
@@ -308,25 +492,44 @@ func (w *OrchestratorWorkerState) runAgent(ctx context.Context, input json.RawMe
 		return nil, fmt.Errorf("agent not found: %s", req.AgentID)
 	}
 
+	run := w.executeGenome(ctx, agent, req.Input)
+	if run.Status == "failed" {
+		return json.Marshal(map[string]any{
+			"run_id": run.RunID,
+			"status": "failed",
+			"error":  run.Error,
+		})
+	}
+
+	return json.Marshal(map[string]any{
+		"run_id": run.RunID,
+		"status": "completed",
+		"output": run.Output,
+	})
+}
+
+// executeGenome runs an agent on input and records the run. The agent need not be
+// registered, which lets evolution try out candidates before keeping them.
+func (w *OrchestratorWorkerState) executeGenome(ctx context.Context, agent AgentGenome, input string) AgentRun {
 	// Create run
 	runID := generateRunID()
 	run := AgentRun{
 		RunID:     runID,
-		GenomeID:  req.AgentID,
-		Input:     req.Input,
+		GenomeID:  agent.ID,
+		Input:     input,
 		Status:    "running",
 		StartedAt: time.Now(),
 	}
 
 	w.mu.Lock()
-	w.Runs[runID] = run
+	w.saveRun(run)
 	w.mu.Unlock()
 
 	// Execute
 	var output string
 	var execErr error
 
-	if w.LLMProvider != nil {
+	if provider := w.providerFor(agent.Provider); provider != nil {
 		temp := agent.Temperature
 		if temp == 0 {
 			temp = 0.7
@@ -335,10 +538,10 @@ func (w *OrchestratorWorkerState) runAgent(ctx context.Context, input json.RawMe
 		if maxTokens == 0 {
 			maxTokens = 2048
 		}
-		output, execErr = w.LLMProvider.Call(ctx, agent.Model, agent.SystemPrompt, req.Input, temp, maxTokens)
+		output, execErr = provider.Call(ctx, agent.Model, agent.SystemPrompt, input, temp, maxTokens)
 	} else {
 		// Fallback: simulate execution
-		output = fmt.Sprintf("[Simulated] Agent '%s' would process: %s", agent.Name, req.Input)
+		output = fmt.Sprintf("[Simulated] Agent '%s' would process: %s", agent.Name, input)
 	}
 
 	now := time.Now()
@@ -352,22 +555,10 @@ func (w *OrchestratorWorkerState) runAgent(ctx context.Context, input json.RawMe
 		existingRun.Output = output
 	}
 	existingRun.CompletedAt = &now
-	w.Runs[runID] = existingRun
+	w.saveRun(existingRun)
 	w.mu.Unlock()
 
-	if execErr != nil {
-		return json.Marshal(map[string]any{
-			"run_id": runID,
-			"status": "failed",
-			"error":  execErr.Error(),
-		})
-	}
-
-	return json.Marshal(map[string]any{
-		"run_id": runID,
-		"status": "completed",
-		"output": output,
-	})
+	return existingRun
 }
 
 func (w *OrchestratorWorkerState) runParallel(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -476,54 +667,67 @@ func (w *OrchestratorWorkerState) runWorkflow(ctx context.Context, input json.Ra
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Execute steps
+	// Execute steps. Consecutive steps marked Parallel form a group that runs
+	// concurrently; the next step waits for the whole group.
 	stepResults := make(map[string]string)
 	stepResults["_initial"] = req.InitialInput
 
-	var lastOutput string
-	lastOutput = req.InitialInput
+	lastOutput := req.InitialInput
+	steps := workflow.Steps
+
+	for start := 0; start < len(steps); {
+		end := start + 1
+		if steps[start].Parallel {
+			for end < len(steps) && steps[end].Parallel {
+				end++
+			}
+		}
+		group := steps[start:end]
+		start = end
 
-	for _, step := range workflow.Steps {
-		// Get input from previous step or initial
-		input := stepResults[step.StepID]
-		if input == "" {
-			input = lastOutput
+		// Resolve inputs before running so group members don't see each other's output
+		inputs := make([]string, len(group))
+		for i, step := range group {
+			inputs[i] = stepInput(step, stepResults, lastOutput)
 		}
 
-		// Override with explicit inputs
-		for key, fromStep := range step.Inputs {
-			if val, ok := stepResults[fromStep]; ok {
-				input = strings.ReplaceAll(input, "${"+key+"}", val)
+		outputs := make([]string, len(group))
+		errs := make([]error, len(group))
+		sem := make(chan struct{}, w.MaxParallel)
+		var wg sync.WaitGroup
+
+		for i, step := range group {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, step WorkflowStep) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				outputs[i], errs[i] = w.runStep(ctx, step.AgentID, inputs[i])
+			}(i, step)
+		}
+		wg.Wait()
+
+		var failed *WorkflowStep
+		var failErr error
+		for i, step := range group {
+			if errs[i] != nil {
+				if failed == nil {
+					failed, failErr = &group[i], errs[i]
+				}
+				continue
 			}
+			stepResults[step.StepID] = outputs[i]
+			lastOutput = outputs[i]
 		}
 
-		// Run agent
-		runInput, _ := json.Marshal(map[string]any{
-			"agent_id": step.AgentID,
-			"input":    input,
-		})
-		runOutput, err := w.runAgent(ctx, runInput)
-
-		if err != nil {
+		if failed != nil {
 			return json.Marshal(map[string]any{
 				"status":  "failed",
-				"step":    step.StepID,
-				"error":   err.Error(),
+				"step":    failed.StepID,
+				"error":   failErr.Error(),
 				"results": stepResults,
 			})
 		}
-
-		var runResult map[string]any
-		json.Unmarshal(runOutput, &runResult)
-
-		output, _ := runResult["output"].(string)
-		runID, _ := runResult["run_id"].(string)
-
-		stepResults[step.StepID] = output
-		lastOutput = output
-
-		// If parallel with next, continue without waiting (already handled)
-		_ = runID
 	}
 
 	return json.Marshal(map[string]any{
@@ -534,6 +738,44 @@ func (w *OrchestratorWorkerState) runWorkflow(ctx context.Context, input json.Ra
 	})
 }
 
+// stepInput picks a step's input: its own earlier result if any, else the previous
+// output, with ${key} placeholders filled from the step results named in Inputs
+func stepInput(step WorkflowStep, stepResults map[string]string, lastOutput string) string {
+	input := stepResults[step.StepID]
+	if input == "" {
+		input = lastOutput
+	}
+
+	for key, fromStep := range step.Inputs {
+		if val, ok := stepResults[fromStep]; ok {
+			input = strings.ReplaceAll(input, "${"+key+"}", val)
+		}
+	}
+	return input
+}
+
+// runStep runs one workflow step's agent, treating a failed run as an error
+func (w *OrchestratorWorkerState) runStep(ctx context.Context, agentID, input string) (string, error) {
+	runInput, _ := json.Marshal(map[string]any{
+		"agent_id": agentID,
+		"input":    input,
+	})
+	runOutput, err := w.runAgent(ctx, runInput)
+	if err != nil {
+		return "", err
+	}
+
+	var runResult map[string]any
+	json.Unmarshal(runOutput, &runResult)
+
+	if status, _ := runResult["status"].(string); status == "failed" {
+		msg, _ := runResult["error"].(string)
+		return "", fmt.Errorf("run failed: %s", msg)
+	}
+	output, _ := runResult["output"].(string)
+	return output, nil
+}
+
 // --- Evolution ---
 
 func (w *OrchestratorWorkerState) evaluate(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -565,12 +807,14 @@ func (w *OrchestratorWorkerState) evaluate(ctx context.Context, input json.RawMe
 	}
 
 	run.Fitness = fitness
-	w.Runs[req.RunID] = run
+	w.saveRun(run)
 
 	// Update agent fitness
 	if agent, ok := w.Agents[run.GenomeID]; ok {
 		agent.Fitness = fitness
-		w.Agents[run.GenomeID] = agent
+		if err := w.saveAgent(agent); err != nil {
+			return nil, err
+		}
 	}
 
 	return json.Marshal(map[string]any{
@@ -580,13 +824,14 @@ func (w *OrchestratorWorkerState) evaluate(ctx context.Context, input json.RawMe
 	})
 }
 
-func (w *OrchestratorWorkerState) evolve(ctx context.Context, input json.RawMessage) ([]byte, error) {
+// evolve breeds new agents from parents. With stream set, each generation's stats are
+// also sent to the progress sink as soon as it has been scored.
+func (w *OrchestratorWorkerState) evolve(ctx context.Context, input json.RawMessage, stream bool) ([]byte, error) {
 	var req struct {
-		Task           string   `json:"task"`
-		ParentIDs      []string `json:"parent_ids"`
-		PopulationSize int      `json:"population_size"`
-		Generations    int      `json:"generations"`
-		MutationRate   float64  `json:"mutation_rate"`
+		Task      string   `json:"task"`
+		ParentIDs []string `json:"parent_ids"`
+		EvolutionConfig
+		fitnessParams
 	}
 
 	if err := json.Unmarshal(input, &req); err != nil {
@@ -602,69 +847,93 @@ func (w *OrchestratorWorkerState) evolve(ctx context.Context, input json.RawMess
 	if req.MutationRate == 0 {
 		req.MutationRate = 0.1
 	}
+	if req.CrossoverRate == 0 {
+		req.CrossoverRate = 0.3
+	}
+	if req.EliteCount == 0 {
+		req.EliteCount = 2
+	}
+	if req.Seed == nil {
+		seed := time.Now().UnixNano()
+		req.Seed = &seed
+	}
+	// All randomness in the run comes from rng so a seed reproduces it exactly
+	rng := rand.New(rand.NewSource(*req.Seed))
 
-	w.mu.Lock()
-	defer w.mu.Unlock()
+	// Without a provider there's nothing real to run, so fall back to random scores
+	mode := "random"
+	var fitness FitnessFunc
+	if w.LLMProvider != nil {
+		if req.Task == "" {
+			return nil, fmt.Errorf("task required to evaluate agents")
+		}
+		var err error
+		if fitness, err = w.fitnessFunc(req.FitnessFunction, req.fitnessParams); err != nil {
+			return nil, err
+		}
+		mode = "llm"
+		if req.FitnessFunction == "" {
+			req.FitnessFunction = FitnessKeywordCoverage
+		}
+	}
 
 	// Get parent agents
+	w.mu.RLock()
 	var parents []AgentGenome
 	for _, id := range req.ParentIDs {
 		if a, ok := w.Agents[id]; ok {
 			parents = append(parents, a)
 		}
 	}
+	w.mu.RUnlock()
 
 	if len(parents) == 0 {
 		return nil, fmt.Errorf("no valid parent agents found")
 	}
 
-	// Evolution loop
-	type scoredAgent struct {
-		genome AgentGenome
-		score  float64
-	}
-
-	population := make([]scoredAgent, 0, req.PopulationSize)
+	population := make([]scoredGenome, 0, req.PopulationSize)
+	usedIDs := map[string]bool{}
 
 	// Initialize with parents + mutations
 	for i := 0; i < req.PopulationSize; i++ {
 		var genome AgentGenome
 		if i < len(parents) {
-			genome = w.mutate(parents[i], req.MutationRate)
+			genome = w.mutate(rng, parents[i], req.MutationRate)
 		} else {
 			// Random mutation of random parent
-			genome = w.mutate(parents[rand.Intn(len(parents))], req.MutationRate)
+			genome = w.mutate(rng, parents[rng.Intn(len(parents))], req.MutationRate)
 		}
-		genome.ID = generateAgentID(genome.Name)
+		genome.ID = w.evolvedAgentID(rng, genome.Name, usedIDs)
 		genome.Generation = 1
 		genome.ParentIDs = req.ParentIDs
 
-		population = append(population, scoredAgent{genome: genome, score: genome.Fitness})
+		population = append(population, scoredGenome{genome: genome, score: genome.Fitness})
 	}
 
-	// Run evolution generations
-	for gen := 0; gen < req.Generations; gen++ {
-		// Evaluate (simulated - in real impl would run agents on task)
-		for i := range population {
-			// Simulated fitness based on diversity
-			population[i].score = 0.3 + rand.Float64()*0.7
-			population[i].genome.Fitness = population[i].score
+	var generations []GenerationStats
+	record := func(gen int) {
+		stats := generationStats(gen, population)
+		generations = append(generations, stats)
+		if stream && w.Progress != nil {
+			w.Progress(ctx, stats)
 		}
+	}
 
-		// Sort by fitness
-		for i := 0; i < len(population)-1; i++ {
-			for j := i + 1; j < len(population); j++ {
-				if population[j].score > population[i].score {
-					population[i], population[j] = population[j], population[i]
-				}
-			}
+	// Run evolution generations
+	for gen := 0; gen < req.Generations; gen++ {
+		if err := w.scorePopulation(ctx, rng, population, req.Task, fitness); err != nil {
+			return nil, err
 		}
+		record(gen)
 
 		// Elitism: keep top performers
-		eliteCount := min(2, len(population)/2)
+		eliteCount := min(req.EliteCount, len(population)/2)
+		if eliteCount < 1 {
+			eliteCount = 1
+		}
 
 		// Create next generation
-		newPopulation := make([]scoredAgent, 0, req.PopulationSize)
+		newPopulation := make([]scoredGenome, 0, req.PopulationSize)
 
 		// Keep elites
 		for i := 0; i < eliteCount; i++ {
@@ -673,100 +942,200 @@ func (w *OrchestratorWorkerState) evolve(ctx context.Context, input json.RawMess
 
 		// Fill rest with crossover + mutation
 		for i := eliteCount; i < req.PopulationSize; i++ {
-			parent1 := population[rand.Intn(eliteCount)].genome
-			parent2 := population[rand.Intn(eliteCount)].genome
+			parent1 := population[rng.Intn(eliteCount)].genome
+			parent2 := population[rng.Intn(eliteCount)].genome
 
 			var child AgentGenome
-			if rand.Float64() < 0.3 {
-				child = w.crossover(parent1, parent2)
+			if rng.Float64() < req.CrossoverRate {
+				child = w.crossover(rng, parent1, parent2)
 			} else {
-				child = w.mutate(parent1, req.MutationRate)
+				child = w.mutate(rng, parent1, req.MutationRate)
 			}
 
-			child.ID = generateAgentID(child.Name)
+			child.ID = w.evolvedAgentID(rng, child.Name, usedIDs)
 			child.Generation = gen + 1
 			child.ParentIDs = []string{parent1.ID, parent2.ID}
 
-			newPopulation = append(newPopulation, scoredAgent{genome: child, score: child.Fitness})
+			newPopulation = append(newPopulation, scoredGenome{genome: child, score: child.Fitness})
 		}
 
 		population = newPopulation
 	}
 
+	// Score the final generation so the agents we keep were actually evaluated
+	if err := w.scorePopulation(ctx, rng, population, req.Task, fitness); err != nil {
+		return nil, err
+	}
+	record(req.Generations)
+
 	// Save best agents
+	w.mu.Lock()
 	bestAgents := make([]AgentGenome, 0)
 	for i := 0; i < min(3, len(population)); i++ {
 		agent := population[i].genome
-		w.Agents[agent.ID] = agent
+		if err := w.saveAgent(agent); err != nil {
+			w.mu.Unlock()
+			return nil, err
+		}
 		bestAgents = append(bestAgents, agent)
 	}
+	w.mu.Unlock()
 
-	return json.Marshal(map[string]any{
-		"evolved":      true,
-		"generations":  req.Generations,
-		"best_agents":  bestAgents,
-		"best_fitness": population[0].score,
-	})
+	result := map[string]any{
+		"evolved":            true,
+		"generations":        req.Generations,
+		"generations_detail": generations,
+		"best_agents":        bestAgents,
+		"best_fitness":       population[0].score,
+		"evaluation":         mode,
+		"seed":               *req.Seed,
+	}
+	if mode == "llm" {
+		result["fitness_function"] = req.FitnessFunction
+	}
+	return json.Marshal(result)
+}
+
+// scoredGenome is a candidate in an evolving population
+type scoredGenome struct {
+	genome AgentGenome
+	score  float64
+}
+
+// generationStats summarises a scored population, which is sorted best first
+func generationStats(gen int, population []scoredGenome) GenerationStats {
+	total := 0.0
+	for _, c := range population {
+		total += c.score
+	}
+	return GenerationStats{
+		Generation:  gen,
+		BestFitness: population[0].score,
+		MeanFitness: total / float64(len(population)),
+		BestAgentID: population[0].genome.ID,
+	}
+}
+
+// scorePopulation sets each candidate's fitness, then sorts best first. With a fitness
+// function, candidates are run on the task (bounded by MaxParallel) and their output
+// scored; a failed run scores 0. Without one, scores are random.
+func (w *OrchestratorWorkerState) scorePopulation(ctx context.Context, rng *rand.Rand, population []scoredGenome, task string, fitness FitnessFunc) error {
+	if fitness == nil {
+		for i := range population {
+			population[i].score = 0.3 + rng.Float64()*0.7
+		}
+	} else {
+		sem := make(chan struct{}, w.MaxParallel)
+		var wg sync.WaitGroup
+		errs := make([]error, len(population))
+
+		for i := range population {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				run := w.executeGenome(ctx, population[i].genome, task)
+				score, rationale := 0.0, ""
+				if run.Status == "completed" {
+					s, why, err := fitness(ctx, task, run.Output)
+					if err != nil {
+						errs[i] = fmt.Errorf("failed to score %s: %w", population[i].genome.ID, err)
+						return
+					}
+					score, rationale = math.Max(0, math.Min(1, s)), why
+				}
+				population[i].score = score
+
+				w.mu.Lock()
+				r := w.Runs[run.RunID]
+				r.Fitness = score
+				if rationale != "" {
+					r.Metadata = withMetadata(r.Metadata, "judge_rationale", rationale)
+				}
+				w.saveRun(r)
+				w.mu.Unlock()
+			}(i)
+		}
+		wg.Wait()
+
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("evolution cancelled: %w", err)
+		}
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := range population {
+		population[i].genome.Fitness = population[i].score
+	}
+	sort.SliceStable(population, func(i, j int) bool { return population[i].score > population[j].score })
+	return nil
 }
 
-func (w *OrchestratorWorkerState) mutate(agent AgentGenome, rate float64) AgentGenome {
-	mutated := agent
+func (w *OrchestratorWorkerState) mutate(rng *rand.Rand, agent AgentGenome, rate float64) AgentGenome {
+	mutated := cloneGenome(agent)
 	mutated.ID = "" // Will be regenerated
 
-	r := rand.Float64()
+	r := rng.Float64()
 	if r < rate {
 		// Mutate temperature
-		delta := (rand.Float64() - 0.5) * 0.2
+		delta := (rng.Float64() - 0.5) * 0.2
 		mutated.Temperature = math.Max(0, math.Min(2, agent.Temperature+delta))
 	}
 
-	r = rand.Float64()
+	r = rng.Float64()
 	if r < rate {
 		// Mutate system prompt (simple truncation/extension)
 		if len(agent.SystemPrompt) > 50 {
-			start := rand.Intn(len(agent.SystemPrompt) - 50)
+			start := rng.Intn(len(agent.SystemPrompt) - 50)
 			mutated.SystemPrompt = agent.SystemPrompt[start : start+50]
 		}
 	}
 
-	r = rand.Float64()
+	r = rng.Float64()
 	if r < rate {
 		// Add/remove a tool
-		if len(agent.Tools) > 0 && rand.Float64() < 0.5 {
-			idx := rand.Intn(len(agent.Tools))
-			mutated.Tools = append(agent.Tools[:idx], agent.Tools[idx+1:]...)
+		if len(mutated.Tools) > 0 && rng.Float64() < 0.5 {
+			idx := rng.Intn(len(mutated.Tools))
+			mutated.Tools = append(mutated.Tools[:idx], mutated.Tools[idx+1:]...)
 		} else {
-			mutated.Tools = append(mutated.Tools, "tool_"+fmt.Sprintf("%d", rand.Intn(100)))
+			mutated.Tools = append(mutated.Tools, "tool_"+fmt.Sprintf("%d", rng.Intn(100)))
 		}
 	}
 
 	return mutated
 }
 
-func (w *OrchestratorWorkerState) crossover(parent1, parent2 AgentGenome) AgentGenome {
-	child := parent1
+func (w *OrchestratorWorkerState) crossover(rng *rand.Rand, parent1, parent2 AgentGenome) AgentGenome {
+	child := cloneGenome(parent1)
 
 	// Crossover: mix prompts
-	if rand.Float64() < 0.5 && len(parent1.SystemPrompt) > 0 && len(parent2.SystemPrompt) > 0 {
+	if rng.Float64() < 0.5 && len(parent1.SystemPrompt) > 0 && len(parent2.SystemPrompt) > 0 {
 		mid1 := len(parent1.SystemPrompt) / 2
 		mid2 := len(parent2.SystemPrompt) / 2
 		child.SystemPrompt = parent1.SystemPrompt[:mid1] + parent2.SystemPrompt[mid2:]
 	}
 
-	// Mix tools
+	// Mix tools, keeping parent order so a seeded run builds the same child
 	toolSet := make(map[string]bool)
+	child.Tools = make([]string, 0, len(parent1.Tools)+len(parent2.Tools))
 	for _, t := range parent1.Tools {
-		toolSet[t] = true
+		if !toolSet[t] {
+			toolSet[t] = true
+			child.Tools = append(child.Tools, t)
+		}
 	}
 	for _, t := range parent2.Tools {
-		if rand.Float64() < 0.5 {
+		if rng.Float64() < 0.5 && !toolSet[t] {
 			toolSet[t] = true
+			child.Tools = append(child.Tools, t)
 		}
 	}
-	child.Tools = make([]string, 0, len(toolSet))
-	for t := range toolSet {
-		child.Tools = append(child.Tools, t)
-	}
 
 	// Average temperature
 	child.Temperature = (parent1.Temperature + parent2.Temperature) / 2
@@ -796,6 +1165,128 @@ func (w *OrchestratorWorkerState) getResult(ctx context.Context, input json.RawM
 	return json.Marshal(run)
 }
 
+func (w *OrchestratorWorkerState) listRuns(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		AgentID string `json:"agent_id"`
+		Status  string `json:"status"`
+		Limit   int    `json:"limit"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+	if req.Limit == 0 {
+		req.Limit = 50
+	}
+
+	w.mu.RLock()
+	runs := make([]AgentRun, 0)
+	for _, r := range w.Runs {
+		if req.AgentID != "" && r.GenomeID != req.AgentID {
+			continue
+		}
+		if req.Status != "" && r.Status != req.Status {
+			continue
+		}
+		runs = append(runs, r)
+	}
+	w.mu.RUnlock()
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	total := len(runs)
+	if len(runs) > req.Limit {
+		runs = runs[:req.Limit]
+	}
+
+	return json.Marshal(map[string]any{
+		"runs":  runs,
+		"count": len(runs),
+		"total": total,
+	})
+}
+
+// AgentStats summarises an agent's run history
+type AgentStats struct {
+	AgentID       string     `json:"agent_id"`
+	Runs          int        `json:"runs"`
+	Completed     int        `json:"completed"`
+	Failed        int        `json:"failed"`
+	Running       int        `json:"running"`
+	SuccessRate   float64    `json:"success_rate"`    // completed / finished runs; 0 with none finished
+	AvgDurationMs float64    `json:"avg_duration_ms"` // over finished runs
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+}
+
+// agentStats reports run stats for one agent, or for every registered agent when
+// agent_id is omitted. Agents that have never run get zeroed stats.
+func (w *OrchestratorWorkerState) agentStats(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		AgentID string `json:"agent_id"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	stats := make(map[string]*AgentStats)
+	if req.AgentID != "" {
+		stats[req.AgentID] = &AgentStats{AgentID: req.AgentID}
+	} else {
+		for id := range w.Agents {
+			stats[id] = &AgentStats{AgentID: id}
+		}
+	}
+
+	durations := make(map[string]time.Duration)
+	for _, r := range w.Runs {
+		s, ok := stats[r.GenomeID]
+		if !ok {
+			continue
+		}
+		s.Runs++
+		switch r.Status {
+		case "completed":
+			s.Completed++
+		case "failed":
+			s.Failed++
+		default:
+			s.Running++
+		}
+		if r.CompletedAt != nil {
+			durations[r.GenomeID] += r.CompletedAt.Sub(r.StartedAt)
+		}
+		if s.LastRunAt == nil || r.StartedAt.After(*s.LastRunAt) {
+			started := r.StartedAt
+			s.LastRunAt = &started
+		}
+	}
+
+	if req.AgentID != "" {
+		if _, registered := w.Agents[req.AgentID]; !registered && stats[req.AgentID].Runs == 0 {
+			return nil, fmt.Errorf("agent not found: %s", req.AgentID)
+		}
+	}
+
+	result := make([]AgentStats, 0, len(stats))
+	for id, s := range stats {
+		if finished := s.Completed + s.Failed; finished > 0 {
+			s.SuccessRate = float64(s.Completed) / float64(finished)
+			s.AvgDurationMs = float64(durations[id].Milliseconds()) / float64(finished)
+		}
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].AgentID < result[j].AgentID })
+
+	if req.AgentID != "" {
+		return json.Marshal(result[0])
+	}
+	return json.Marshal(map[string]any{
+		"agents": result,
+		"count":  len(result),
+	})
+}
+
 // --- Workflows ---
 
 func (w *OrchestratorWorkerState) createWorkflow(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -820,8 +1311,13 @@ func (w *OrchestratorWorkerState) createWorkflow(ctx context.Context, input json
 	}
 
 	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Store != nil {
+		if err := w.Store.SaveWorkflow(workflow); err != nil {
+			return nil, fmt.Errorf("failed to persist workflow: %w", err)
+		}
+	}
 	w.Workflows[workflowID] = workflow
-	w.mu.Unlock()
 
 	return json.Marshal(map[string]any{
 		"workflow_id": workflowID,
@@ -841,14 +1337,355 @@ func (w *OrchestratorWorkerState) listWorkflows(ctx context.Context, input json.
 	return json.Marshal(workflows)
 }
 
+// fitnessFunc resolves a fitness function by name
+func (w *OrchestratorWorkerState) fitnessFunc(name string, params fitnessParams) (FitnessFunc, error) {
+	switch name {
+	case "", FitnessKeywordCoverage:
+		return func(ctx context.Context, task, output string) (float64, string, error) {
+			keywords := params.Keywords
+			if len(keywords) == 0 {
+				for word := range significantWords(strings.ToLower(task)) {
+					keywords = append(keywords, word)
+				}
+			}
+			return keywordCoverage(output, keywords), "", nil
+		}, nil
+	case FitnessLengthTarget:
+		target := params.TargetLength
+		if target <= 0 {
+			target = 500
+		}
+		return func(ctx context.Context, task, output string) (float64, string, error) {
+			diff := math.Abs(float64(len(output) - target))
+			return math.Max(0, 1-diff/float64(target)), "", nil
+		}, nil
+	case FitnessLLMJudge:
+		if w.LLMProvider == nil {
+			return nil, fmt.Errorf("llm_judge requires an LLM provider")
+		}
+		return func(ctx context.Context, task, output string) (float64, string, error) {
+			return w.judge(ctx, params.JudgeModel, task, output, params.Rubric)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown fitness function: %s", name)
+	}
+}
+
+// judgeScorePattern finds the first number in a judge's reply
+var judgeScorePattern = regexp.MustCompile(`-?\d+(?:\.\d+)?`)
+
+// judge asks the LLM to grade output against a rubric on a 0-10 scale and returns the
+// grade as a 0.0-1.0 fitness along with the judge's reply as its rationale
+func (w *OrchestratorWorkerState) judge(ctx context.Context, model, task, output, rubric string) (float64, string, error) {
+	if model == "" {
+		model = w.JudgeModel
+	}
+	if rubric == "" {
+		rubric = "Accuracy, completeness and clarity in addressing the task."
+	}
+
+	prompt := fmt.Sprintf(`Grade the response below against the rubric.
+
+Task:
+%s
+
+Rubric:
+%s
+
+Response:
+%s
+
+Reply with a score from 0 to 10 on the first line, then a short rationale.`, task, rubric, output)
+
+	reply, err := w.LLMProvider.Call(ctx, model, "You are a strict, impartial judge of AI agent outputs.", prompt, 0, 512)
+	if err != nil {
+		return 0, "", fmt.Errorf("judge call failed: %w", err)
+	}
+
+	// Models like to wrap the score in prose ("I'd give this 7/10"), so take the first number
+	match := judgeScorePattern.FindString(reply)
+	if match == "" {
+		return 0, "", fmt.Errorf("judge reply has no score: %q", reply)
+	}
+	score, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("judge reply has no score: %q", reply)
+	}
+
+	return math.Max(0, math.Min(10, score)) / 10, strings.TrimSpace(reply), nil
+}
+
+// judgeRun scores a completed run with the LLM judge, recording the rationale on the run
+func (w *OrchestratorWorkerState) judgeRun(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		RunID      string `json:"run_id"`
+		Rubric     string `json:"rubric"`
+		JudgeModel string `json:"judge_model"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	if w.LLMProvider == nil {
+		return nil, fmt.Errorf("LLM provider required for judging")
+	}
+
+	w.mu.RLock()
+	run, ok := w.Runs[req.RunID]
+	w.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("run not found: %s", req.RunID)
+	}
+	if run.Status != "completed" {
+		return nil, fmt.Errorf("run %s is %s, not completed", req.RunID, run.Status)
+	}
+
+	fitness, rationale, err := w.judge(ctx, req.JudgeModel, run.Input, run.Output, req.Rubric)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	run = w.Runs[req.RunID]
+	run.Fitness = fitness
+	run.Metadata = withMetadata(run.Metadata, "judge_rationale", rationale)
+	w.saveRun(run)
+	if agent, ok := w.Agents[run.GenomeID]; ok {
+		agent.Fitness = fitness
+		err = w.saveAgent(agent)
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]any{
+		"run_id":    req.RunID,
+		"fitness":   fitness,
+		"rationale": rationale,
+	})
+}
+
+// comparisonFitnessStep is how far a head-to-head comparison moves each agent's fitness
+const comparisonFitnessStep = 0.1
+
+// comparisonVerdictPattern reads the judge's pick from the first line of its reply
+var comparisonVerdictPattern = regexp.MustCompile(`(?i)^\W*(tie|a|b)\b`)
+
+// compareAgents runs two agents on the same input and asks the LLM judge which output
+// is better. The winner's fitness goes up and the loser's down; a tie leaves both.
+func (w *OrchestratorWorkerState) compareAgents(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		AgentID1    string `json:"agent_id_1"`
+		AgentID2    string `json:"agent_id_2"`
+		Input       string `json:"input"`
+		JudgeRubric string `json:"judge_rubric"`
+		JudgeModel  string `json:"judge_model"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	if req.AgentID1 == "" || req.AgentID2 == "" || req.Input == "" {
+		return nil, fmt.Errorf("agent_id_1, agent_id_2 and input required")
+	}
+	if req.AgentID1 == req.AgentID2 {
+		return nil, fmt.Errorf("agent_id_1 and agent_id_2 must differ")
+	}
+	if w.LLMProvider == nil {
+		return nil, fmt.Errorf("LLM provider required for judging")
+	}
+
+	type contender struct {
+		RunID  string `json:"run_id"`
+		Status string `json:"status"`
+		Output string `json:"output"`
+		Error  string `json:"error"`
+	}
+	var runs [2]contender
+	for i, agentID := range []string{req.AgentID1, req.AgentID2} {
+		runInput, _ := json.Marshal(map[string]any{
+			"agent_id": agentID,
+			"input":    req.Input,
+		})
+		runOutput, err := w.runAgent(ctx, runInput)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(runOutput, &runs[i]); err != nil {
+			return nil, fmt.Errorf("failed to read run result: %w", err)
+		}
+	}
+
+	// A run that failed loses without troubling the judge
+	var verdict, reasoning string
+	switch {
+	case runs[0].Status != "completed" && runs[1].Status != "completed":
+		return nil, fmt.Errorf("both runs failed: %s; %s", runs[0].Error, runs[1].Error)
+	case runs[0].Status != "completed":
+		verdict, reasoning = "agent_2", "agent_1's run failed: "+runs[0].Error
+	case runs[1].Status != "completed":
+		verdict, reasoning = "agent_1", "agent_2's run failed: "+runs[1].Error
+	default:
+		var err error
+		verdict, reasoning, err = w.judgePair(ctx, req.JudgeModel, req.Input, runs[0].Output, runs[1].Output, req.JudgeRubric)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	winner, loser := "", ""
+	switch verdict {
+	case "agent_1":
+		winner, loser = req.AgentID1, req.AgentID2
+	case "agent_2":
+		winner, loser = req.AgentID2, req.AgentID1
+	}
+
+	fitness := make(map[string]float64, 2)
+	w.mu.Lock()
+	for id, delta := range map[string]float64{winner: comparisonFitnessStep, loser: -comparisonFitnessStep} {
+		agent, ok := w.Agents[id]
+		if id == "" || !ok {
+			continue
+		}
+		agent.Fitness = math.Max(0, math.Min(1, agent.Fitness+delta))
+		if err := w.saveAgent(agent); err != nil {
+			w.mu.Unlock()
+			return nil, err
+		}
+		fitness[id] = agent.Fitness
+	}
+	w.mu.Unlock()
+
+	return json.Marshal(map[string]any{
+		"agent_id_1": req.AgentID1,
+		"agent_id_2": req.AgentID2,
+		"run_id_1":   runs[0].RunID,
+		"run_id_2":   runs[1].RunID,
+		"output_1":   runs[0].Output,
+		"output_2":   runs[1].Output,
+		"verdict":    verdict,
+		"winner":     winner,
+		"reasoning":  reasoning,
+		"fitness":    fitness,
+	})
+}
+
+// judgePair asks the LLM judge which of two outputs better answers the task, returning
+// "agent_1", "agent_2" or "tie" and the judge's reasoning
+func (w *OrchestratorWorkerState) judgePair(ctx context.Context, model, task, output1, output2, rubric string) (string, string, error) {
+	if model == "" {
+		model = w.JudgeModel
+	}
+	if rubric == "" {
+		rubric = "Accuracy, completeness and clarity in addressing the task."
+	}
+
+	prompt := fmt.Sprintf(`Compare the two responses below against the rubric.
+
+Task:
+%s
+
+Rubric:
+%s
+
+Response A:
+%s
+
+Response B:
+%s
+
+Reply with A, B or TIE on the first line, then a short explanation.`, task, rubric, output1, output2)
+
+	reply, err := w.LLMProvider.Call(ctx, model, "You are a strict, impartial judge of AI agent outputs.", prompt, 0, 512)
+	if err != nil {
+		return "", "", fmt.Errorf("judge call failed: %w", err)
+	}
+
+	reply = strings.TrimSpace(reply)
+	firstLine, _, _ := strings.Cut(reply, "\n")
+	match := comparisonVerdictPattern.FindStringSubmatch(firstLine)
+	if match == nil {
+		return "", "", fmt.Errorf("judge reply has no verdict: %q", reply)
+	}
+
+	switch strings.ToLower(match[1]) {
+	case "a":
+		return "agent_1", reply, nil
+	case "b":
+		return "agent_2", reply, nil
+	default:
+		return "tie", reply, nil
+	}
+}
+
+// withMetadata sets key on a possibly nil metadata map
+func withMetadata(metadata map[string]any, key string, value any) map[string]any {
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	metadata[key] = value
+	return metadata
+}
+
+// keywordCoverage is the fraction of keywords that appear in output, ignoring case
+func keywordCoverage(output string, keywords []string) float64 {
+	if len(keywords) == 0 {
+		return 0
+	}
+	lower := strings.ToLower(output)
+	found := 0
+	for _, k := range keywords {
+		if strings.Contains(lower, strings.ToLower(k)) {
+			found++
+		}
+	}
+	return float64(found) / float64(len(keywords))
+}
+
 // --- Helpers ---
 
+// cloneGenome copies a genome deeply enough that editing the copy's tools, parents or
+// metadata can't reach back into the original stored in Agents
+func cloneGenome(agent AgentGenome) AgentGenome {
+	clone := agent
+	clone.Tools = append([]string(nil), agent.Tools...)
+	clone.ParentIDs = append([]string(nil), agent.ParentIDs...)
+	if agent.Metadata != nil {
+		clone.Metadata = make(map[string]any, len(agent.Metadata))
+		for k, v := range agent.Metadata {
+			clone.Metadata[k] = v
+		}
+	}
+	return clone
+}
+
 func generateAgentID(name string) string {
 	return fmt.Sprintf("agent_%s_%d", strings.ReplaceAll(name, " ", "_"), time.Now().UnixNano()%10000)
 }
 
+// evolvedAgentID derives an evolved agent's ID from the run's rng so seeded runs repeat.
+// Siblings share their parent's name, so an ID already used in this run or by a stored
+// agent is redrawn rather than reused.
+func (w *OrchestratorWorkerState) evolvedAgentID(rng *rand.Rand, name string, used map[string]bool) string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for {
+		id := fmt.Sprintf("agent_%s_%d", strings.ReplaceAll(name, " ", "_"), rng.Intn(1000000))
+		if _, stored := w.Agents[id]; !stored && !used[id] {
+			used[id] = true
+			return id
+		}
+	}
+}
+
+// runSeq keeps run IDs unique when runs start in the same instant, as in parallel evaluation
+var runSeq atomic.Uint64
+
 func generateRunID() string {
-	return fmt.Sprintf("run_%d", time.Now().UnixNano()%100000)
+	return fmt.Sprintf("run_%d_%d", time.Now().UnixNano()%100000, runSeq.Add(1))
 }
 
 func generateWorkflowID(name string) string {
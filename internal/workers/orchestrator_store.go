@@ -0,0 +1,132 @@
+package workers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OrchestratorStore persists agents, runs, and workflows so they survive restarts
+type OrchestratorStore interface {
+	SaveAgent(a AgentGenome) error
+	DeleteAgent(id string) error
+	LoadAgents() ([]AgentGenome, error)
+	SaveRun(r AgentRun) error
+	LoadRuns() ([]AgentRun, error)
+	SaveWorkflow(wf Workflow) error
+	LoadWorkflows() ([]Workflow, error)
+}
+
+// FileOrchestratorStore keeps one JSON file per entity under agents/, runs/, and
+// workflows/ subdirectories of a directory
+type FileOrchestratorStore struct {
+	dir string
+}
+
+const (
+	orchestratorAgentsDir    = "agents"
+	orchestratorRunsDir      = "runs"
+	orchestratorWorkflowsDir = "workflows"
+)
+
+// NewFileOrchestratorStore creates the storage directories if needed
+func NewFileOrchestratorStore(dir string) (*FileOrchestratorStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("storage path required")
+	}
+	for _, sub := range []string{orchestratorAgentsDir, orchestratorRunsDir, orchestratorWorkflowsDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create orchestrator storage: %w", err)
+		}
+	}
+	return &FileOrchestratorStore{dir: dir}, nil
+}
+
+func (s *FileOrchestratorStore) path(kind, id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid %s id: %q", kind, id)
+	}
+	return filepath.Join(s.dir, kind, id+".json"), nil
+}
+
+func (s *FileOrchestratorStore) SaveAgent(a AgentGenome) error {
+	return s.save(orchestratorAgentsDir, a.ID, a)
+}
+
+// DeleteAgent removes a stored agent; deleting one that was never saved is not an error
+func (s *FileOrchestratorStore) DeleteAgent(id string) error {
+	path, err := s.path(orchestratorAgentsDir, id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileOrchestratorStore) LoadAgents() ([]AgentGenome, error) {
+	return loadAll[AgentGenome](s, orchestratorAgentsDir)
+}
+
+func (s *FileOrchestratorStore) SaveRun(r AgentRun) error {
+	return s.save(orchestratorRunsDir, r.RunID, r)
+}
+
+func (s *FileOrchestratorStore) LoadRuns() ([]AgentRun, error) {
+	return loadAll[AgentRun](s, orchestratorRunsDir)
+}
+
+func (s *FileOrchestratorStore) SaveWorkflow(wf Workflow) error {
+	return s.save(orchestratorWorkflowsDir, wf.ID, wf)
+}
+
+func (s *FileOrchestratorStore) LoadWorkflows() ([]Workflow, error) {
+	return loadAll[Workflow](s, orchestratorWorkflowsDir)
+}
+
+// save writes v atomically via a temp file and rename
+func (s *FileOrchestratorStore) save(kind, id string, v any) error {
+	path, err := s.path(kind, id)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadAll decodes every JSON file in one of the store's subdirectories
+func loadAll[T any](s *FileOrchestratorStore, kind string) ([]T, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, kind))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []T
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, kind, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var item T
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil, fmt.Errorf("failed to decode %s/%s: %w", kind, e.Name(), err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,506 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider answers every call with a fixed response, optionally after a delay
+type fakeProvider struct {
+	response string
+	delay    time.Duration
+}
+
+func (f *fakeProvider) Call(ctx context.Context, model, systemPrompt, userPrompt string, temperature float64, maxTokens int) (string, error) {
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return f.response, nil
+}
+
+func registerTestAgent(t *testing.T, w *OrchestratorWorkerState, tools ...string) AgentGenome {
+	input, _ := json.Marshal(map[string]any{
+		"name":          "writer",
+		"model":         "test-model",
+		"system_prompt": "You are a careful technical writer who explains things clearly and briefly.",
+		"tools":         tools,
+	})
+	out, err := w.Execute(context.Background(), "orchestrator_register_agent", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		Agent AgentGenome `json:"agent"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	return resp.Agent
+}
+
+func TestOrchestratorEvolve_ScoresWithFitnessFunction(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	w.SetLLMProvider(&fakeProvider{response: "alpha and beta"})
+	parent := registerTestAgent(t, w)
+
+	input, _ := json.Marshal(map[string]any{
+		"task":             "Explain alpha, beta and gamma",
+		"parent_ids":       []string{parent.ID},
+		"population_size":  4,
+		"generations":      2,
+		"fitness_function": "keyword_coverage",
+		"keywords":         []string{"alpha", "beta", "gamma", "delta"},
+	})
+	out, err := w.Execute(context.Background(), "orchestrator_evolve", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		Evaluation      string        `json:"evaluation"`
+		FitnessFunction string        `json:"fitness_function"`
+		BestFitness     float64       `json:"best_fitness"`
+		BestAgents      []AgentGenome `json:"best_agents"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "llm", resp.Evaluation)
+	assert.Equal(t, "keyword_coverage", resp.FitnessFunction)
+	assert.Equal(t, 0.5, resp.BestFitness)
+	for _, a := range resp.BestAgents {
+		assert.Equal(t, 0.5, a.Fitness)
+	}
+}
+
+func TestOrchestratorEvolve_RandomWithoutProvider(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	parent := registerTestAgent(t, w)
+
+	input, _ := json.Marshal(map[string]any{"parent_ids": []string{parent.ID}, "population_size": 4, "generations": 1})
+	out, err := w.Execute(context.Background(), "orchestrator_evolve", input)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"evaluation":"random"`)
+}
+
+func TestRunAgent_UsesNamedProvider(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	w.SetProvider("lmstudio", &fakeProvider{response: "from lmstudio"})
+	ctx := context.Background()
+
+	register := func(provider string) string {
+		out, err := w.Execute(ctx, "orchestrator_register_agent", json.RawMessage(`{"name":"a","model":"m","provider":"`+provider+`"}`))
+		require.NoError(t, err)
+		var resp struct {
+			Agent AgentGenome `json:"agent"`
+		}
+		require.NoError(t, json.Unmarshal(out, &resp))
+		return resp.Agent.ID
+	}
+
+	out, err := w.Execute(ctx, "orchestrator_run_agent", json.RawMessage(`{"agent_id":"`+register("lmstudio")+`","input":"hi"}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"output":"from lmstudio"`)
+
+	// Agents for providers that aren't registered still fall back to simulation
+	out, err = w.Execute(ctx, "orchestrator_run_agent", json.RawMessage(`{"agent_id":"`+register("tgi")+`","input":"hi"}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "[Simulated]")
+}
+
+func TestOrchestratorEvolve_UnknownFitnessFunction(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	w.SetLLMProvider(&fakeProvider{response: "ok"})
+	parent := registerTestAgent(t, w)
+
+	input, _ := json.Marshal(map[string]any{"task": "x", "parent_ids": []string{parent.ID}, "fitness_function": "vibes"})
+	_, err := w.Execute(context.Background(), "orchestrator_evolve", input)
+	assert.ErrorContains(t, err, "unknown fitness function")
+}
+
+func runTestAgent(t *testing.T, w *OrchestratorWorkerState, agentID, input string) string {
+	req, _ := json.Marshal(map[string]string{"agent_id": agentID, "input": input})
+	out, err := w.Execute(context.Background(), "orchestrator_run_agent", req)
+	require.NoError(t, err)
+
+	var resp struct {
+		RunID string `json:"run_id"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	return resp.RunID
+}
+
+func TestOrchestratorJudge(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	provider := &fakeProvider{response: "The sky is blue."}
+	w.SetLLMProvider(provider)
+	agent := registerTestAgent(t, w)
+	runID := runTestAgent(t, w, agent.ID, "What colour is the sky?")
+
+	provider.response = "Solid answer. I'd give it 7.5 out of 10 since it is correct but terse."
+	input, _ := json.Marshal(map[string]string{"run_id": runID, "rubric": "Correct and complete"})
+	out, err := w.Execute(context.Background(), "orchestrator_judge", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		Fitness float64 `json:"fitness"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 0.75, resp.Fitness)
+
+	run := w.Runs[runID]
+	assert.Equal(t, 0.75, run.Fitness)
+	assert.Equal(t, provider.response, run.Metadata["judge_rationale"])
+	assert.Equal(t, 0.75, w.Agents[agent.ID].Fitness)
+
+	provider.response = "Hard to say."
+	_, err = w.Execute(context.Background(), "orchestrator_judge", input)
+	assert.ErrorContains(t, err, "no score")
+}
+
+func TestOrchestratorEvolve_LLMJudge(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	w.SetLLMProvider(&fakeProvider{response: "8"})
+	parent := registerTestAgent(t, w)
+
+	input, _ := json.Marshal(map[string]any{
+		"task":             "Summarise the report",
+		"parent_ids":       []string{parent.ID},
+		"population_size":  3,
+		"generations":      1,
+		"fitness_function": "llm_judge",
+		"rubric":           "Concise",
+	})
+	out, err := w.Execute(context.Background(), "orchestrator_evolve", input)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"best_fitness":0.8`)
+}
+
+func TestOrchestratorEvolve_LeavesParentToolsIntact(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	parent := registerTestAgent(t, w, "fs_read", "fs_write", "git_status", "web_search")
+
+	// A mutation rate of 1 removes or adds a tool on every mutation
+	input, _ := json.Marshal(map[string]any{
+		"parent_ids":      []string{parent.ID},
+		"population_size": 6,
+		"generations":     5,
+		"mutation_rate":   1.0,
+	})
+	_, err := w.Execute(context.Background(), "orchestrator_evolve", input)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"fs_read", "fs_write", "git_status", "web_search"}, w.Agents[parent.ID].Tools)
+}
+
+func TestCrossover_DoesNotAliasParents(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	p1 := AgentGenome{Tools: []string{"a", "b"}, ParentIDs: []string{"x"}, Metadata: map[string]any{"k": "v"}}
+	p2 := AgentGenome{Tools: []string{"c"}}
+
+	child := w.crossover(rand.New(rand.NewSource(1)), p1, p2)
+	child.Metadata["k"] = "changed"
+	child.ParentIDs[0] = "y"
+
+	assert.Equal(t, "v", p1.Metadata["k"])
+	assert.Equal(t, []string{"x"}, p1.ParentIDs)
+	assert.Equal(t, []string{"a", "b"}, p1.Tools)
+}
+
+func TestOrchestratorStore_ReloadsStateFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileOrchestratorStore(dir)
+	require.NoError(t, err)
+
+	w := NewOrchestratorWorkerState(4, 0, store)
+	agent := registerTestAgent(t, w, "fs_read")
+	input, _ := json.Marshal(map[string]any{"agent_id": agent.ID, "input": "hello"})
+	out, err := w.Execute(context.Background(), "orchestrator_run_agent", input)
+	require.NoError(t, err)
+	var run struct {
+		RunID string `json:"run_id"`
+	}
+	require.NoError(t, json.Unmarshal(out, &run))
+
+	reopened, err := NewFileOrchestratorStore(dir)
+	require.NoError(t, err)
+	restarted := NewOrchestratorWorkerState(4, 0, reopened)
+
+	input, _ = json.Marshal(map[string]any{"agent_id": agent.ID})
+	out, err = restarted.Execute(context.Background(), "orchestrator_get_agent", input)
+	require.NoError(t, err)
+	var loaded AgentGenome
+	require.NoError(t, json.Unmarshal(out, &loaded))
+	assert.Equal(t, agent.Name, loaded.Name)
+	assert.Equal(t, []string{"fs_read"}, loaded.Tools)
+
+	require.Contains(t, restarted.Runs, run.RunID)
+	assert.Equal(t, "completed", restarted.Runs[run.RunID].Status)
+}
+
+func TestOrchestratorStore_DeleteAgentRemovesFile(t *testing.T) {
+	store, err := NewFileOrchestratorStore(t.TempDir())
+	require.NoError(t, err)
+
+	w := NewOrchestratorWorkerState(4, 0, store)
+	agent := registerTestAgent(t, w)
+	input, _ := json.Marshal(map[string]any{"agent_id": agent.ID})
+	_, err = w.Execute(context.Background(), "orchestrator_delete_agent", input)
+	require.NoError(t, err)
+
+	agents, err := store.LoadAgents()
+	require.NoError(t, err)
+	assert.Empty(t, agents)
+}
+
+func runTestWorkflow(t *testing.T, w *OrchestratorWorkerState, steps []WorkflowStep) map[string]any {
+	input, _ := json.Marshal(map[string]any{"name": "pipeline", "steps": steps})
+	out, err := w.Execute(context.Background(), "orchestrator_create_workflow", input)
+	require.NoError(t, err)
+	var created struct {
+		WorkflowID string `json:"workflow_id"`
+	}
+	require.NoError(t, json.Unmarshal(out, &created))
+
+	input, _ = json.Marshal(map[string]any{"workflow_id": created.WorkflowID, "initial_input": "start"})
+	out, err = w.Execute(context.Background(), "orchestrator_run_workflow", input)
+	require.NoError(t, err)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(out, &resp))
+	return resp
+}
+
+func TestRunWorkflow_ParallelStepsRunConcurrently(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	w.SetLLMProvider(&fakeProvider{response: "done", delay: delay})
+	agent := registerTestAgent(t, w)
+
+	began := time.Now()
+	resp := runTestWorkflow(t, w, []WorkflowStep{
+		{StepID: "a", AgentID: agent.ID, Parallel: true},
+		{StepID: "b", AgentID: agent.ID, Parallel: true},
+	})
+	elapsed := time.Since(began)
+
+	assert.Equal(t, "completed", resp["status"])
+	assert.Equal(t, map[string]any{"_initial": "start", "a": "done", "b": "done"}, resp["results"])
+	assert.GreaterOrEqual(t, elapsed, delay)
+	assert.Less(t, elapsed, 2*delay, "parallel steps should take about as long as the slowest one")
+}
+
+func TestRunWorkflow_ReportsFailingParallelStep(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	agent := registerTestAgent(t, w)
+
+	resp := runTestWorkflow(t, w, []WorkflowStep{
+		{StepID: "ok", AgentID: agent.ID, Parallel: true},
+		{StepID: "broken", AgentID: "missing", Parallel: true},
+		{StepID: "after", AgentID: agent.ID},
+	})
+
+	assert.Equal(t, "failed", resp["status"])
+	assert.Equal(t, "broken", resp["step"])
+	assert.Contains(t, resp["error"], "agent not found")
+	results := resp["results"].(map[string]any)
+	assert.Contains(t, results, "ok")
+	assert.NotContains(t, results, "after")
+}
+
+func TestCompareAgents_AdjustsFitnessOfWinnerAndLoser(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	w.SetLLMProvider(&fakeProvider{response: "A\nResponse A is more complete."})
+	first := registerTestAgent(t, w)
+	second := registerTestAgent(t, w)
+
+	input, _ := json.Marshal(map[string]any{
+		"agent_id_1":   first.ID,
+		"agent_id_2":   second.ID,
+		"input":        "Summarise the release notes",
+		"judge_rubric": "Completeness",
+	})
+	out, err := w.Execute(context.Background(), "orchestrator_compare_agents", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		Verdict   string             `json:"verdict"`
+		Winner    string             `json:"winner"`
+		Reasoning string             `json:"reasoning"`
+		Output1   string             `json:"output_1"`
+		Fitness   map[string]float64 `json:"fitness"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "agent_1", resp.Verdict)
+	assert.Equal(t, first.ID, resp.Winner)
+	assert.Contains(t, resp.Reasoning, "more complete")
+	assert.NotEmpty(t, resp.Output1)
+	assert.InDelta(t, 0.6, resp.Fitness[first.ID], 1e-9)
+	assert.InDelta(t, 0.4, resp.Fitness[second.ID], 1e-9)
+	assert.InDelta(t, 0.6, w.Agents[first.ID].Fitness, 1e-9)
+}
+
+func TestOrchestratorEvolveStream_ReportsEachGeneration(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	var updates []GenerationStats
+	w.SetProgressSink(func(ctx context.Context, stats GenerationStats) {
+		updates = append(updates, stats)
+	})
+	parent := registerTestAgent(t, w)
+
+	input, _ := json.Marshal(map[string]any{
+		"parent_ids":      []string{parent.ID},
+		"population_size": 4,
+		"generations":     3,
+	})
+	out, err := w.Execute(context.Background(), "orchestrator_evolve_stream", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		BestFitness       float64           `json:"best_fitness"`
+		GenerationsDetail []GenerationStats `json:"generations_detail"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.Len(t, resp.GenerationsDetail, 4)
+	assert.Equal(t, resp.GenerationsDetail, updates)
+	for i, g := range resp.GenerationsDetail {
+		assert.Equal(t, i, g.Generation)
+		assert.GreaterOrEqual(t, g.BestFitness, g.MeanFitness)
+		assert.NotEmpty(t, g.BestAgentID)
+	}
+	assert.Equal(t, resp.BestFitness, resp.GenerationsDetail[3].BestFitness)
+
+	// The plain tool records the trajectory but doesn't stream it
+	updates = nil
+	_, err = w.Execute(context.Background(), "orchestrator_evolve", input)
+	require.NoError(t, err)
+	assert.Empty(t, updates)
+}
+
+func TestRegisterAgent_WarnsOnUnknownTools(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	w.SetKnownTools([]string{"fs_read", "git_status"})
+
+	input, _ := json.Marshal(map[string]any{
+		"name":  "writer",
+		"model": "test-model",
+		"tools": []string{"fs_read", "teleport"},
+	})
+	out, err := w.Execute(context.Background(), "orchestrator_register_agent", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		AgentID      string   `json:"agent_id"`
+		UnknownTools []string `json:"unknown_tools"`
+		Warning      string   `json:"warning"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, []string{"teleport"}, resp.UnknownTools)
+	assert.Contains(t, resp.Warning, "teleport")
+	assert.Contains(t, w.Agents, resp.AgentID)
+
+	w.StrictTools = true
+	_, err = w.Execute(context.Background(), "orchestrator_register_agent", input)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "teleport")
+	assert.Len(t, w.Agents, 1)
+}
+
+func TestListRunsAndAgentStats(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	busy := registerTestAgent(t, w)
+	idle := registerTestAgent(t, w)
+
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	addRun := func(id, status string, start time.Time, took time.Duration) {
+		done := start.Add(took)
+		w.Runs[id] = AgentRun{RunID: id, GenomeID: busy.ID, Status: status, StartedAt: start, CompletedAt: &done}
+	}
+	addRun("r1", "completed", base, time.Second)
+	addRun("r2", "failed", base.Add(time.Minute), 2*time.Second)
+	addRun("r3", "completed", base.Add(2*time.Minute), 3*time.Second)
+
+	input, _ := json.Marshal(map[string]any{"agent_id": busy.ID, "status": "completed"})
+	out, err := w.Execute(context.Background(), "orchestrator_list_runs", input)
+	require.NoError(t, err)
+	var listed struct {
+		Runs  []AgentRun `json:"runs"`
+		Total int        `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(out, &listed))
+	require.Len(t, listed.Runs, 2)
+	assert.Equal(t, "r3", listed.Runs[0].RunID)
+	assert.Equal(t, "r1", listed.Runs[1].RunID)
+
+	input, _ = json.Marshal(map[string]any{"agent_id": busy.ID})
+	out, err = w.Execute(context.Background(), "orchestrator_agent_stats", input)
+	require.NoError(t, err)
+	var stats AgentStats
+	require.NoError(t, json.Unmarshal(out, &stats))
+	assert.Equal(t, 3, stats.Runs)
+	assert.Equal(t, 1, stats.Failed)
+	assert.InDelta(t, 2.0/3, stats.SuccessRate, 1e-9)
+	assert.InDelta(t, 2000, stats.AvgDurationMs, 1e-9)
+
+	input, _ = json.Marshal(map[string]any{"agent_id": idle.ID})
+	out, err = w.Execute(context.Background(), "orchestrator_agent_stats", input)
+	require.NoError(t, err)
+	var idleStats AgentStats
+	require.NoError(t, json.Unmarshal(out, &idleStats))
+	assert.Equal(t, AgentStats{AgentID: idle.ID}, idleStats)
+
+	_, err = w.Execute(context.Background(), "orchestrator_agent_stats", json.RawMessage(`{"agent_id":"missing"}`))
+	assert.Error(t, err)
+}
+
+func TestOrchestratorEvolve_SeedMakesRunsReproducible(t *testing.T) {
+	parent := registerTestAgent(t, NewOrchestratorWorkerState(4, 0, nil), "fs_read", "git_status")
+
+	// Each run gets a fresh worker holding only the parent, so no stored agent forces an
+	// ID to be redrawn
+	evolve := func(seed int64) (ids []string, detail []GenerationStats) {
+		w := NewOrchestratorWorkerState(4, 0, nil)
+		w.Agents[parent.ID] = parent
+		input, _ := json.Marshal(map[string]any{
+			"parent_ids":      []string{parent.ID},
+			"population_size": 6,
+			"generations":     4,
+			"mutation_rate":   0.5,
+			"seed":            seed,
+		})
+		out, err := w.Execute(context.Background(), "orchestrator_evolve", input)
+		require.NoError(t, err)
+
+		var resp struct {
+			BestAgents        []AgentGenome     `json:"best_agents"`
+			GenerationsDetail []GenerationStats `json:"generations_detail"`
+		}
+		require.NoError(t, json.Unmarshal(out, &resp))
+		for _, a := range resp.BestAgents {
+			ids = append(ids, a.ID)
+		}
+		return ids, resp.GenerationsDetail
+	}
+
+	ids1, detail1 := evolve(42)
+	ids2, detail2 := evolve(42)
+	assert.Equal(t, ids1, ids2)
+	assert.Equal(t, detail1, detail2)
+
+	ids3, _ := evolve(7)
+	assert.NotEqual(t, ids1, ids3)
+}
+
+func TestEvolvedAgentID_RedrawsUsedIDs(t *testing.T) {
+	w := NewOrchestratorWorkerState(4, 0, nil)
+	first := w.evolvedAgentID(rand.New(rand.NewSource(1)), "writer", map[string]bool{})
+
+	// The same draw is taken, by this run or by a stored agent, so another is made
+	used := map[string]bool{first: true}
+	assert.NotEqual(t, first, w.evolvedAgentID(rand.New(rand.NewSource(1)), "writer", used))
+
+	w.Agents[first] = AgentGenome{ID: first}
+	assert.NotEqual(t, first, w.evolvedAgentID(rand.New(rand.NewSource(1)), "writer", map[string]bool{}))
+}
@@ -0,0 +1,64 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errPathOutsideBase is returned by pathWithin for a path that escapes its base
+var errPathOutsideBase = errors.New("path outside base path")
+
+// pathWithin resolves p, relative to base unless absolute, following symlinks. It
+// returns errPathOutsideBase if the result is outside base.
+func pathWithin(base, p string) (string, error) {
+	resolvedBase, err := resolveExisting(base)
+	if err != nil {
+		return "", err
+	}
+
+	if p == "" {
+		return resolvedBase, nil
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(base, p)
+	}
+	resolved, err := resolveExisting(p)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(resolvedBase, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", errPathOutsideBase, p)
+	}
+	return resolved, nil
+}
+
+// resolveExisting makes p absolute and follows symlinks in the longest prefix of it
+// that exists, keeping any remaining components as they are
+func resolveExisting(p string) (string, error) {
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	var rest []string
+	for {
+		resolved, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return filepath.Join(append([]string{p}, rest...)...), nil
+		}
+		rest = append([]string{filepath.Base(p)}, rest...)
+		p = parent
+	}
+}
@@ -1,12 +1,17 @@
 package workers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -14,6 +19,7 @@ import (
 type ProjectWorker struct {
 	basePath     string
 	templatesDir string
+	linters      map[string][]string // project type -> lint command overriding the defaults
 }
 
 func NewProjectWorker(basePath, templatesDir string) *ProjectWorker {
@@ -23,14 +29,33 @@ func NewProjectWorker(basePath, templatesDir string) *ProjectWorker {
 	}
 }
 
+// SetFrameworks applies per-type settings from the project worker's frameworks config.
+// Entries that are maps may set "lint" to the command that lints that project type,
+// for example {"go": {"lint": "golangci-lint run --fast"}}.
+func (w *ProjectWorker) SetFrameworks(frameworks map[string]interface{}) {
+	w.linters = map[string][]string{}
+	for projectType, v := range frameworks {
+		settings, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if lint, ok := settings["lint"].(string); ok && strings.TrimSpace(lint) != "" {
+			w.linters[projectType] = strings.Fields(lint)
+		}
+	}
+}
+
 func (w *ProjectWorker) GetTools() []ToolDef {
 	return []ToolDef{
 		{Name: "list_templates", Description: "List available project templates"},
+		{Name: "template_info", Description: "Show the variables a project template expects"},
 		{Name: "create", Description: "Create project from template"},
 		{Name: "info", Description: "Get project information"},
 		{Name: "build", Description: "Build the project"},
 		{Name: "test", Description: "Run project tests"},
+		{Name: "lint", Description: "Run the project's linter and report findings"},
 		{Name: "deps", Description: "Manage dependencies"},
+		{Name: "clean", Description: "Remove build output; previews unless confirm is set"},
 		{Name: "structure", Description: "Get project structure"},
 	}
 }
@@ -39,6 +64,8 @@ func (w *ProjectWorker) Execute(ctx context.Context, name string, input json.Raw
 	switch name {
 	case "list_templates", "project_list_templates":
 		return w.listTemplates(ctx, input)
+	case "template_info", "project_template_info":
+		return w.templateInfo(ctx, input)
 	case "create", "project_create":
 		return w.create(ctx, input)
 	case "info", "project_info":
@@ -47,8 +74,12 @@ func (w *ProjectWorker) Execute(ctx context.Context, name string, input json.Raw
 		return w.build(ctx, input)
 	case "test", "project_test":
 		return w.test(ctx, input)
+	case "lint", "project_lint":
+		return w.lint(ctx, input)
 	case "deps", "project_deps":
 		return w.deps(ctx, input)
+	case "clean", "project_clean":
+		return w.clean(ctx, input)
 	case "structure", "project_structure":
 		return w.structure(ctx, input)
 	default:
@@ -62,10 +93,7 @@ func (w *ProjectWorker) listTemplates(ctx context.Context, input json.RawMessage
 	var req ListTemplatesInput
 	json.Unmarshal(input, &req)
 
-	path := w.templatesDir
-	if path == "" {
-		path = filepath.Join(w.basePath, "templates")
-	}
+	path := w.templateRoot()
 
 	entries, err := os.ReadDir(path)
 	if err != nil {
@@ -92,6 +120,94 @@ func (w *ProjectWorker) listTemplates(ctx context.Context, input json.RawMessage
 	})
 }
 
+// templateManifestFile declares a template's variables; it is not copied into projects
+const templateManifestFile = "template.json"
+
+// TemplateManifest describes a project template, read from its template.json
+type TemplateManifest struct {
+	Description string             `json:"description,omitempty"`
+	Variables   []TemplateVariable `json:"variables"`
+}
+
+type TemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+func (w *ProjectWorker) templateRoot() string {
+	if w.templatesDir != "" {
+		return w.templatesDir
+	}
+	return filepath.Join(w.basePath, "templates")
+}
+
+// loadTemplateManifest reads a template's manifest. Templates without one have no
+// declared variables.
+func loadTemplateManifest(templatePath string) (TemplateManifest, error) {
+	manifest := TemplateManifest{Variables: []TemplateVariable{}}
+	data, err := os.ReadFile(filepath.Join(templatePath, templateManifestFile))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid %s: %w", templateManifestFile, err)
+	}
+	return manifest, nil
+}
+
+// validTemplateName reports whether name is a single entry of the templates directory
+func validTemplateName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// sandboxPath resolves p, relative to basePath unless absolute, and rejects it if it
+// escapes basePath once symlinks are followed. An empty p is basePath itself. The
+// path need not exist yet, so projects can be created.
+func (w *ProjectWorker) sandboxPath(p string) (string, error) {
+	if w.basePath == "" {
+		return "", fmt.Errorf("project base path not configured")
+	}
+	resolved, err := pathWithin(w.basePath, p)
+	if errors.Is(err, errPathOutsideBase) {
+		return "", fmt.Errorf("path outside project base path: %s", p)
+	}
+	return resolved, err
+}
+
+type TemplateInfoInput struct {
+	Template string `json:"template"`
+}
+
+func (w *ProjectWorker) templateInfo(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req TemplateInfoInput
+	json.Unmarshal(input, &req)
+
+	if req.Template == "" {
+		return nil, fmt.Errorf("template is required")
+	}
+
+	templatePath := filepath.Join(w.templateRoot(), req.Template)
+	if info, err := os.Stat(templatePath); err != nil || !info.IsDir() || !validTemplateName(req.Template) {
+		return nil, fmt.Errorf("template not found: %s", req.Template)
+	}
+
+	manifest, err := loadTemplateManifest(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"template":    req.Template,
+		"description": manifest.Description,
+		"variables":   manifest.Variables,
+	})
+}
+
 type CreateInput struct {
 	Template string            `json:"template"`
 	Name     string            `json:"name"`
@@ -107,31 +223,56 @@ func (w *ProjectWorker) create(ctx context.Context, input json.RawMessage) ([]by
 		return nil, fmt.Errorf("project name is required")
 	}
 
-	templatePath := w.templatesDir
-	if templatePath == "" {
-		templatePath = filepath.Join(w.basePath, "templates")
-	}
+	templatePath := w.templateRoot()
 	if req.Template != "" {
+		if !validTemplateName(req.Template) {
+			return nil, fmt.Errorf("template not found: %s", req.Template)
+		}
 		templatePath = filepath.Join(templatePath, req.Template)
 	}
 
-	destPath := req.Path
-	if destPath == "" {
-		destPath = filepath.Join(w.basePath, req.Name)
+	// Fill variables the caller left out from the manifest defaults; name defaults to
+	// the project name
+	manifest, err := loadTemplateManifest(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]string{"name": req.Name}
+	var missing []string
+	for k, v := range req.Vars {
+		vars[k] = v
+	}
+	for _, v := range manifest.Variables {
+		if _, ok := vars[v.Name]; ok {
+			continue
+		}
+		if v.Default != "" {
+			vars[v.Name] = v.Default
+		} else if v.Required {
+			missing = append(missing, v.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required template variables: %s", strings.Join(missing, ", "))
+	}
+
+	target := req.Path
+	if target == "" {
+		target = req.Name
+	}
+	destPath, err := w.sandboxPath(target)
+	if err != nil {
+		return nil, err
 	}
 
 	if _, err := os.Stat(destPath); err == nil {
 		return nil, fmt.Errorf("project already exists: %s", req.Name)
 	}
 
-	if err := copyDir(templatePath, destPath); err != nil {
+	if err := copyTemplate(templatePath, destPath, vars); err != nil {
 		return nil, err
 	}
 
-	if len(req.Vars) > 0 {
-		applyVars(destPath, req.Vars)
-	}
-
 	return json.Marshal(map[string]string{
 		"status":   "created",
 		"name":     req.Name,
@@ -148,9 +289,9 @@ func (w *ProjectWorker) info(ctx context.Context, input json.RawMessage) ([]byte
 	var req InfoInput
 	json.Unmarshal(input, &req)
 
-	projectPath := req.Path
-	if projectPath == "" {
-		projectPath = w.basePath
+	projectPath, err := w.sandboxPath(req.Path)
+	if err != nil {
+		return nil, err
 	}
 
 	info, err := os.Stat(projectPath)
@@ -179,9 +320,9 @@ func (w *ProjectWorker) build(ctx context.Context, input json.RawMessage) ([]byt
 	var req BuildInput
 	json.Unmarshal(input, &req)
 
-	projectPath := req.Path
-	if projectPath == "" {
-		projectPath = w.basePath
+	projectPath, err := w.sandboxPath(req.Path)
+	if err != nil {
+		return nil, err
 	}
 
 	projectType := detectProjectType(projectPath)
@@ -201,17 +342,14 @@ func (w *ProjectWorker) build(ctx context.Context, input json.RawMessage) ([]byt
 	}
 
 	cmd.Dir = projectPath
-	out, err := cmd.CombinedOutput()
+	result, err := runProjectCommand(cmd, "built")
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s", err, string(out))
+		return nil, err
 	}
+	result["project"] = filepath.Base(projectPath)
+	result["type"] = projectType
 
-	return json.Marshal(map[string]interface{}{
-		"status":  "built",
-		"project": filepath.Base(projectPath),
-		"type":    projectType,
-		"output":  string(out),
-	})
+	return json.Marshal(result)
 }
 
 type TestInput struct {
@@ -223,9 +361,9 @@ func (w *ProjectWorker) test(ctx context.Context, input json.RawMessage) ([]byte
 	var req TestInput
 	json.Unmarshal(input, &req)
 
-	projectPath := req.Path
-	if projectPath == "" {
-		projectPath = w.basePath
+	projectPath, err := w.sandboxPath(req.Path)
+	if err != nil {
+		return nil, err
 	}
 
 	projectType := detectProjectType(projectPath)
@@ -248,16 +386,142 @@ func (w *ProjectWorker) test(ctx context.Context, input json.RawMessage) ([]byte
 	}
 
 	cmd.Dir = projectPath
-	out, err := cmd.CombinedOutput()
+	result, err := runProjectCommand(cmd, "passed")
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s", err, string(out))
+		return nil, err
 	}
+	result["project"] = filepath.Base(projectPath)
+	result["type"] = projectType
 
-	return json.Marshal(map[string]interface{}{
-		"status":  "tested",
-		"project": filepath.Base(projectPath),
-		"output":  string(out),
-	})
+	return json.Marshal(result)
+}
+
+// runProjectCommand runs cmd with separate stdout and stderr capture. A non-zero exit
+// is a valid result with status "failed"; only failing to run the command at all,
+// such as a missing toolchain, is an error.
+func runProjectCommand(cmd *exec.Cmd, successStatus string) (map[string]interface{}, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	duration := time.Since(start)
+
+	status, exitCode := successStatus, 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("failed to run %s: %w", filepath.Base(cmd.Path), err)
+		}
+		status, exitCode = "failed", exitErr.ExitCode()
+	}
+
+	return map[string]interface{}{
+		"status":      status,
+		"exit_code":   exitCode,
+		"stdout":      stdout.String(),
+		"stderr":      stderr.String(),
+		"duration_ms": duration.Milliseconds(),
+	}, nil
+}
+
+type LintInput struct {
+	Path string `json:"path"`
+}
+
+// defaultLinters lists lint commands per project type in order of preference; the
+// first one installed is used
+var defaultLinters = map[string][][]string{
+	"go":     {{"golangci-lint", "run"}, {"go", "vet", "./..."}},
+	"node":   {{"eslint", "-f", "unix", "."}},
+	"python": {{"ruff", "check", "--output-format=concise", "."}, {"flake8", "."}},
+}
+
+// LintFinding is one problem reported by a linter
+type LintFinding struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// lintFindingPattern matches the file:line:col: message format go vet,
+// golangci-lint, ruff, flake8, and eslint's unix formatter share
+var lintFindingPattern = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)?\s*(.+)$`)
+
+func (w *ProjectWorker) lint(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req LintInput
+	json.Unmarshal(input, &req)
+
+	projectPath, err := w.sandboxPath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	projectType := detectProjectType(projectPath)
+
+	candidates := defaultLinters[projectType]
+	if custom, ok := w.linters[projectType]; ok {
+		candidates = [][]string{custom}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("unsupported project type: %s", projectType)
+	}
+
+	var linter []string
+	for _, c := range candidates {
+		// Prefer a linter installed in the project, such as eslint under node_modules
+		if local := filepath.Join(projectPath, "node_modules", ".bin", c[0]); fileExists(local) {
+			linter = append([]string{local}, c[1:]...)
+			break
+		}
+		if _, err := exec.LookPath(c[0]); err == nil {
+			linter = c
+			break
+		}
+	}
+	if linter == nil {
+		return json.Marshal(map[string]interface{}{
+			"status":  "not_installed",
+			"project": filepath.Base(projectPath),
+			"type":    projectType,
+			"message": fmt.Sprintf("linter not installed: %s", candidates[0][0]),
+		})
+	}
+
+	cmd := exec.CommandContext(ctx, linter[0], linter[1:]...)
+	cmd.Dir = projectPath
+	result, err := runProjectCommand(cmd, "passed")
+	if err != nil {
+		return nil, err
+	}
+
+	findings := []LintFinding{}
+	for _, stream := range []string{"stdout", "stderr"} {
+		for _, line := range strings.Split(result[stream].(string), "\n") {
+			m := lintFindingPattern.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil {
+				continue
+			}
+			lineNo, _ := strconv.Atoi(m[2])
+			col, _ := strconv.Atoi(m[3])
+			findings = append(findings, LintFinding{File: m[1], Line: lineNo, Column: col, Message: m[4]})
+		}
+	}
+
+	result["project"] = filepath.Base(projectPath)
+	result["type"] = projectType
+	result["linter"] = strings.Join(linter, " ")
+	result["findings"] = findings
+	result["count"] = len(findings)
+
+	return json.Marshal(result)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
 type DepsInput struct {
@@ -269,9 +533,9 @@ func (w *ProjectWorker) deps(ctx context.Context, input json.RawMessage) ([]byte
 	var req DepsInput
 	json.Unmarshal(input, &req)
 
-	projectPath := req.Path
-	if projectPath == "" {
-		projectPath = w.basePath
+	projectPath, err := w.sandboxPath(req.Path)
+	if err != nil {
+		return nil, err
 	}
 
 	action := req.Action
@@ -331,18 +595,137 @@ func (w *ProjectWorker) deps(ctx context.Context, input json.RawMessage) ([]byte
 	})
 }
 
+type CleanInput struct {
+	Path    string `json:"path"`
+	Confirm bool   `json:"confirm"`
+}
+
+// cleanTargets lists build output removed per project type, relative to the project
+var cleanTargets = map[string][]string{
+	"go":     {}, // go clean runs instead; bin/ may hold checked-in scripts, so it stays
+	"node":   {"node_modules/.cache", "dist"},
+	"python": {}, // __pycache__ directories are found by walking the project
+}
+
+func (w *ProjectWorker) clean(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req CleanInput
+	json.Unmarshal(input, &req)
+
+	projectPath, err := w.sandboxPath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	projectType := detectProjectType(projectPath)
+	targets, ok := cleanTargets[projectType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported project type: %s", projectType)
+	}
+
+	paths := []string{}
+	for _, t := range targets {
+		// A symlinked parent such as node_modules can point outside the project
+		if _, err := pathWithin(projectPath, t); err != nil {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(projectPath, t)); err == nil {
+			paths = append(paths, t)
+		}
+	}
+	if projectType == "python" {
+		filepath.WalkDir(projectPath, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if d.Name() == "node_modules" || d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if d.Name() == "__pycache__" {
+				rel, _ := filepath.Rel(projectPath, p)
+				paths = append(paths, rel)
+				return filepath.SkipDir
+			}
+			return nil
+		})
+	}
+
+	var freed int64
+	for _, p := range paths {
+		freed += diskUsage(filepath.Join(projectPath, p))
+	}
+
+	if !req.Confirm {
+		return json.Marshal(map[string]interface{}{
+			"status":       "preview",
+			"project":      filepath.Base(projectPath),
+			"type":         projectType,
+			"would_remove": paths,
+			"bytes":        freed,
+		})
+	}
+
+	for _, p := range paths {
+		// Listed targets were checked against the project and the walk doesn't follow
+		// symlinks, so they are inside it; RemoveAll deletes a symlink, not its target
+		if err := os.RemoveAll(filepath.Join(projectPath, p)); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", p, err)
+		}
+	}
+
+	result := map[string]interface{}{
+		"status":      "cleaned",
+		"project":     filepath.Base(projectPath),
+		"type":        projectType,
+		"removed":     paths,
+		"freed_bytes": freed,
+	}
+	if projectType == "go" {
+		if _, err := exec.LookPath("go"); err == nil {
+			cmd := exec.CommandContext(ctx, "go", "clean")
+			cmd.Dir = projectPath
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				return nil, fmt.Errorf("go clean: %s: %s", err, string(out))
+			}
+		}
+	}
+
+	return json.Marshal(result)
+}
+
+// diskUsage returns the total size of the files under path without following symlinks
+func diskUsage(path string) int64 {
+	var total int64
+	filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
 type StructureInput struct {
-	Path  string `json:"path"`
-	Depth int    `json:"depth"`
+	Path             string   `json:"path"`
+	Depth            int      `json:"depth"`
+	Ignore           []string `json:"ignore"`             // replaces defaultStructureIgnore
+	IncludeFilesGlob string   `json:"include_files_glob"` // e.g. *.go
 }
 
+// defaultStructureIgnore lists dependency and build output directories left out of
+// project structure along with anything the project's .gitignore excludes
+var defaultStructureIgnore = []string{"node_modules", "vendor", "target", "dist", ".git"}
+
 func (w *ProjectWorker) structure(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req StructureInput
 	json.Unmarshal(input, &req)
 
-	projectPath := req.Path
-	if projectPath == "" {
-		projectPath = w.basePath
+	projectPath, err := w.sandboxPath(req.Path)
+	if err != nil {
+		return nil, err
 	}
 
 	depth := req.Depth
@@ -350,7 +733,23 @@ func (w *ProjectWorker) structure(ctx context.Context, input json.RawMessage) ([
 		depth = 3
 	}
 
-	tree, err := getDirTree(projectPath, depth, 0)
+	if req.IncludeFilesGlob != "" {
+		if _, err := filepath.Match(req.IncludeFilesGlob, ""); err != nil {
+			return nil, fmt.Errorf("invalid include_files_glob: %w", err)
+		}
+	}
+
+	patterns := req.Ignore
+	if patterns == nil {
+		patterns = defaultStructureIgnore
+	}
+	walker := &treeWalker{
+		root:   projectPath,
+		ignore: append(parseIgnorePatterns(patterns), readGitignore(projectPath)...),
+		glob:   req.IncludeFilesGlob,
+	}
+
+	tree, err := walker.walk(projectPath, depth, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -358,6 +757,7 @@ func (w *ProjectWorker) structure(ctx context.Context, input json.RawMessage) ([
 	return json.Marshal(map[string]interface{}{
 		"project": filepath.Base(projectPath),
 		"tree":    tree,
+		"omitted": walker.omitted,
 	})
 }
 
@@ -383,13 +783,21 @@ func detectProjectType(path string) string {
 	return "unknown"
 }
 
-func copyDir(src, dst string) error {
+// copyTemplate copies a template directory to dst, substituting {{var}} in file
+// contents as well as file and directory names
+func copyTemplate(src, dst string, vars map[string]string) error {
 	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 		rel, _ := filepath.Rel(src, path)
-		dest := filepath.Join(dst, rel)
+		if rel == templateManifestFile {
+			return nil
+		}
+		dest := filepath.Join(dst, applyVars(rel, vars))
+		if dest != filepath.Clean(dst) && !strings.HasPrefix(dest, filepath.Clean(dst)+string(filepath.Separator)) {
+			return fmt.Errorf("template path escapes project: %s", rel)
+		}
 
 		if info.IsDir() {
 			return os.MkdirAll(dest, info.Mode())
@@ -398,26 +806,15 @@ func copyDir(src, dst string) error {
 		if err != nil {
 			return err
 		}
-		return os.WriteFile(dest, data, info.Mode())
+		return os.WriteFile(dest, []byte(applyVars(string(data), vars)), info.Mode())
 	})
 }
 
-func applyVars(path string, vars map[string]string) {
-	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
-			return err
-		}
-		data, err := os.ReadFile(p)
-		if err != nil {
-			return nil
-		}
-		content := string(data)
-		for k, v := range vars {
-			content = strings.ReplaceAll(content, "{{"+k+"}}", v)
-		}
-		os.WriteFile(p, []byte(content), info.Mode())
-		return nil
-	})
+func applyVars(s string, vars map[string]string) string {
+	for k, v := range vars {
+		s = strings.ReplaceAll(s, "{{"+k+"}}", v)
+	}
+	return s
 }
 
 type TreeNode struct {
@@ -426,7 +823,73 @@ type TreeNode struct {
 	Children []TreeNode `json:"children,omitempty"`
 }
 
-func getDirTree(path string, maxDepth, currentDepth int) ([]TreeNode, error) {
+// ignoreRule is one .gitignore-style pattern
+type ignoreRule struct {
+	pattern  string
+	dirOnly  bool // trailing slash: matches directories only
+	anchored bool // contains a slash: matches the path from the root, not just the name
+}
+
+func parseIgnorePatterns(lines []string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		// Negations would need ordered evaluation; they are rare enough to skip
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		rule.pattern = strings.TrimPrefix(line, "**/")
+		if rule.pattern != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// readGitignore returns the rules in the .gitignore at the project root, if any
+func readGitignore(root string) []ignoreRule {
+	data, err := os.ReadFile(filepath.Join(root, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	return parseIgnorePatterns(strings.Split(string(data), "\n"))
+}
+
+// treeWalker builds a project tree, leaving out ignored entries and, with a glob,
+// files that don't match it
+type treeWalker struct {
+	root    string
+	ignore  []ignoreRule
+	glob    string
+	omitted int
+}
+
+func (t *treeWalker) ignored(rel, name string, isDir bool) bool {
+	for _, r := range t.ignore {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		target := name
+		if r.anchored {
+			target = filepath.ToSlash(rel)
+		}
+		if ok, _ := filepath.Match(r.pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *treeWalker) walk(path string, maxDepth, currentDepth int) ([]TreeNode, error) {
 	if currentDepth >= maxDepth {
 		return nil, nil
 	}
@@ -443,20 +906,33 @@ func getDirTree(path string, maxDepth, currentDepth int) ([]TreeNode, error) {
 			continue
 		}
 
+		full := filepath.Join(path, name)
+		rel, _ := filepath.Rel(t.root, full)
+		if t.ignored(rel, name, e.IsDir()) {
+			t.omitted++
+			continue
+		}
+
 		node := TreeNode{
 			Name: name,
 			Type: "file",
 		}
 		if e.IsDir() {
 			node.Type = "dir"
-			children, _ := getDirTree(filepath.Join(path, name), maxDepth, currentDepth+1)
+			children, _ := t.walk(full, maxDepth, currentDepth+1)
+			// With a glob, drop directories that turned out to hold no matching files
+			if t.glob != "" && len(children) == 0 && currentDepth+1 < maxDepth {
+				t.omitted++
+				continue
+			}
 			node.Children = children
+		} else if t.glob != "" {
+			if ok, _ := filepath.Match(t.glob, name); !ok {
+				t.omitted++
+				continue
+			}
 		}
 		nodes = append(nodes, node)
 	}
 	return nodes, nil
 }
-
-func init() {
-	_ = time.Time{}
-}
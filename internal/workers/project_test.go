@@ -0,0 +1,377 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestFiles creates files under root from a map of relative path to content
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func newTestProjectWorker(t *testing.T) (*ProjectWorker, string) {
+	t.Helper()
+	base := t.TempDir()
+	writeTestFiles(t, filepath.Join(base, "templates", "gosvc"), map[string]string{
+		"template.json": `{
+			"description": "Go service",
+			"variables": [
+				{"name": "name", "description": "Package name", "required": true},
+				{"name": "module", "description": "Module path", "required": true},
+				{"name": "port", "description": "Listen port", "default": "8080"}
+			]
+		}`,
+		"go.mod":               "module {{module}}\n",
+		"{{name}}.go":          "package {{name}}\n\nconst Port = \"{{port}}\"\n",
+		"cmd/{{name}}/main.go": "package main\n",
+	})
+	return NewProjectWorker(base, ""), base
+}
+
+func TestProjectTemplateInfo(t *testing.T) {
+	w, _ := newTestProjectWorker(t)
+
+	out, err := w.Execute(context.Background(), "project_template_info", json.RawMessage(`{"template":"gosvc"}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Description string             `json:"description"`
+		Variables   []TemplateVariable `json:"variables"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "Go service", resp.Description)
+	require.Len(t, resp.Variables, 3)
+	assert.Equal(t, "port", resp.Variables[2].Name)
+	assert.Equal(t, "8080", resp.Variables[2].Default)
+
+	_, err = w.Execute(context.Background(), "project_template_info", json.RawMessage(`{"template":"missing"}`))
+	assert.ErrorContains(t, err, "template not found")
+}
+
+func TestProjectCreate_SubstitutesNamesAndDefaults(t *testing.T) {
+	w, base := newTestProjectWorker(t)
+
+	_, err := w.Execute(context.Background(), "project_create", json.RawMessage(`{"template":"gosvc","name":"billing","vars":{"module":"example.com/billing"}}`))
+	require.NoError(t, err)
+
+	project := filepath.Join(base, "billing")
+	data, err := os.ReadFile(filepath.Join(project, "billing.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package billing\n\nconst Port = \"8080\"\n", string(data))
+
+	data, err = os.ReadFile(filepath.Join(project, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/billing\n", string(data))
+
+	assert.FileExists(t, filepath.Join(project, "cmd", "billing", "main.go"))
+	assert.NoFileExists(t, filepath.Join(project, "{{name}}.go"))
+	assert.NoFileExists(t, filepath.Join(project, "template.json"))
+}
+
+func TestProjectCreate_MissingRequiredVariable(t *testing.T) {
+	w, base := newTestProjectWorker(t)
+
+	_, err := w.Execute(context.Background(), "project_create", json.RawMessage(`{"template":"gosvc","name":"billing"}`))
+	assert.ErrorContains(t, err, "missing required template variables: module")
+	assert.NoDirExists(t, filepath.Join(base, "billing"))
+}
+
+func TestProjectTest_FailingSuiteIsAResult(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"go.mod":      "module example.com/fixture\n\ngo 1.21\n",
+		"add.go":      "package fixture\n\nfunc Add(a, b int) int { return a - b }\n",
+		"add_test.go": "package fixture\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif Add(2, 2) != 4 {\n\t\tt.Fatal(\"Add(2, 2) is not 4\")\n\t}\n}\n",
+	})
+	w := NewProjectWorker(dir, "")
+
+	input, _ := json.Marshal(map[string]string{"path": dir})
+	out, err := w.Execute(context.Background(), "project_test", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		Status     string `json:"status"`
+		ExitCode   int    `json:"exit_code"`
+		Stdout     string `json:"stdout"`
+		DurationMS *int64 `json:"duration_ms"`
+		Type       string `json:"type"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "failed", resp.Status)
+	assert.NotZero(t, resp.ExitCode)
+	assert.Contains(t, resp.Stdout, "Add(2, 2) is not 4")
+	assert.NotNil(t, resp.DurationMS)
+	assert.Equal(t, "go", resp.Type)
+
+	// Fixing the code turns the same call into a pass
+	writeTestFiles(t, dir, map[string]string{"add.go": "package fixture\n\nfunc Add(a, b int) int { return a + b }\n"})
+	out, err = w.Execute(context.Background(), "project_test", input)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "passed", resp.Status)
+	assert.Zero(t, resp.ExitCode)
+}
+
+func TestProjectLint_ReportsGoVetFindings(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"go.mod":  "module example.com/fixture\n\ngo 1.21\n",
+		"main.go": "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Printf(\"%d\\n\", \"three\")\n}\n",
+	})
+	w := NewProjectWorker(dir, "")
+	// Pin go vet so the result doesn't depend on golangci-lint being installed
+	w.SetFrameworks(map[string]interface{}{
+		"go":   map[string]interface{}{"lint": "go vet ./..."},
+		"node": "package.json",
+	})
+
+	input, _ := json.Marshal(map[string]string{"path": dir})
+	out, err := w.Execute(context.Background(), "project_lint", input)
+	require.NoError(t, err)
+
+	var resp struct {
+		Status   string        `json:"status"`
+		Linter   string        `json:"linter"`
+		Findings []LintFinding `json:"findings"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "failed", resp.Status)
+	assert.Equal(t, "go vet ./...", resp.Linter)
+	require.Len(t, resp.Findings, 1)
+	assert.Equal(t, "main.go", filepath.Base(resp.Findings[0].File))
+	assert.Equal(t, 6, resp.Findings[0].Line)
+	assert.Contains(t, resp.Findings[0].Message, "wrong type")
+}
+
+func TestProjectLint_LinterNotInstalled(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"package.json": "{}"})
+	w := NewProjectWorker(dir, "")
+	w.SetFrameworks(map[string]interface{}{"node": map[string]interface{}{"lint": "definitely-not-a-linter ."}})
+
+	input, _ := json.Marshal(map[string]string{"path": dir})
+	out, err := w.Execute(context.Background(), "project_lint", input)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"status":"not_installed"`)
+	assert.Contains(t, string(out), "linter not installed: definitely-not-a-linter")
+}
+
+func TestProjectSandboxPath(t *testing.T) {
+	base := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "app", "src"), 0755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(base, "link")))
+	w := NewProjectWorker(base, "")
+
+	resolvedBase, err := filepath.EvalSymlinks(base)
+	require.NoError(t, err)
+
+	for _, p := range []string{"app", "app/src", filepath.Join(base, "app"), "new-project", ""} {
+		got, err := w.sandboxPath(p)
+		require.NoError(t, err, p)
+		rel, err := filepath.Rel(resolvedBase, got)
+		require.NoError(t, err)
+		assert.False(t, strings.HasPrefix(rel, ".."), p)
+	}
+	got, err := w.sandboxPath("app/../app/src")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(resolvedBase, "app", "src"), got)
+
+	for _, p := range []string{"../../etc", "/etc", "app/../../x", "link", "link/new"} {
+		_, err := w.sandboxPath(p)
+		assert.ErrorContains(t, err, "outside project base path", p)
+	}
+}
+
+func TestProjectTools_RejectPathsOutsideBase(t *testing.T) {
+	w, _ := newTestProjectWorker(t)
+	ctx := context.Background()
+
+	for _, tool := range []string{"project_info", "project_build", "project_test", "project_lint", "project_deps", "project_structure"} {
+		_, err := w.Execute(ctx, tool, json.RawMessage(`{"path":"../../etc"}`))
+		assert.ErrorContains(t, err, "outside project base path", tool)
+	}
+
+	_, err := w.Execute(ctx, "project_create", json.RawMessage(`{"template":"gosvc","name":"../escaped","vars":{"module":"m"}}`))
+	assert.ErrorContains(t, err, "outside project base path")
+	_, err = w.Execute(ctx, "project_create", json.RawMessage(`{"template":"../templates/gosvc","name":"app"}`))
+	assert.ErrorContains(t, err, "template not found")
+	_, err = w.Execute(ctx, "project_template_info", json.RawMessage(`{"template":".."}`))
+	assert.ErrorContains(t, err, "template not found")
+
+	out, err := w.Execute(ctx, "project_info", json.RawMessage(`{"path":"templates"}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"name":"templates"`)
+}
+
+func projectStructure(t *testing.T, w *ProjectWorker, input string) ([]TreeNode, int) {
+	t.Helper()
+	out, err := w.Execute(context.Background(), "project_structure", json.RawMessage(input))
+	require.NoError(t, err)
+	var resp struct {
+		Tree    []TreeNode `json:"tree"`
+		Omitted int        `json:"omitted"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	return resp.Tree, resp.Omitted
+}
+
+func treeNames(nodes []TreeNode, prefix string) []string {
+	var names []string
+	for _, n := range nodes {
+		names = append(names, prefix+n.Name)
+		names = append(names, treeNames(n.Children, prefix+n.Name+"/")...)
+	}
+	return names
+}
+
+func TestProjectStructure_OmitsIgnoredPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		".gitignore":                 "# build output\n/bin/\n*.log\n",
+		"main.go":                    "package main\n",
+		"README.md":                  "# app\n",
+		"debug.log":                  "noise\n",
+		"bin/app":                    "binary",
+		"internal/util/util.go":      "package util\n",
+		"internal/util/notes.txt":    "todo\n",
+		"docs/guide.md":              "guide\n",
+		"node_modules/left-pad/a.js": "module.exports = 1\n",
+	})
+	w := NewProjectWorker(dir, "")
+
+	tree, omitted := projectStructure(t, w, `{}`)
+	assert.ElementsMatch(t, []string{
+		"README.md", "main.go", "docs", "docs/guide.md",
+		"internal", "internal/util", "internal/util/notes.txt", "internal/util/util.go",
+	}, treeNames(tree, ""))
+	assert.Equal(t, 3, omitted) // node_modules, bin, debug.log
+
+	tree, omitted = projectStructure(t, w, `{"include_files_glob":"*.go"}`)
+	assert.ElementsMatch(t, []string{"main.go", "internal", "internal/util", "internal/util/util.go"}, treeNames(tree, ""))
+	assert.Equal(t, 7, omitted) // plus README.md, notes.txt, docs/guide.md, and docs
+
+	// An explicit ignore list replaces the defaults; .gitignore still applies
+	tree, _ = projectStructure(t, w, `{"ignore":["docs"],"depth":1}`)
+	assert.ElementsMatch(t, []string{"README.md", "main.go", "internal", "node_modules"}, treeNames(tree, ""))
+}
+
+func TestProjectClean_RequiresConfirm(t *testing.T) {
+	base := t.TempDir()
+	outside := t.TempDir()
+	writeTestFiles(t, outside, map[string]string{"keep.txt": "not part of the project"})
+	dir := filepath.Join(base, "web")
+	writeTestFiles(t, dir, map[string]string{
+		"package.json":                   "{}",
+		"src/index.js":                   "console.log(1)\n",
+		"dist/bundle.js":                 "0123456789",
+		"node_modules/.cache/babel/x":    "12345",
+		"node_modules/left-pad/index.js": "module.exports = 1\n",
+	})
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "dist", "external")))
+	w := NewProjectWorker(base, "")
+
+	out, err := w.Execute(context.Background(), "project_clean", json.RawMessage(`{"path":"web"}`))
+	require.NoError(t, err)
+	var preview struct {
+		Status      string   `json:"status"`
+		WouldRemove []string `json:"would_remove"`
+		Bytes       int64    `json:"bytes"`
+	}
+	require.NoError(t, json.Unmarshal(out, &preview))
+	assert.Equal(t, "preview", preview.Status)
+	assert.ElementsMatch(t, []string{"node_modules/.cache", "dist"}, preview.WouldRemove)
+	assert.Equal(t, int64(15), preview.Bytes)
+	assert.DirExists(t, filepath.Join(dir, "dist"))
+
+	out, err = w.Execute(context.Background(), "project_clean", json.RawMessage(`{"path":"web","confirm":true}`))
+	require.NoError(t, err)
+	var cleaned struct {
+		Status     string   `json:"status"`
+		Removed    []string `json:"removed"`
+		FreedBytes int64    `json:"freed_bytes"`
+	}
+	require.NoError(t, json.Unmarshal(out, &cleaned))
+	assert.Equal(t, "cleaned", cleaned.Status)
+	assert.Len(t, cleaned.Removed, 2)
+	assert.Equal(t, int64(15), cleaned.FreedBytes)
+
+	assert.NoDirExists(t, filepath.Join(dir, "dist"))
+	assert.NoDirExists(t, filepath.Join(dir, "node_modules", ".cache"))
+	assert.FileExists(t, filepath.Join(dir, "node_modules", "left-pad", "index.js"))
+	assert.FileExists(t, filepath.Join(dir, "src", "index.js"))
+	assert.FileExists(t, filepath.Join(outside, "keep.txt"))
+
+	_, err = w.Execute(context.Background(), "project_clean", json.RawMessage(`{"path":"../","confirm":true}`))
+	assert.ErrorContains(t, err, "outside project base path")
+}
+
+func TestProjectClean_SkipsTargetsOutsideProject(t *testing.T) {
+	base := t.TempDir()
+	outside := t.TempDir()
+	writeTestFiles(t, outside, map[string]string{".cache/babel/x": "12345"})
+	dir := filepath.Join(base, "web")
+	writeTestFiles(t, dir, map[string]string{"package.json": "{}", "dist/bundle.js": "0123456789"})
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "node_modules")))
+	w := NewProjectWorker(base, "")
+
+	out, err := w.Execute(context.Background(), "project_clean", json.RawMessage(`{"path":"web","confirm":true}`))
+	require.NoError(t, err)
+	var cleaned struct {
+		Removed []string `json:"removed"`
+	}
+	require.NoError(t, json.Unmarshal(out, &cleaned))
+	assert.Equal(t, []string{"dist"}, cleaned.Removed)
+	assert.FileExists(t, filepath.Join(outside, ".cache", "babel", "x"))
+}
+
+func TestProjectClean_GoKeepsBin(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"go.mod": "module example.com/x\n", "bin/tool.sh": "#!/bin/sh\n"})
+	w := NewProjectWorker(dir, "")
+
+	out, err := w.Execute(context.Background(), "project_clean", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"would_remove":[]`)
+	assert.FileExists(t, filepath.Join(dir, "bin", "tool.sh"))
+}
+
+func TestProjectClean_PythonCaches(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"requirements.txt":                 "",
+		"app/__init__.py":                  "",
+		"app/__pycache__/__init__.pyc":     "cc",
+		"tests/__pycache__/test_x.pyc":     "ccc",
+		"node_modules/x/__pycache__/y.pyc": "c",
+	})
+	w := NewProjectWorker(dir, "")
+
+	out, err := w.Execute(context.Background(), "project_clean", json.RawMessage(`{"confirm":true}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"freed_bytes":5`)
+	assert.NoDirExists(t, filepath.Join(dir, "app", "__pycache__"))
+	assert.NoDirExists(t, filepath.Join(dir, "tests", "__pycache__"))
+	assert.FileExists(t, filepath.Join(dir, "app", "__init__.py"))
+	assert.DirExists(t, filepath.Join(dir, "node_modules", "x", "__pycache__"))
+}
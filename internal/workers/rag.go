@@ -2,21 +2,28 @@ package workers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // RAG Worker State
 type RAGWorkerState struct {
 	Tools        []ToolDef
+	mu           sync.RWMutex // guards Documents; tools run concurrently
 	Documents    map[string]Document
 	ChunkSize    int
 	ChunkOverlap int
+	Collection   string // vector store collection holding the chunks
 	VectorStore  VectorStore
 	Embedder     Embedder
+	LLMCaller    LLMCaller // answers rag_ask when set
 }
 
 type VectorStore interface {
@@ -46,6 +53,18 @@ type Document struct {
 	IndexedAt time.Time       `json:"indexed_at"`
 }
 
+// ragHit is one search result, a chunk from the vector store or a document preview
+// from keyword search
+type ragHit struct {
+	ChunkID     string   `json:"chunk_id,omitempty"`
+	DocumentID  string   `json:"document_id"`
+	Content     string   `json:"content"`
+	Score       float32  `json:"score"`
+	RerankScore *float32 `json:"rerank_score,omitempty"`
+	Title       string   `json:"title"`
+	Source      string   `json:"source,omitempty"`
+}
+
 type DocumentChunk struct {
 	ChunkID    string `json:"chunk_id"`
 	DocumentID string `json:"document_id"`
@@ -84,6 +103,7 @@ func NewRAGWorkerState(cfg RAGConfig) *RAGWorkerState {
 		Documents:    make(map[string]Document),
 		ChunkSize:    cfg.ChunkSize,
 		ChunkOverlap: cfg.ChunkOverlap,
+		Collection:   cfg.Collection,
 	}
 }
 
@@ -120,7 +140,13 @@ func (w *RAGWorkerState) SetVectorStore(v VectorStore) {
 	w.VectorStore = v
 }
 
-// ingest handles document ingestion
+// SetLLMCaller sets the LLM used to answer rag_ask questions
+func (w *RAGWorkerState) SetLLMCaller(caller LLMCaller) {
+	w.LLMCaller = caller
+}
+
+// ingest handles document ingestion. Documents with a source are keyed by it, so
+// re-ingesting a source replaces the earlier version and its vectors.
 func (w *RAGWorkerState) ingest(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
 		Source   string         `json:"source"`
@@ -145,10 +171,14 @@ func (w *RAGWorkerState) ingest(ctx context.Context, input json.RawMessage) ([]b
 	}
 
 	// Generate document ID
-	docID := generateDocID(req.Source + req.Title + time.Now().Format(time.RFC3339))
-
+	var docID string
+	if req.Source != "" {
+		docID = generateDocID("doc", "source:"+req.Source)
+	} else {
+		docID = generateDocID("doc", "content:"+req.Title+"\n"+req.Content)
+	}
 	// Chunk the content
-	chunks := w.chunkText(req.Content)
+	chunks := w.chunkText(docID, req.Content)
 
 	// Create document
 	doc := Document{
@@ -162,14 +192,27 @@ func (w *RAGWorkerState) ingest(ctx context.Context, input json.RawMessage) ([]b
 		IndexedAt: time.Now(),
 	}
 
-	// Update chunk document IDs
-	for i := range chunks {
-		chunks[i].DocumentID = docID
-	}
-	doc.Chunks = chunks
-
-	// Store document
+	// Store document, keeping whatever version it replaces
+	w.mu.Lock()
+	previous, exists := w.Documents[docID]
 	w.Documents[docID] = doc
+	w.mu.Unlock()
+
+	// Drop vectors for chunks the new version no longer has
+	if exists && w.VectorStore != nil {
+		current := make(map[string]bool, len(chunks))
+		for _, chunk := range chunks {
+			current[chunk.ChunkID] = true
+		}
+		for _, chunk := range previous.Chunks {
+			if current[chunk.ChunkID] {
+				continue
+			}
+			if err := w.VectorStore.Delete(w.Collection, chunk.ChunkID); err != nil {
+				fmt.Printf("Warning: failed to delete vector: %v\n", err)
+			}
+		}
+	}
 
 	// Generate embeddings and store in vector DB if available
 	if w.Embedder != nil && w.VectorStore != nil {
@@ -191,25 +234,32 @@ func (w *RAGWorkerState) ingest(ctx context.Context, input json.RawMessage) ([]b
 					"title":       doc.Title,
 					"source":      doc.Source,
 				}
-				if err := w.VectorStore.Upsert("rag", chunk.ChunkID, embeddings[i], metadata); err != nil {
+				if err := w.VectorStore.Upsert(w.Collection, chunk.ChunkID, embeddings[i], metadata); err != nil {
 					fmt.Printf("Warning: failed to store vector: %v\n", err)
 				}
 			}
 		}
 	}
 
+	action := "inserted"
+	if exists {
+		action = "updated"
+	}
 	return json.Marshal(map[string]any{
 		"document_id": docID,
 		"chunk_count": len(chunks),
 		"indexed":     w.VectorStore != nil,
+		"action":      action,
 	})
 }
 
-// search performs semantic search
+// search performs semantic search. With rerank, it retrieves three times as many
+// candidates and re-scores them against the query before trimming to top_k.
 func (w *RAGWorkerState) search(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
-		Query string `json:"query"`
-		TopK  int    `json:"top_k"`
+		Query  string `json:"query"`
+		TopK   int    `json:"top_k"`
+		Rerank bool   `json:"rerank"`
 	}
 
 	if err := json.Unmarshal(input, &req); err != nil {
@@ -224,51 +274,153 @@ func (w *RAGWorkerState) search(ctx context.Context, input json.RawMessage) ([]b
 		req.TopK = 5
 	}
 
-	// If no vector store, fall back to keyword search
+	candidates := req.TopK
+	if req.Rerank {
+		candidates = req.TopK * 3
+	}
+
+	var hits []ragHit
 	if w.VectorStore == nil || w.Embedder == nil {
-		return w.keywordSearch(req.Query, req.TopK)
+		// If no vector store, fall back to keyword search
+		hits = w.keywordSearch(req.Query, candidates)
+	} else {
+		// Generate embedding for query
+		embeddings, err := w.Embedder.Embed(ctx, []string{req.Query})
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+
+		// Search vector store
+		results, err := w.VectorStore.Search(w.Collection, embeddings[0], candidates)
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
+		}
+
+		for _, r := range results {
+			docID, _ := r.Metadata["document_id"].(string)
+			content, _ := r.Metadata["content"].(string)
+			title, _ := r.Metadata["title"].(string)
+			source, _ := r.Metadata["source"].(string)
+
+			hits = append(hits, ragHit{
+				ChunkID:    r.ID,
+				DocumentID: docID,
+				Content:    content,
+				Score:      r.Score,
+				Title:      title,
+				Source:     source,
+			})
+		}
 	}
 
-	// Generate embedding for query
-	embeddings, err := w.Embedder.Embed(ctx, []string{req.Query})
-	if err != nil {
-		return nil, fmt.Errorf("failed to embed query: %w", err)
+	if req.Rerank {
+		w.rerank(ctx, req.Query, hits)
+	}
+	if len(hits) > req.TopK {
+		hits = hits[:req.TopK]
+	}
+
+	return json.Marshal(hits)
+}
+
+// rerank re-scores hits by relevance to query, 0-1, and sorts them best first. The
+// LLM judges relevance when one is configured; otherwise, or if it fails, hits are
+// scored by the share of query terms they contain.
+func (w *RAGWorkerState) rerank(ctx context.Context, query string, hits []ragHit) {
+	if len(hits) == 0 {
+		return
+	}
+
+	var scores []float32
+	if w.LLMCaller != nil {
+		scores = w.rerankLLM(ctx, query, hits)
+	}
+	if scores == nil {
+		scores = make([]float32, len(hits))
+		for i, h := range hits {
+			scores[i] = lexicalOverlap(query, h.Title+" "+h.Content)
+		}
+	}
+
+	for i := range hits {
+		score := scores[i]
+		hits[i].RerankScore = &score
+	}
+	// Stable, so equally relevant hits keep their retrieval order
+	sort.SliceStable(hits, func(i, j int) bool { return *hits[i].RerankScore > *hits[j].RerankScore })
+}
+
+// rerankLLM asks the LLM to score each hit. Any failure, including malformed JSON or
+// the wrong number of scores, is logged and yields nil.
+func (w *RAGWorkerState) rerankLLM(ctx context.Context, query string, hits []ragHit) []float32 {
+	var passages strings.Builder
+	for i, h := range hits {
+		fmt.Fprintf(&passages, "[%d] %s\n\n", i+1, truncateUTF8(h.Content, 1000))
 	}
+	prompt := fmt.Sprintf(`Rate how relevant each passage is to the query, from 0 (unrelated) to 1 (directly answers it).
+Respond with only a JSON array of %d numbers, one per passage, in order.
 
-	// Search vector store
-	results, err := w.VectorStore.Search("rag", embeddings[0], req.TopK)
+Query: %s
+
+Passages:
+%s`, len(hits), query, passages.String())
+
+	resp, err := w.LLMCaller.Call(ctx, prompt, "You are a search relevance judge. Output valid JSON only.")
 	if err != nil {
-		return nil, fmt.Errorf("search failed: %w", err)
+		fmt.Printf("Warning: LLM rerank failed: %v\n", err)
+		return nil
 	}
 
-	// Format results
-	type SearchResult struct {
-		ChunkID    string  `json:"chunk_id"`
-		DocumentID string  `json:"document_id"`
-		Content    string  `json:"content"`
-		Score      float32 `json:"score"`
-		Title      string  `json:"title"`
-		Source     string  `json:"source"`
-	}
-
-	var formattedResults []SearchResult
-	for _, r := range results {
-		docID, _ := r.Metadata["document_id"].(string)
-		content, _ := r.Metadata["content"].(string)
-		title, _ := r.Metadata["title"].(string)
-		source, _ := r.Metadata["source"].(string)
-
-		formattedResults = append(formattedResults, SearchResult{
-			ChunkID:    r.ID,
-			DocumentID: docID,
-			Content:    content,
-			Score:      r.Score,
-			Title:      title,
-			Source:     source,
-		})
+	// Models often wrap the array in prose or code fences; keep only the outermost brackets
+	start, end := strings.Index(resp, "["), strings.LastIndex(resp, "]")
+	if start < 0 || end <= start {
+		fmt.Printf("Warning: LLM rerank returned no JSON array\n")
+		return nil
+	}
+
+	var scores []float32
+	if err := json.Unmarshal([]byte(resp[start:end+1]), &scores); err != nil {
+		fmt.Printf("Warning: LLM rerank returned malformed JSON: %v\n", err)
+		return nil
+	}
+	if len(scores) != len(hits) {
+		fmt.Printf("Warning: LLM rerank returned %d scores for %d passages\n", len(scores), len(hits))
+		return nil
 	}
+	for i, s := range scores {
+		if s < 0 {
+			scores[i] = 0
+		} else if s > 1 {
+			scores[i] = 1
+		}
+	}
+	return scores
+}
 
-	return json.Marshal(formattedResults)
+// lexicalOverlap returns the fraction of distinct query terms that appear in text
+func lexicalOverlap(query, text string) float32 {
+	terms := map[string]bool{}
+	for _, t := range strings.Fields(strings.ToLower(query)) {
+		terms[t] = true
+	}
+	if len(terms) == 0 {
+		return 0
+	}
+
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		words[word] = true
+	}
+
+	matched := 0
+	for t := range terms {
+		if words[strings.Trim(t, ".,;:!?\"'()")] {
+			matched++
+		}
+	}
+	return float32(matched) / float32(len(terms))
 }
 
 // ask performs RAG Q&A
@@ -277,6 +429,7 @@ func (w *RAGWorkerState) ask(ctx context.Context, input json.RawMessage) ([]byte
 		Query  string `json:"query"`
 		TopK   int    `json:"top_k"`
 		Prompt string `json:"prompt"`
+		Rerank bool   `json:"rerank"`
 	}
 
 	if err := json.Unmarshal(input, &req); err != nil {
@@ -291,15 +444,16 @@ func (w *RAGWorkerState) ask(ctx context.Context, input json.RawMessage) ([]byte
 		req.TopK = 5
 	}
 
-	// Default prompt template
+	// Default prompt template, filled with the context and then the question
 	if req.Prompt == "" {
 		req.Prompt = "Based on the following context, answer the question.\n\nContext:\n%s\n\nQuestion: %s\n\nAnswer:"
 	}
 
 	// Search for relevant context
 	searchInput, _ := json.Marshal(map[string]any{
-		"query": req.Query,
-		"top_k": req.TopK,
+		"query":  req.Query,
+		"top_k":  req.TopK,
+		"rerank": req.Rerank,
 	})
 	searchResults, err := w.search(ctx, searchInput)
 	if err != nil {
@@ -322,14 +476,29 @@ func (w *RAGWorkerState) ask(ctx context.Context, input json.RawMessage) ([]byte
 		contextBuilder.WriteString(fmt.Sprintf("[%s]\n%s", r.Title, r.Content))
 	}
 
-	// For now, return the context - actual LLM call would happen in orchestrator
-	// This allows the RAG worker to be used with any LLM provider
+	// Without an LLM, return the context so the caller can answer with its own model
 	response := map[string]any{
-		"answer":    "", // Would be filled by LLM
+		"answer":    "",
 		"context":   contextBuilder.String(),
 		"sources":   results,
 		"processed": true,
 	}
+	if w.LLMCaller == nil {
+		return json.Marshal(response)
+	}
+
+	var prompt string
+	if strings.Count(req.Prompt, "%s") == 2 {
+		prompt = fmt.Sprintf(req.Prompt, contextBuilder.String(), req.Query)
+	} else {
+		// A custom prompt without placeholders still needs the context and question
+		prompt = fmt.Sprintf("%s\n\nContext:\n%s\n\nQuestion: %s\n\nAnswer:", req.Prompt, contextBuilder.String(), req.Query)
+	}
+	answer, err := w.LLMCaller.Call(ctx, prompt, "You answer questions using only the provided context. If the context does not contain the answer, say so.")
+	if err != nil {
+		return nil, fmt.Errorf("LLM call failed: %w", err)
+	}
+	response["answer"] = strings.TrimSpace(answer)
 
 	return json.Marshal(response)
 }
@@ -346,6 +515,9 @@ func (w *RAGWorkerState) list(ctx context.Context, input json.RawMessage) ([]byt
 		req.Limit = 50
 	}
 
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
 	docs := make([]map[string]any, 0)
 	i := 0
 	skipped := 0
@@ -385,7 +557,11 @@ func (w *RAGWorkerState) delete(ctx context.Context, input json.RawMessage) ([]b
 		return nil, fmt.Errorf("document_id required")
 	}
 
+	// Delete from documents
+	w.mu.Lock()
 	doc, exists := w.Documents[req.DocumentID]
+	delete(w.Documents, req.DocumentID)
+	w.mu.Unlock()
 	if !exists {
 		return nil, fmt.Errorf("document not found: %s", req.DocumentID)
 	}
@@ -393,15 +569,12 @@ func (w *RAGWorkerState) delete(ctx context.Context, input json.RawMessage) ([]b
 	// Delete from vector store
 	if w.VectorStore != nil {
 		for _, chunk := range doc.Chunks {
-			if err := w.VectorStore.Delete("rag", chunk.ChunkID); err != nil {
+			if err := w.VectorStore.Delete(w.Collection, chunk.ChunkID); err != nil {
 				fmt.Printf("Warning: failed to delete vector: %v\n", err)
 			}
 		}
 	}
 
-	// Delete from documents
-	delete(w.Documents, req.DocumentID)
-
 	return json.Marshal(map[string]any{
 		"deleted":     true,
 		"document_id": req.DocumentID,
@@ -410,6 +583,9 @@ func (w *RAGWorkerState) delete(ctx context.Context, input json.RawMessage) ([]b
 
 // stats returns index statistics
 func (w *RAGWorkerState) stats(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
 	totalDocs := len(w.Documents)
 	totalChunks := 0
 	for _, doc := range w.Documents {
@@ -431,8 +607,10 @@ func (w *RAGWorkerState) stats(ctx context.Context, input json.RawMessage) ([]by
 	})
 }
 
-// chunkText splits content into chunks using recursive character splitting
-func (w *RAGWorkerState) chunkText(content string) []DocumentChunk {
+// chunkText splits content into chunks using recursive character splitting. Each
+// chunk's content is content[StartChar:EndChar], so offsets map back to the source
+// text even where chunks overlap.
+func (w *RAGWorkerState) chunkText(docID, content string) []DocumentChunk {
 	if content == "" {
 		return nil
 	}
@@ -441,58 +619,51 @@ func (w *RAGWorkerState) chunkText(content string) []DocumentChunk {
 	contentLength := len(content)
 
 	// Split by paragraphs first (preserves logical units)
-	paragraphs := strings.Split(content, "\n\n")
+	paragraphs := paragraphSpans(content)
 
-	var currentChunk strings.Builder
-	currentStart := 0
-	chunkIndex := 0
+	chunkStart, chunkEnd := -1, 0
+	emit := func() {
+		chunkContent := content[chunkStart:chunkEnd]
+		chunks = append(chunks, DocumentChunk{
+			ChunkID:    chunkID(docID, chunkStart, chunkContent),
+			DocumentID: docID,
+			Content:    chunkContent,
+			StartChar:  chunkStart,
+			EndChar:    chunkEnd,
+			Index:      len(chunks),
+		})
+	}
 
 	for _, para := range paragraphs {
-		para = strings.TrimSpace(para)
-		if para == "" {
-			continue
-		}
-
 		// If adding this paragraph exceeds chunk size, save current chunk
-		if currentChunk.Len()+len(para)+2 > w.ChunkSize && currentChunk.Len() > 0 {
-			chunkContent := currentChunk.String()
-			chunks = append(chunks, DocumentChunk{
-				ChunkID:   generateDocID(chunkContent),
-				Content:   chunkContent,
-				StartChar: currentStart,
-				EndChar:   currentStart + len(chunkContent),
-				Index:     chunkIndex,
-			})
-			chunkIndex++
-
-			// Handle overlap
-			overlapStart := len(chunkContent) - w.ChunkOverlap
-			if overlapStart > 0 {
-				currentChunk.Reset()
-				currentChunk.WriteString(chunkContent[overlapStart:])
-				currentStart = currentStart + overlapStart
+		if chunkStart >= 0 && para[1]-chunkStart > w.ChunkSize {
+			emit()
+
+			// Handle overlap: carry the tail of the saved chunk into the next one
+			overlapStart := chunkEnd - w.ChunkOverlap
+			if w.ChunkOverlap > 0 && overlapStart > chunkStart {
+				// The overlap is measured in bytes, so step off any partial rune first
+				for overlapStart < chunkEnd && !utf8.RuneStart(content[overlapStart]) {
+					overlapStart++
+				}
+				for overlapStart < chunkEnd && isSpaceByte(content[overlapStart]) {
+					overlapStart++
+				}
+				chunkStart = overlapStart
 			} else {
-				currentChunk.Reset()
-				currentStart = 0
+				chunkStart = para[0]
 			}
 		}
 
-		if currentChunk.Len() > 0 {
-			currentChunk.WriteString("\n\n")
+		if chunkStart < 0 {
+			chunkStart = para[0]
 		}
-		currentChunk.WriteString(para)
+		chunkEnd = para[1]
 	}
 
 	// Add final chunk
-	if currentChunk.Len() > 0 {
-		chunkContent := currentChunk.String()
-		chunks = append(chunks, DocumentChunk{
-			ChunkID:   generateDocID(chunkContent),
-			Content:   chunkContent,
-			StartChar: currentStart,
-			EndChar:   currentStart + len(chunkContent),
-			Index:     chunkIndex,
-		})
+	if chunkStart >= 0 {
+		emit()
 	}
 
 	// Fallback: if no chunks, create single chunk
@@ -502,19 +673,46 @@ func (w *RAGWorkerState) chunkText(content string) []DocumentChunk {
 			maxChunk = contentLength
 		}
 		chunks = append(chunks, DocumentChunk{
-			ChunkID:   generateDocID(content[:maxChunk]),
-			Content:   content[:maxChunk],
-			StartChar: 0,
-			EndChar:   maxChunk,
-			Index:     0,
+			ChunkID:    chunkID(docID, 0, content[:maxChunk]),
+			DocumentID: docID,
+			Content:    content[:maxChunk],
+			StartChar:  0,
+			EndChar:    maxChunk,
+			Index:      0,
 		})
 	}
 
 	return chunks
 }
 
+// paragraphSpans returns the [start, end) byte offsets of each blank-line separated
+// paragraph in content, trimmed of surrounding whitespace
+func paragraphSpans(content string) [][2]int {
+	var spans [][2]int
+	offset := 0
+	for _, para := range strings.Split(content, "\n\n") {
+		start, end := offset, offset+len(para)
+		offset = end + 2
+
+		for start < end && isSpaceByte(content[start]) {
+			start++
+		}
+		for end > start && isSpaceByte(content[end-1]) {
+			end--
+		}
+		if start < end {
+			spans = append(spans, [2]int{start, end})
+		}
+	}
+	return spans
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
 // keywordSearch fallback when vector store unavailable
-func (w *RAGWorkerState) keywordSearch(query string, topK int) ([]byte, error) {
+func (w *RAGWorkerState) keywordSearch(query string, topK int) []ragHit {
 	queryLower := strings.ToLower(query)
 	words := strings.Fields(queryLower)
 
@@ -525,6 +723,7 @@ func (w *RAGWorkerState) keywordSearch(query string, topK int) ([]byte, error) {
 
 	var scored []scoredDoc
 
+	w.mu.RLock()
 	for _, doc := range w.Documents {
 		contentLower := strings.ToLower(doc.Content)
 		score := 0
@@ -537,6 +736,7 @@ func (w *RAGWorkerState) keywordSearch(query string, topK int) ([]byte, error) {
 			scored = append(scored, scoredDoc{doc: doc, score: score})
 		}
 	}
+	w.mu.RUnlock()
 
 	// Sort by score descending
 	for i := 0; i < len(scored)-1; i++ {
@@ -552,29 +752,23 @@ func (w *RAGWorkerState) keywordSearch(query string, topK int) ([]byte, error) {
 		scored = scored[:topK]
 	}
 
-	type Result struct {
-		DocumentID string `json:"document_id"`
-		Title      string `json:"title"`
-		Content    string `json:"content"`
-		Score      int    `json:"score"`
-	}
-
-	var results []Result
+	var results []ragHit
 	for _, s := range scored {
 		// Return first chunk as preview
 		preview := ""
 		if len(s.doc.Chunks) > 0 {
 			preview = s.doc.Chunks[0].Content
 		}
-		results = append(results, Result{
+		results = append(results, ragHit{
 			DocumentID: s.doc.ID,
 			Title:      s.doc.Title,
 			Content:    preview,
-			Score:      s.score,
+			Score:      float32(s.score),
+			Source:     s.doc.Source,
 		})
 	}
 
-	return json.Marshal(results)
+	return results
 }
 
 // detectDocType from file extension
@@ -596,16 +790,15 @@ func detectDocType(source string) string {
 	}
 }
 
-// Simple ID generator
-var docIDCounter int
+// generateDocID returns a content-addressed ID: kind, an underscore, and the first
+// 16 hex characters of the input's SHA-256, so equal inputs always share an ID
+func generateDocID(kind, input string) string {
+	sum := sha256.Sum256([]byte(input))
+	return kind + "_" + hex.EncodeToString(sum[:])[:16]
+}
 
-func generateDocID(input string) string {
-	docIDCounter++
-	// Simple hash-like ID
-	re := regexp.MustCompile(`[^a-zA-Z0-9]`)
-	cleaned := re.ReplaceAllString(input, "")
-	if len(cleaned) > 20 {
-		cleaned = cleaned[:20]
-	}
-	return fmt.Sprintf("doc_%s_%d", cleaned, docIDCounter)
+// chunkID identifies a chunk by its document, position, and content, so identical
+// text in different documents or places doesn't share a vector
+func chunkID(docID string, start int, content string) string {
+	return generateDocID("chunk", fmt.Sprintf("%s:%d:%s", docID, start, content))
 }
@@ -0,0 +1,338 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPEmbedder calls an OpenAI-compatible /v1/embeddings endpoint such as LM Studio's
+type HTTPEmbedder struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+func NewHTTPEmbedder(baseURL, model string) *HTTPEmbedder {
+	if model == "" {
+		model = "local-embedding"
+	}
+	return &HTTPEmbedder{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var result LMStudioEmbedResponse
+	if err := postJSON(ctx, e.httpClient, e.baseURL+"/v1/embeddings", LMStudioEmbedRequest{Model: e.model, Input: texts}, &result); err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	return embeddingVectors(result, len(texts))
+}
+
+// embeddingVectors converts an embeddings response for n inputs to float32 vectors in
+// input order, which the response gives by index rather than by position
+func embeddingVectors(result LMStudioEmbedResponse, n int) ([][]float32, error) {
+	if len(result.Data) != n {
+		return nil, fmt.Errorf("embedding response has %d vectors for %d inputs", len(result.Data), n)
+	}
+
+	embeddings := make([][]float32, n)
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= n {
+			return nil, fmt.Errorf("embedding response index out of range: %d", d.Index)
+		}
+		if embeddings[d.Index] != nil {
+			return nil, fmt.Errorf("embedding response repeats index %d", d.Index)
+		}
+		vector := make([]float32, len(d.Embedding))
+		for i, v := range d.Embedding {
+			vector[i] = float32(v)
+		}
+		embeddings[d.Index] = vector
+	}
+	return embeddings, nil
+}
+
+// VectorStoreConfig selects and locates a vector database
+type VectorStoreConfig struct {
+	Backend        string `json:"backend"` // chroma or qdrant
+	Endpoint       string `json:"endpoint"`
+	DistanceMetric string `json:"distance_metric,omitempty"` // cosine (default), l2, or ip
+}
+
+// NewVectorStore returns a client for the configured backend. Collections are created
+// on first write.
+func NewVectorStore(cfg VectorStoreConfig) (VectorStore, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("vector store endpoint required")
+	}
+	metric := strings.ToLower(cfg.DistanceMetric)
+	if metric == "" {
+		metric = "cosine"
+	}
+	if metric != "cosine" && metric != "l2" && metric != "ip" {
+		return nil, fmt.Errorf("unsupported distance metric: %s", cfg.DistanceMetric)
+	}
+
+	base := vectorHTTP{
+		baseURL:    strings.TrimSuffix(cfg.Endpoint, "/"),
+		metric:     metric,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	switch strings.ToLower(cfg.Backend) {
+	case "chroma":
+		return &chromaStore{vectorHTTP: base, ids: map[string]string{}}, nil
+	case "qdrant":
+		return &qdrantStore{vectorHTTP: base, created: map[string]bool{}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported vector backend: %s", cfg.Backend)
+	}
+}
+
+// NewRAGWorkerWithBackends builds a RAG worker that embeds through embedEndpoint and
+// stores vectors in the configured database instead of falling back to keyword search
+func NewRAGWorkerWithBackends(cfg RAGConfig, vector VectorStoreConfig, embedEndpoint, embedModel string) (*RAGWorkerState, error) {
+	store, err := NewVectorStore(vector)
+	if err != nil {
+		return nil, err
+	}
+	if embedEndpoint == "" {
+		return nil, fmt.Errorf("embedding endpoint required")
+	}
+
+	w := NewRAGWorkerState(cfg)
+	w.SetVectorStore(store)
+	w.SetEmbedder(NewHTTPEmbedder(embedEndpoint, embedModel))
+	return w, nil
+}
+
+// vectorHTTP holds what the HTTP vector store clients share
+type vectorHTTP struct {
+	baseURL    string
+	metric     string
+	httpClient *http.Client
+}
+
+// chromaStore talks to Chroma's v1 REST API, which addresses collections by id
+type chromaStore struct {
+	vectorHTTP
+
+	mu  sync.Mutex
+	ids map[string]string // collection name -> id
+}
+
+func (s *chromaStore) collectionID(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id, ok := s.ids[name]; ok {
+		return id, nil
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	err := postJSON(context.Background(), s.httpClient, s.baseURL+"/api/v1/collections", map[string]any{
+		"name":          name,
+		"get_or_create": true,
+		"metadata":      map[string]any{"hnsw:space": s.metric},
+	}, &resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to open chroma collection %s: %w", name, err)
+	}
+	s.ids[name] = resp.ID
+	return resp.ID, nil
+}
+
+func (s *chromaStore) Upsert(collection string, id string, vector []float32, metadata map[string]any) error {
+	cid, err := s.collectionID(collection)
+	if err != nil {
+		return err
+	}
+	return postJSON(context.Background(), s.httpClient, s.baseURL+"/api/v1/collections/"+cid+"/upsert", map[string]any{
+		"ids":        []string{id},
+		"embeddings": [][]float32{vector},
+		"metadatas":  []map[string]any{metadata},
+	}, nil)
+}
+
+func (s *chromaStore) Search(collection string, queryVector []float32, topK int) ([]SearchResult, error) {
+	cid, err := s.collectionID(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		IDs       [][]string         `json:"ids"`
+		Distances [][]float32        `json:"distances"`
+		Metadatas [][]map[string]any `json:"metadatas"`
+	}
+	err = postJSON(context.Background(), s.httpClient, s.baseURL+"/api/v1/collections/"+cid+"/query", map[string]any{
+		"query_embeddings": [][]float32{queryVector},
+		"n_results":        topK,
+		"include":          []string{"metadatas", "distances"},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.IDs) == 0 {
+		return nil, nil
+	}
+
+	results := make([]SearchResult, len(resp.IDs[0]))
+	for i, id := range resp.IDs[0] {
+		results[i] = SearchResult{ID: id}
+		if len(resp.Distances) > 0 && i < len(resp.Distances[0]) {
+			// Chroma returns distances; higher scores should mean closer
+			results[i].Score = 1 - resp.Distances[0][i]
+		}
+		if len(resp.Metadatas) > 0 && i < len(resp.Metadatas[0]) {
+			results[i].Metadata = resp.Metadatas[0][i]
+		}
+	}
+	return results, nil
+}
+
+func (s *chromaStore) Delete(collection string, id string) error {
+	cid, err := s.collectionID(collection)
+	if err != nil {
+		return err
+	}
+	return postJSON(context.Background(), s.httpClient, s.baseURL+"/api/v1/collections/"+cid+"/delete", map[string]any{
+		"ids": []string{id},
+	}, nil)
+}
+
+// qdrantStore talks to Qdrant's REST API. Qdrant point ids must be integers or UUIDs,
+// so string ids are mapped to a UUID derived from them and kept in the payload.
+type qdrantStore struct {
+	vectorHTTP
+
+	mu      sync.Mutex
+	created map[string]bool
+}
+
+const qdrantIDField = "_id"
+
+// qdrantPointID derives a stable UUID from an arbitrary id
+func qdrantPointID(id string) string {
+	h := sha1.Sum([]byte(id))
+	h[6] = (h[6] & 0x0f) | 0x50 // version 5
+	h[8] = (h[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+func (s *qdrantStore) ensureCollection(name string, size int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.created[name] {
+		return nil
+	}
+
+	distance := map[string]string{"cosine": "Cosine", "l2": "Euclid", "ip": "Dot"}[s.metric]
+	err := doJSON(context.Background(), s.httpClient, http.MethodPut, s.baseURL+"/collections/"+url.PathEscape(name), map[string]any{
+		"vectors": map[string]any{"size": size, "distance": distance},
+	}, nil)
+	// Qdrant rejects creating a collection that already exists
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		return fmt.Errorf("failed to create qdrant collection %s: %w", name, err)
+	}
+	s.created[name] = true
+	return nil
+}
+
+func (s *qdrantStore) Upsert(collection string, id string, vector []float32, metadata map[string]any) error {
+	if err := s.ensureCollection(collection, len(vector)); err != nil {
+		return err
+	}
+
+	payload := map[string]any{qdrantIDField: id}
+	for k, v := range metadata {
+		payload[k] = v
+	}
+	return doJSON(context.Background(), s.httpClient, http.MethodPut, s.baseURL+"/collections/"+url.PathEscape(collection)+"/points?wait=true", map[string]any{
+		"points": []map[string]any{{"id": qdrantPointID(id), "vector": vector, "payload": payload}},
+	}, nil)
+}
+
+func (s *qdrantStore) Search(collection string, queryVector []float32, topK int) ([]SearchResult, error) {
+	// Searching a collection that was never created is a 404, not an empty result
+	if err := s.ensureCollection(collection, len(queryVector)); err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Result []struct {
+			Score   float32        `json:"score"`
+			Payload map[string]any `json:"payload"`
+		} `json:"result"`
+	}
+	err := postJSON(context.Background(), s.httpClient, s.baseURL+"/collections/"+url.PathEscape(collection)+"/points/search", map[string]any{
+		"vector":       queryVector,
+		"limit":        topK,
+		"with_payload": true,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(resp.Result))
+	for _, r := range resp.Result {
+		id, _ := r.Payload[qdrantIDField].(string)
+		delete(r.Payload, qdrantIDField)
+		results = append(results, SearchResult{ID: id, Score: r.Score, Metadata: r.Payload})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results, nil
+}
+
+func (s *qdrantStore) Delete(collection string, id string) error {
+	return postJSON(context.Background(), s.httpClient, s.baseURL+"/collections/"+url.PathEscape(collection)+"/points/delete?wait=true", map[string]any{
+		"points": []string{qdrantPointID(id)},
+	}, nil)
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, body, out any) error {
+	return doJSON(ctx, client, http.MethodPost, endpoint, body, out)
+}
+
+// doJSON sends body as JSON and decodes a 2xx response into out, if non-nil
+func doJSON(ctx context.Context, client *http.Client, method, endpoint string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, endpoint, resp.Status, strings.TrimSpace(string(b)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
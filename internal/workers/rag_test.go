@@ -0,0 +1,600 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEmbedServer embeds text as counts of a few marker words so similarity is predictable
+func newTestEmbedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/embeddings", r.URL.Path)
+		var req LMStudioEmbedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var data []map[string]any
+		for i, text := range req.Input {
+			text = strings.ToLower(text)
+			data = append(data, map[string]any{
+				"embedding": []float64{float64(strings.Count(text, "apple")), float64(strings.Count(text, "banana")), 0.1},
+				"index":     i,
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type testPoint struct {
+	id       string
+	vector   []float32
+	metadata map[string]any
+}
+
+// fakeVectorDB serves just enough of the Chroma and Qdrant REST APIs for the RAG worker
+type fakeVectorDB struct {
+	mu     sync.Mutex
+	points map[string]testPoint
+}
+
+func (db *fakeVectorDB) search(query []float32, topK int) []testPoint {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var all []testPoint
+	for _, p := range db.points {
+		all = append(all, p)
+	}
+	score := func(p testPoint) float32 {
+		var s float32
+		for i := range p.vector {
+			s += p.vector[i] * query[i]
+		}
+		return s
+	}
+	sort.Slice(all, func(i, j int) bool { return score(all[i]) > score(all[j]) })
+	if len(all) > topK {
+		all = all[:topK]
+	}
+	return all
+}
+
+func newTestChromaServer(t *testing.T) (*httptest.Server, *fakeVectorDB) {
+	t.Helper()
+	db := &fakeVectorDB{points: map[string]testPoint{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]json.RawMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		switch r.URL.Path {
+		case "/api/v1/collections":
+			json.NewEncoder(w).Encode(map[string]string{"id": "c1"})
+		case "/api/v1/collections/c1/upsert":
+			var ids []string
+			var vectors [][]float32
+			var metadatas []map[string]any
+			json.Unmarshal(body["ids"], &ids)
+			json.Unmarshal(body["embeddings"], &vectors)
+			json.Unmarshal(body["metadatas"], &metadatas)
+			db.mu.Lock()
+			for i, id := range ids {
+				db.points[id] = testPoint{id: id, vector: vectors[i], metadata: metadatas[i]}
+			}
+			db.mu.Unlock()
+		case "/api/v1/collections/c1/query":
+			var queries [][]float32
+			var n int
+			json.Unmarshal(body["query_embeddings"], &queries)
+			json.Unmarshal(body["n_results"], &n)
+			var ids []string
+			var distances []float32
+			var metadatas []map[string]any
+			for _, p := range db.search(queries[0], n) {
+				ids = append(ids, p.id)
+				distances = append(distances, 0.5)
+				metadatas = append(metadatas, p.metadata)
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"ids":       [][]string{ids},
+				"distances": [][]float32{distances},
+				"metadatas": [][]map[string]any{metadatas},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, db
+}
+
+func newTestQdrantServer(t *testing.T) (*httptest.Server, *fakeVectorDB) {
+	t.Helper()
+	db := &fakeVectorDB{points: map[string]testPoint{}}
+	created := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/collections/docs":
+			if created {
+				http.Error(w, `{"status":{"error":"Collection docs already exists!"}}`, http.StatusConflict)
+				return
+			}
+			created = true
+			w.Write([]byte(`{"result":true}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/collections/docs/points":
+			var req struct {
+				Points []struct {
+					ID      string         `json:"id"`
+					Vector  []float32      `json:"vector"`
+					Payload map[string]any `json:"payload"`
+				} `json:"points"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			db.mu.Lock()
+			for _, p := range req.Points {
+				db.points[p.ID] = testPoint{id: p.ID, vector: p.Vector, metadata: p.Payload}
+			}
+			db.mu.Unlock()
+			w.Write([]byte(`{"result":{"status":"completed"}}`))
+		case r.URL.Path == "/collections/docs/points/search":
+			if !created {
+				http.Error(w, `{"status":{"error":"Not found: Collection `+"`docs`"+` doesn't exist!"}}`, http.StatusNotFound)
+				return
+			}
+			var req struct {
+				Vector []float32 `json:"vector"`
+				Limit  int       `json:"limit"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			var result []map[string]any
+			for _, p := range db.search(req.Vector, req.Limit) {
+				result = append(result, map[string]any{"id": p.id, "score": 0.9, "payload": p.metadata})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"result": result})
+		case r.URL.Path == "/collections/docs/points/delete":
+			var req struct {
+				Points []string `json:"points"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			db.mu.Lock()
+			for _, id := range req.Points {
+				delete(db.points, id)
+			}
+			db.mu.Unlock()
+			w.Write([]byte(`{"result":{"status":"completed"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, db
+}
+
+func ingestAndSearch(t *testing.T, w *RAGWorkerState) []map[string]any {
+	t.Helper()
+	ctx := context.Background()
+	for _, content := range []string{"apple apple apple pie", "banana bread with banana"} {
+		out, err := w.Execute(ctx, "rag_ingest", json.RawMessage(`{"content":"`+content+`","title":"`+content+`"}`))
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `"indexed":true`)
+	}
+
+	out, err := w.Execute(ctx, "rag_search", json.RawMessage(`{"query":"banana","top_k":1}`))
+	require.NoError(t, err)
+	var results []map[string]any
+	require.NoError(t, json.Unmarshal(out, &results))
+	return results
+}
+
+func TestRAGWorkerWithBackends_Chroma(t *testing.T) {
+	embed := newTestEmbedServer(t)
+	chroma, db := newTestChromaServer(t)
+
+	w, err := NewRAGWorkerWithBackends(RAGConfig{ChunkSize: 500, Collection: "docs"},
+		VectorStoreConfig{Backend: "chroma", Endpoint: chroma.URL}, embed.URL, "")
+	require.NoError(t, err)
+
+	results := ingestAndSearch(t, w)
+	require.Len(t, results, 1)
+	assert.Equal(t, "banana bread with banana", results[0]["content"])
+	assert.InDelta(t, 0.5, results[0]["score"], 0.001)
+	assert.Len(t, db.points, 2)
+}
+
+func TestRAGWorkerWithBackends_Qdrant(t *testing.T) {
+	embed := newTestEmbedServer(t)
+	qdrant, db := newTestQdrantServer(t)
+
+	w, err := NewRAGWorkerWithBackends(RAGConfig{ChunkSize: 500, Collection: "docs"},
+		VectorStoreConfig{Backend: "qdrant", Endpoint: qdrant.URL}, embed.URL, "")
+	require.NoError(t, err)
+
+	results := ingestAndSearch(t, w)
+	require.Len(t, results, 1)
+	assert.Equal(t, "banana bread with banana", results[0]["content"])
+	chunkID := results[0]["chunk_id"].(string)
+	assert.NotEmpty(t, chunkID)
+	assert.Contains(t, db.points, qdrantPointID(chunkID))
+
+	require.NoError(t, w.VectorStore.Delete("docs", chunkID))
+	assert.NotContains(t, db.points, qdrantPointID(chunkID))
+}
+
+func TestRAGWorkerWithBackends_QdrantSearchBeforeIngest(t *testing.T) {
+	embed := newTestEmbedServer(t)
+	qdrant, _ := newTestQdrantServer(t)
+
+	w, err := NewRAGWorkerWithBackends(RAGConfig{ChunkSize: 500, Collection: "docs"},
+		VectorStoreConfig{Backend: "qdrant", Endpoint: qdrant.URL}, embed.URL, "")
+	require.NoError(t, err)
+
+	assert.Empty(t, ragSearch(t, w, `{"query": "banana", "top_k": 1}`))
+}
+
+func TestNewRAGWorkerWithBackends_RejectsBadConfig(t *testing.T) {
+	_, err := NewRAGWorkerWithBackends(RAGConfig{}, VectorStoreConfig{Backend: "pinecone", Endpoint: "http://localhost"}, "http://localhost", "")
+	assert.ErrorContains(t, err, "unsupported vector backend")
+
+	_, err = NewRAGWorkerWithBackends(RAGConfig{}, VectorStoreConfig{Backend: "qdrant", Endpoint: "http://localhost", DistanceMetric: "manhattan"}, "http://localhost", "")
+	assert.ErrorContains(t, err, "unsupported distance metric")
+
+	_, err = NewRAGWorkerWithBackends(RAGConfig{}, VectorStoreConfig{Backend: "qdrant", Endpoint: "http://localhost"}, "", "")
+	assert.ErrorContains(t, err, "embedding endpoint required")
+}
+
+func TestQdrantPointID_IsStableUUID(t *testing.T) {
+	id := qdrantPointID("doc_1_chunk_0")
+	assert.Equal(t, id, qdrantPointID("doc_1_chunk_0"))
+	assert.NotEqual(t, id, qdrantPointID("doc_1_chunk_1"))
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, id)
+}
+
+// promptLLM answers each call with a function of the prompt
+type promptLLM func(prompt string) string
+
+func (f promptLLM) Call(ctx context.Context, prompt string, systemPrompt string) (string, error) {
+	return f(prompt), nil
+}
+
+// recordingLLM returns a canned response and remembers the prompt it was given
+type recordingLLM struct {
+	response string
+	prompt   string
+}
+
+func (r *recordingLLM) Call(ctx context.Context, prompt string, systemPrompt string) (string, error) {
+	r.prompt = prompt
+	return r.response, nil
+}
+
+func TestRAGAsk_CallsLLMWithRetrievedContext(t *testing.T) {
+	w := NewRAGWorkerState(RAGConfig{})
+	llm := &recordingLLM{response: " The invoice is due in 30 days. "}
+	w.SetLLMCaller(llm)
+
+	_, err := w.Execute(context.Background(), "rag_ingest", json.RawMessage(`{"content":"Payment terms: the invoice is due within 30 days of receipt.","title":"Terms"}`))
+	require.NoError(t, err)
+
+	out, err := w.Execute(context.Background(), "rag_ask", json.RawMessage(`{"query":"invoice"}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Answer  string           `json:"answer"`
+		Sources []map[string]any `json:"sources"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "The invoice is due in 30 days.", resp.Answer)
+	require.Len(t, resp.Sources, 1)
+	assert.Equal(t, "Terms", resp.Sources[0]["title"])
+	assert.Contains(t, llm.prompt, "due within 30 days of receipt")
+	assert.Contains(t, llm.prompt, "Question: invoice")
+}
+
+func TestRAGAsk_WithoutLLMReturnsContextOnly(t *testing.T) {
+	w := NewRAGWorkerState(RAGConfig{})
+	_, err := w.Execute(context.Background(), "rag_ingest", json.RawMessage(`{"content":"The invoice is due within 30 days.","title":"Terms"}`))
+	require.NoError(t, err)
+
+	out, err := w.Execute(context.Background(), "rag_ask", json.RawMessage(`{"query":"invoice"}`))
+	require.NoError(t, err)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "", resp["answer"])
+	assert.Contains(t, resp["context"], "due within 30 days")
+}
+
+func TestChunkText_OffsetsMapBackToSource(t *testing.T) {
+	w := NewRAGWorkerState(RAGConfig{ChunkSize: 120, ChunkOverlap: 30})
+
+	var paras []string
+	for i := 0; i < 12; i++ {
+		paras = append(paras, strings.Repeat(string(rune('a'+i)), 10+i*3)+" paragraph "+string(rune('A'+i)))
+	}
+	content := "  \n" + strings.Join(paras, "\n\n") + "\n\n\n  trailing line  \n"
+
+	chunks := w.chunkText("doc_test", content)
+	require.Greater(t, len(chunks), 2)
+
+	var rebuilt strings.Builder
+	end := 0
+	overlapped := false
+	for i, c := range chunks {
+		assert.Equal(t, i, c.Index)
+		assert.Equal(t, content[c.StartChar:c.EndChar], c.Content, "chunk %d", i)
+		if i == 0 {
+			assert.Empty(t, strings.TrimSpace(content[:c.StartChar]))
+			end = c.StartChar
+		}
+		if c.StartChar < end {
+			overlapped = true
+		} else {
+			// Anything skipped between chunks is paragraph separator whitespace
+			assert.Empty(t, strings.TrimSpace(content[end:c.StartChar]), "chunk %d", i)
+			rebuilt.WriteString(content[end:c.StartChar])
+		}
+		rebuilt.WriteString(content[max(end, c.StartChar):c.EndChar])
+		end = c.EndChar
+	}
+
+	assert.True(t, overlapped, "expected consecutive chunks to overlap")
+	assert.Equal(t, strings.TrimSpace(content), rebuilt.String())
+}
+
+func TestChunkText_OverlapKeepsRunesWhole(t *testing.T) {
+	w := NewRAGWorkerState(RAGConfig{ChunkSize: 60, ChunkOverlap: 8})
+
+	var paras []string
+	for i := 0; i < 10; i++ {
+		paras = append(paras, strings.Repeat("héllo wörld ", 2+i%3)+"日本語")
+	}
+	content := strings.Join(paras, "\n\n")
+
+	chunks := w.chunkText("doc_test", content)
+	require.Greater(t, len(chunks), 2)
+	for i, c := range chunks {
+		assert.True(t, utf8.ValidString(c.Content), "chunk %d: %q", i, c.Content)
+		assert.Equal(t, content[c.StartChar:c.EndChar], c.Content, "chunk %d", i)
+	}
+}
+
+func ragSearch(t *testing.T, w *RAGWorkerState, input string) []map[string]any {
+	t.Helper()
+	out, err := w.Execute(context.Background(), "rag_search", json.RawMessage(input))
+	require.NoError(t, err)
+	var results []map[string]any
+	require.NoError(t, json.Unmarshal(out, &results))
+	return results
+}
+
+func TestRAGSearch_LexicalRerankReorders(t *testing.T) {
+	w := NewRAGWorkerState(RAGConfig{})
+	for _, doc := range []struct{ title, content string }{
+		{"Invoices", "Invoice numbering: every invoice gets an invoice id, and invoice ids never repeat."},
+		{"Payment", "The invoice payment is due within 30 days."},
+		{"Holidays", "The office is closed on public holidays."},
+	} {
+		_, err := w.Execute(context.Background(), "rag_ingest", json.RawMessage(`{"title":"`+doc.title+`","content":"`+doc.content+`"}`))
+		require.NoError(t, err)
+	}
+
+	// Keyword scores favour the document repeating "invoice"
+	results := ragSearch(t, w, `{"query":"invoice payment due","top_k":2}`)
+	require.Len(t, results, 2)
+	assert.Equal(t, "Invoices", results[0]["title"])
+	assert.NotContains(t, results[0], "rerank_score")
+
+	// Re-ranking by query term coverage prefers the one that actually answers it
+	results = ragSearch(t, w, `{"query":"invoice payment due","top_k":1,"rerank":true}`)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Payment", results[0]["title"])
+	assert.InDelta(t, 1.0, results[0]["rerank_score"], 0.001)
+}
+
+func TestRAGSearch_LLMRerank(t *testing.T) {
+	w := NewRAGWorkerState(RAGConfig{})
+	for _, title := range []string{"First", "Second"} {
+		_, err := w.Execute(context.Background(), "rag_ingest", json.RawMessage(`{"title":"`+title+`","content":"report `+title+`"}`))
+		require.NoError(t, err)
+	}
+
+	// Both documents tie on keyword score; the LLM decides "Second" is relevant
+	w.SetLLMCaller(promptLLM(func(prompt string) string {
+		if strings.Index(prompt, "report Second") < strings.Index(prompt, "report First") {
+			return "Scores: [0.8, 0.1]"
+		}
+		return "Scores: [0.1, 0.8]"
+	}))
+
+	reranked := ragSearch(t, w, `{"query":"report","top_k":2,"rerank":true}`)
+	require.Len(t, reranked, 2)
+	assert.Equal(t, "Second", reranked[0]["title"])
+	assert.InDelta(t, 0.8, reranked[0]["rerank_score"], 0.001)
+
+	// An unusable response falls back to lexical scoring
+	w.SetLLMCaller(&fakeLLM{response: "[1]"})
+	reranked = ragSearch(t, w, `{"query":"report","top_k":2,"rerank":true}`)
+	require.Len(t, reranked, 2)
+	assert.InDelta(t, 1.0, reranked[0]["rerank_score"], 0.001)
+}
+
+func TestRAGSearch_LLMRerankTruncatesOnRuneBoundary(t *testing.T) {
+	w := NewRAGWorkerState(RAGConfig{})
+	// The 1000-byte passage limit falls in the middle of the "é"
+	content := "report " + strings.Repeat("a", 992) + "é and more"
+	input, _ := json.Marshal(map[string]string{"title": "Accents", "content": content})
+	_, err := w.Execute(context.Background(), "rag_ingest", input)
+	require.NoError(t, err)
+
+	var prompt string
+	w.SetLLMCaller(promptLLM(func(p string) string {
+		prompt = p
+		return "[0.5]"
+	}))
+	ragSearch(t, w, `{"query":"report","rerank":true}`)
+	assert.True(t, utf8.ValidString(prompt))
+	assert.Contains(t, prompt, strings.Repeat("a", 992)+"\n")
+}
+
+// memVectorStore is an in-memory VectorStore
+type memVectorStore struct {
+	vectors map[string]map[string]any
+	deleted []string
+}
+
+func (m *memVectorStore) Upsert(collection string, id string, vector []float32, metadata map[string]any) error {
+	m.vectors[id] = metadata
+	return nil
+}
+
+func (m *memVectorStore) Search(collection string, queryVector []float32, topK int) ([]SearchResult, error) {
+	var results []SearchResult
+	for id, metadata := range m.vectors {
+		results = append(results, SearchResult{ID: id, Score: 1, Metadata: metadata})
+	}
+	return results, nil
+}
+
+func (m *memVectorStore) Delete(collection string, id string) error {
+	delete(m.vectors, id)
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+// constEmbedder embeds every text as the same vector
+type constEmbedder struct{}
+
+func (constEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = []float32{1}
+	}
+	return vectors, nil
+}
+
+func TestRAGIngest_SameSourceReplacesDocument(t *testing.T) {
+	w := NewRAGWorkerState(RAGConfig{ChunkSize: 40, ChunkOverlap: 1})
+	store := &memVectorStore{vectors: map[string]map[string]any{}}
+	w.SetVectorStore(store)
+	w.SetEmbedder(constEmbedder{})
+
+	ingest := func(content string) map[string]any {
+		out, err := w.Execute(context.Background(), "rag_ingest", json.RawMessage(`{"source":"/notes/plan.md","content":"`+content+`"}`))
+		require.NoError(t, err)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(out, &resp))
+		return resp
+	}
+
+	first := ingest(`First draft of the plan.\n\nIt has two paragraphs, both old.`)
+	assert.Equal(t, "inserted", first["action"])
+	require.Len(t, w.Documents, 1)
+	oldChunks := w.Documents[first["document_id"].(string)].Chunks
+	require.Len(t, oldChunks, 2)
+
+	second := ingest(`The revised plan fits in one paragraph.`)
+	assert.Equal(t, "updated", second["action"])
+	assert.Equal(t, first["document_id"], second["document_id"])
+
+	require.Len(t, w.Documents, 1)
+	doc := w.Documents[second["document_id"].(string)]
+	assert.Equal(t, "The revised plan fits in one paragraph.", doc.Content)
+	assert.Equal(t, "markdown", doc.Type)
+
+	for _, chunk := range oldChunks {
+		assert.Contains(t, store.deleted, chunk.ChunkID)
+		assert.NotContains(t, store.vectors, chunk.ChunkID)
+	}
+	require.Len(t, store.vectors, 1)
+	assert.Contains(t, store.vectors, doc.Chunks[0].ChunkID)
+}
+
+func TestGenerateDocID_IsContentAddressed(t *testing.T) {
+	id := generateDocID("doc", "quarterly report")
+	assert.Equal(t, id, generateDocID("doc", "quarterly report"))
+	assert.NotEqual(t, id, generateDocID("doc", "quarterly reports"))
+	assert.NotEqual(t, id, generateDocID("contract", "quarterly report"))
+	assert.Regexp(t, `^doc_[0-9a-f]{16}$`, id)
+
+	// Inputs that the old cleaned-prefix IDs conflated now differ
+	assert.NotEqual(t, generateDocID("doc", "a-b"), generateDocID("doc", "ab"))
+}
+
+func TestRAGIngest_ChunkIDsAreDeterministic(t *testing.T) {
+	content := "Shared paragraph.\n\nSecond paragraph."
+	ingest := func(w *RAGWorkerState, title string) Document {
+		input, _ := json.Marshal(map[string]string{"title": title, "content": content})
+		out, err := w.Execute(context.Background(), "rag_ingest", input)
+		require.NoError(t, err)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(out, &resp))
+		return w.Documents[resp["document_id"].(string)]
+	}
+
+	// The same document ingested by separate workers gets the same IDs
+	a := ingest(NewRAGWorkerState(RAGConfig{ChunkSize: 20}), "Notes")
+	b := ingest(NewRAGWorkerState(RAGConfig{ChunkSize: 20}), "Notes")
+	require.Len(t, a.Chunks, 2)
+	assert.Equal(t, a.ID, b.ID)
+	for i := range a.Chunks {
+		assert.Equal(t, a.Chunks[i].ChunkID, b.Chunks[i].ChunkID)
+		assert.Equal(t, a.ID, a.Chunks[i].DocumentID)
+	}
+	assert.NotEqual(t, a.Chunks[0].ChunkID, a.Chunks[1].ChunkID)
+
+	// Identical text in another document doesn't share chunk IDs
+	c := ingest(NewRAGWorkerState(RAGConfig{ChunkSize: 20}), "Other notes")
+	assert.NotEqual(t, a.ID, c.ID)
+	assert.NotEqual(t, a.Chunks[0].ChunkID, c.Chunks[0].ChunkID)
+}
+
+func TestRAGWorker_ConcurrentAccess(t *testing.T) {
+	w := NewRAGWorkerState(RAGConfig{ChunkSize: 50})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input, _ := json.Marshal(map[string]string{
+				"source":  fmt.Sprintf("/docs/%d.txt", i%5),
+				"content": fmt.Sprintf("Report number %d covers budget planning.", i),
+			})
+			out, err := w.Execute(ctx, "rag_ingest", input)
+			assert.NoError(t, err)
+
+			_, err = w.Execute(ctx, "rag_search", json.RawMessage(`{"query":"budget"}`))
+			assert.NoError(t, err)
+			_, err = w.Execute(ctx, "rag_list", json.RawMessage(`{}`))
+			assert.NoError(t, err)
+			_, err = w.Execute(ctx, "rag_stats", json.RawMessage(`{}`))
+			assert.NoError(t, err)
+
+			if i%4 == 0 {
+				var resp map[string]any
+				json.Unmarshal(out, &resp)
+				input, _ := json.Marshal(map[string]any{"document_id": resp["document_id"]})
+				w.Execute(ctx, "rag_delete", input)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	out, err := w.Execute(ctx, "rag_stats", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	var stats map[string]any
+	require.NoError(t, json.Unmarshal(out, &stats))
+	assert.LessOrEqual(t, stats["documents"], float64(5))
+}
@@ -1,6 +1,7 @@
 package workers
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -29,8 +30,9 @@ type RemindersTask struct {
 	DueDate     *time.Time `json:"due_date,omitempty"`
 	Completed   bool       `json:"completed"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
-	ExternalID  string     `json:"external_id"` // Apple Reminders ID
-	Source      string     `json:"source"`      // "apple" or "mymcp"
+	ExternalID  string     `json:"external_id"`          // Apple Reminders ID
+	Source      string     `json:"source"`               // "apple" or "mymcp"
+	Recurrence  string     `json:"recurrence,omitempty"` // "daily", "weekly", "monthly" or "yearly"
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
@@ -59,16 +61,6 @@ type RemindersConfig struct {
 
 // NewRemindersSyncWorker creates a new reminders sync worker
 func NewRemindersSyncWorker(cfg RemindersConfig) (*RemindersSyncWorkerState, error) {
-	// Find remindctl
-	remindctlPath := cfg.RemindctlPath
-	if remindctlPath == "" {
-		if path, err := exec.LookPath("remindctl"); err == nil {
-			remindctlPath = path
-		} else {
-			remindctlPath = "/usr/local/bin/remindctl"
-		}
-	}
-
 	// Connect to PostgreSQL if URL provided
 	var db *sql.DB
 	var err error
@@ -93,19 +85,73 @@ func NewRemindersSyncWorker(cfg RemindersConfig) (*RemindersSyncWorkerState, err
 		}
 	}
 
-	return &RemindersSyncWorkerState{
+	return NewRemindersSyncWorkerFromDB(db, cfg)
+}
+
+// NewRemindersSyncWorkerFromDB creates a reminders sync worker on an existing DB
+// connection, which may be nil, adding the columns it needs to the tasks table
+func NewRemindersSyncWorkerFromDB(db *sql.DB, cfg RemindersConfig) (*RemindersSyncWorkerState, error) {
+	// Find remindctl
+	remindctlPath := cfg.RemindctlPath
+	if remindctlPath == "" {
+		if path, err := exec.LookPath("remindctl"); err == nil {
+			remindctlPath = path
+		} else {
+			remindctlPath = "/usr/local/bin/remindctl"
+		}
+	}
+
+	w := &RemindersSyncWorkerState{
 		DB:            db,
 		remindctlPath: remindctlPath,
 		Tools: []ToolDef{
 			{Name: "reminders_sync_to_db", Description: "Sync Apple Reminders to PostgreSQL database"},
+			{Name: "reminders_sync_incremental", Description: "Sync only Apple Reminders modified since the last incremental sync"},
+			{Name: "reminders_sync", Description: "Two-way sync between Apple Reminders and the database with conflict resolution"},
 			{Name: "reminders_sync_from_db", Description: "Sync PostgreSQL tasks to Apple Reminders"},
 			{Name: "reminders_create", Description: "Create a new reminder in both Apple and database"},
 			{Name: "reminders_complete", Description: "Mark a reminder as complete"},
 			{Name: "reminders_list", Description: "List reminders from database"},
 			{Name: "reminders_show", Description: "Show reminders from Apple Reminders"},
 			{Name: "reminders_sync_status", Description: "Check sync status and counts"},
+			{Name: "reminders_list_lists", Description: "List Apple Reminders lists"},
+			{Name: "reminders_create_list", Description: "Create a new Apple Reminders list"},
 		},
-	}, nil
+	}
+	if db != nil {
+		if err := w.migrate(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// remindersColumns are the columns this worker needs on the tasks table, which it
+// shares with the task worker and doesn't create itself
+var remindersColumns = []struct{ name, definition string }{
+	{"deleted_at", "TIMESTAMP"},
+	{"recurrence", "TEXT"},
+}
+
+// migrate adds any of remindersColumns the tasks table lacks
+func (w *RemindersSyncWorkerState) migrate(ctx context.Context) error {
+	for _, col := range remindersColumns {
+		if err := addColumnIfMissing(ctx, w.DB, "tasks", col.name, col.definition); err != nil {
+			return fmt.Errorf("failed to migrate tasks table: %w", err)
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column unless the table already has one by that name.
+// ADD COLUMN IF NOT EXISTS would do on PostgreSQL, but SQLite doesn't support it.
+func addColumnIfMissing(ctx context.Context, db *sql.DB, table, column, definition string) error {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s LIMIT 0", column, table))
+	if err == nil {
+		return rows.Close()
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
 }
 
 // createTasksTable creates the tasks table in PostgreSQL
@@ -144,6 +190,10 @@ func (w *RemindersSyncWorkerState) Execute(ctx context.Context, name string, inp
 	switch name {
 	case "reminders_reminders_sync_to_db", "reminders_sync_to_db":
 		return w.syncToDB(ctx, input)
+	case "reminders_reminders_sync_incremental", "reminders_sync_incremental":
+		return w.syncIncremental(ctx, input)
+	case "reminders_reminders_sync", "reminders_sync":
+		return w.syncBidirectional(ctx, input)
 	case "reminders_reminders_sync_from_db", "reminders_sync_from_db":
 		return w.syncFromDB(ctx, input)
 	case "reminders_reminders_create", "reminders_create":
@@ -156,6 +206,10 @@ func (w *RemindersSyncWorkerState) Execute(ctx context.Context, name string, inp
 		return w.showReminders(ctx, input)
 	case "reminders_reminders_sync_status", "reminders_sync_status":
 		return w.syncStatus(ctx, input)
+	case "reminders_reminders_list_lists", "reminders_list_lists":
+		return w.listLists(ctx, input)
+	case "reminders_reminders_create_list", "reminders_create_list":
+		return w.createList(ctx, input)
 	default:
 		return nil, nil
 	}
@@ -168,61 +222,288 @@ func (w *RemindersSyncWorkerState) syncToDB(ctx context.Context, input json.RawM
 	}
 
 	var req struct {
-		List string `json:"list"` // optional: specific list to sync
+		List       string     `json:"list"`        // optional: specific list to sync
+		Since      *time.Time `json:"since"`       // optional: only reminders modified after this
+		HardDelete bool       `json:"hard_delete"` // delete orphaned tasks instead of marking them deleted
+		DryRun     bool       `json:"dry_run"`     // report planned actions without changing anything
 	}
 	json.Unmarshal(input, &req)
 
-	// Fetch all reminders from Apple Reminders
-	reminders, err := w.fetchAppleReminders(ctx, req.List)
+	result, err := w.pullFromApple(ctx, appleSyncOptions{List: req.List, Since: req.Since, HardDelete: req.HardDelete, DryRun: req.DryRun})
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch Apple Reminders: %w", err)
+		return nil, err
 	}
 
-	synced := 0
-	updated := 0
-	duplicates := 0
+	resp := map[string]any{
+		"success":    true,
+		"synced":     result.Synced,
+		"updated":    result.Updated,
+		"duplicates": result.Duplicates,
+		"skipped":    result.Skipped,
+		"removed":    result.Removed,
+		"total":      result.Total,
+	}
+	if req.DryRun {
+		resp["dry_run"] = true
+		resp["actions"] = result.Plan
+	}
+	return json.Marshal(resp)
+}
+
+// syncIncremental syncs reminders modified since the stored cursor for the list,
+// then advances the cursor to the newest modification seen
+func (w *RemindersSyncWorkerState) syncIncremental(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	if w.DB == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+
+	var req struct {
+		List       string     `json:"list"`
+		Since      *time.Time `json:"since"` // overrides the stored cursor
+		HardDelete bool       `json:"hard_delete"`
+	}
+	json.Unmarshal(input, &req)
+
+	since := req.Since
+	if since == nil {
+		cursor, err := w.loadSyncCursor(ctx, req.List)
+		if err != nil {
+			return nil, err
+		}
+		since = cursor
+	}
+
+	result, err := w.pullFromApple(ctx, appleSyncOptions{List: req.List, Since: since, HardDelete: req.HardDelete})
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := since
+	if !result.HighWater.IsZero() && (since == nil || result.HighWater.After(*since)) {
+		if err := w.saveSyncCursor(ctx, req.List, result.HighWater); err != nil {
+			return nil, err
+		}
+		cursor = &result.HighWater
+	}
+
+	return json.Marshal(map[string]any{
+		"success":    true,
+		"synced":     result.Synced,
+		"updated":    result.Updated,
+		"duplicates": result.Duplicates,
+		"skipped":    result.Skipped,
+		"removed":    result.Removed,
+		"total":      result.Total,
+		"cursor":     cursor,
+	})
+}
+
+// appleSyncOptions controls one Apple -> database pass
+type appleSyncOptions struct {
+	List       string     // only this list; empty means all lists
+	Since      *time.Time // skip reminders not modified after this
+	HardDelete bool       // delete orphaned tasks rather than setting deleted_at
+	DryRun     bool       // compute the plan but leave the database untouched
+}
+
+// plannedAction is one change a sync makes, or would make in a dry run
+type plannedAction struct {
+	Action string `json:"action"` // insert, update, delete, mark_deleted, create_reminder
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+// appleSyncResult tallies one Apple -> database pass
+type appleSyncResult struct {
+	Synced     int
+	Updated    int
+	Duplicates int
+	Skipped    int // unchanged since the cursor
+	Removed    int // deleted in Apple Reminders
+	Total      int
+	HighWater  time.Time // newest ModifiedAt among the reminders fetched
+	Plan       []plannedAction
+}
+
+// pullFromApple inserts or updates tasks from Apple Reminders, then removes tasks whose
+// reminder no longer exists. With Since set, reminders not modified after it are skipped
+// without touching the database.
+func (w *RemindersSyncWorkerState) pullFromApple(ctx context.Context, opts appleSyncOptions) (appleSyncResult, error) {
+	result := appleSyncResult{Plan: make([]plannedAction, 0)}
+
+	// remindctl has no modified-since filter, so fetch everything and filter here
+	reminders, err := w.fetchAppleReminders(ctx, opts.List)
+	if err != nil {
+		return result, fmt.Errorf("failed to fetch Apple Reminders: %w", err)
+	}
+	result.Total = len(reminders)
 
+	seen := make(map[string]bool, len(reminders))
 	for _, reminder := range reminders {
+		seen[reminder.ID] = true
+		if reminder.ModifiedAt.After(result.HighWater) {
+			result.HighWater = reminder.ModifiedAt
+		}
+		if opts.Since != nil && !reminder.ModifiedAt.After(*opts.Since) {
+			result.Skipped++
+			continue
+		}
+
 		// Check if already exists by external_id
 		var existingTask RemindersTask
 		err := w.DB.QueryRowContext(ctx,
-			"SELECT id, title, notes, completed, updated_at FROM tasks WHERE external_id = $1",
+			"SELECT id, title, COALESCE(notes, ''), completed, updated_at FROM tasks WHERE external_id = $1",
 			reminder.ID,
 		).Scan(&existingTask.ID, &existingTask.Title, &existingTask.Notes, &existingTask.Completed, &existingTask.UpdatedAt)
 
 		if err == sql.ErrNoRows {
 			// New reminder - insert
-			err = w.insertTask(ctx, reminder)
-			if err != nil {
-				return nil, fmt.Errorf("failed to insert task: %w", err)
+			result.Plan = append(result.Plan, plannedAction{Action: "insert", Title: reminder.Title, Reason: "new in Apple Reminders"})
+			if !opts.DryRun {
+				if err := w.insertTask(ctx, reminder); err != nil {
+					return result, fmt.Errorf("failed to insert task: %w", err)
+				}
 			}
-			synced++
+			result.Synced++
 		} else if err == nil {
 			// Existing - check if Apple version is newer
 			if reminder.ModifiedAt.After(existingTask.UpdatedAt) {
-				err = w.updateTaskFromApple(ctx, existingTask.ID, reminder)
-				if err != nil {
-					return nil, fmt.Errorf("failed to update task: %w", err)
+				result.Plan = append(result.Plan, plannedAction{Action: "update", Title: reminder.Title, Reason: "modified in Apple Reminders since last update"})
+				if !opts.DryRun {
+					if err := w.updateTaskFromApple(ctx, existingTask.ID, reminder); err != nil {
+						return result, fmt.Errorf("failed to update task: %w", err)
+					}
 				}
-				updated++
+				result.Updated++
 			} else {
-				duplicates++
+				result.Duplicates++
 			}
 		} else {
-			return nil, fmt.Errorf("database error: %w", err)
+			return result, fmt.Errorf("database error: %w", err)
+		}
+	}
+
+	// An empty fetch is more likely a remindctl hiccup than every reminder being deleted
+	if len(reminders) > 0 {
+		removed, err := w.removeOrphans(ctx, opts, seen)
+		if err != nil {
+			return result, err
 		}
+		result.Removed = len(removed)
+		result.Plan = append(result.Plan, removed...)
+	}
+
+	if opts.DryRun {
+		return result, nil
 	}
 
 	// Update sync timestamp
 	w.DB.ExecContext(ctx, "UPDATE tasks SET synced_at = CURRENT_TIMESTAMP WHERE source = 'apple'")
 
-	return json.Marshal(map[string]any{
-		"success":    true,
-		"synced":     synced,
-		"updated":    updated,
-		"duplicates": duplicates,
-		"total":      len(reminders),
-	})
+	return result, nil
+}
+
+// removeOrphans deletes, or marks deleted, Apple-sourced tasks whose reminder was not
+// seen. With a list, only that list's tasks are considered since others weren't fetched.
+func (w *RemindersSyncWorkerState) removeOrphans(ctx context.Context, opts appleSyncOptions, seen map[string]bool) ([]plannedAction, error) {
+	query := "SELECT id, title, external_id FROM tasks WHERE source = 'apple' AND deleted_at IS NULL AND external_id IS NOT NULL AND external_id <> ''"
+	var args []any
+	if opts.List != "" {
+		query += " AND list_name = $1"
+		args = append(args, opts.List)
+	}
+
+	rows, err := w.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query synced tasks: %w", err)
+	}
+	var orphans []int64
+	var titles []string
+	for rows.Next() {
+		var id int64
+		var title, externalID string
+		if err := rows.Scan(&id, &title, &externalID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		if !seen[externalID] {
+			orphans = append(orphans, id)
+			titles = append(titles, title)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query synced tasks: %w", err)
+	}
+
+	action := "mark_deleted"
+	stmt := "UPDATE tasks SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1"
+	if opts.HardDelete {
+		action = "delete"
+		stmt = "DELETE FROM tasks WHERE id = $1"
+	}
+
+	plan := make([]plannedAction, 0, len(orphans))
+	for i, id := range orphans {
+		if !opts.DryRun {
+			if _, err := w.DB.ExecContext(ctx, stmt, id); err != nil {
+				return nil, fmt.Errorf("failed to remove task %d: %w", id, err)
+			}
+		}
+		plan = append(plan, plannedAction{Action: action, Title: titles[i], Reason: "no longer in Apple Reminders"})
+	}
+	return plan, nil
+}
+
+// loadSyncCursor returns the incremental sync high-water mark for a list ("" means
+// all lists), or nil if no incremental sync has run yet
+func (w *RemindersSyncWorkerState) loadSyncCursor(ctx context.Context, list string) (*time.Time, error) {
+	if err := w.ensureCursorTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var highWater time.Time
+	err := w.DB.QueryRowContext(ctx,
+		"SELECT high_water FROM reminders_sync_cursor WHERE list_name = $1",
+		list,
+	).Scan(&highWater)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sync cursor: %w", err)
+	}
+	return &highWater, nil
+}
+
+// saveSyncCursor records the incremental sync high-water mark for a list
+func (w *RemindersSyncWorkerState) saveSyncCursor(ctx context.Context, list string, highWater time.Time) error {
+	if err := w.ensureCursorTable(ctx); err != nil {
+		return err
+	}
+
+	_, err := w.DB.ExecContext(ctx,
+		`INSERT INTO reminders_sync_cursor (list_name, high_water) VALUES ($1, $2)
+		 ON CONFLICT (list_name) DO UPDATE SET high_water = EXCLUDED.high_water`,
+		list, highWater.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save sync cursor: %w", err)
+	}
+	return nil
+}
+
+// ensureCursorTable creates the cursor table; unlike tasks it is owned by this worker
+func (w *RemindersSyncWorkerState) ensureCursorTable(ctx context.Context) error {
+	_, err := w.DB.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS reminders_sync_cursor (
+		list_name TEXT PRIMARY KEY,
+		high_water TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create sync cursor table: %w", err)
+	}
+	return nil
 }
 
 // syncFromDB syncs PostgreSQL tasks to Apple Reminders
@@ -232,10 +513,31 @@ func (w *RemindersSyncWorkerState) syncFromDB(ctx context.Context, input json.Ra
 	}
 
 	var req struct {
-		List string `json:"list"` // optional: specific list to sync to
+		List   string `json:"list"`    // optional: specific list to sync to
+		DryRun bool   `json:"dry_run"` // report planned actions without creating reminders
 	}
 	json.Unmarshal(input, &req)
 
+	synced, errors, plan, err := w.pushNewToApple(ctx, req.List, req.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := map[string]any{
+		"success": true,
+		"synced":  synced,
+		"errors":  errors,
+	}
+	if req.DryRun {
+		resp["dry_run"] = true
+		resp["actions"] = plan
+	}
+	return json.Marshal(resp)
+}
+
+// pushNewToApple creates Apple reminders for local tasks that have never been synced.
+// Per-task failures are collected rather than aborting the run.
+func (w *RemindersSyncWorkerState) pushNewToApple(ctx context.Context, list string, dryRun bool) (int, []string, []plannedAction, error) {
 	// Fetch tasks that need syncing (source = mymcp, no external_id)
 	rows, err := w.DB.QueryContext(ctx,
 		`SELECT id, title, notes, list_name, priority, due_date, completed, completed_at, created_at 
@@ -243,13 +545,11 @@ func (w *RemindersSyncWorkerState) syncFromDB(ctx context.Context, input json.Ra
 		 WHERE (external_id IS NULL OR external_id = '') AND source = 'mymcp'`,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query tasks: %w", err)
+		return 0, nil, nil, fmt.Errorf("failed to query tasks: %w", err)
 	}
-	defer rows.Close()
 
-	synced := 0
 	var errors []string
-
+	var tasks []RemindersTask
 	for rows.Next() {
 		var task RemindersTask
 		var notes, listName, priority sql.NullString
@@ -267,7 +567,7 @@ func (w *RemindersSyncWorkerState) syncFromDB(ctx context.Context, input json.Ra
 		task.Notes = notes.String
 		task.ListName = listName.String
 		if task.ListName == "" {
-			task.ListName = req.List
+			task.ListName = list
 			if task.ListName == "" {
 				task.ListName = "Default"
 			}
@@ -275,6 +575,18 @@ func (w *RemindersSyncWorkerState) syncFromDB(ctx context.Context, input json.Ra
 		task.Priority = priority.String
 		task.DueDate = nullTimeToPtr(dueDate)
 		task.CompletedAt = nullTimeToPtr(completedAt)
+		tasks = append(tasks, task)
+	}
+	rows.Close()
+
+	synced := 0
+	plan := make([]plannedAction, 0, len(tasks))
+	for _, task := range tasks {
+		plan = append(plan, plannedAction{Action: "create_reminder", Title: task.Title, Reason: "not yet in Apple Reminders"})
+		if dryRun {
+			synced++
+			continue
+		}
 
 		// Create in Apple Reminders
 		externalID, err := w.createAppleReminder(ctx, task)
@@ -296,21 +608,162 @@ func (w *RemindersSyncWorkerState) syncFromDB(ctx context.Context, input json.Ra
 		synced++
 	}
 
+	return synced, errors, plan, nil
+}
+
+// Conflict resolution strategies for reminders_sync
+const (
+	StrategyAppleWins  = "apple_wins"
+	StrategyDBWins     = "db_wins"
+	StrategyNewestWins = "newest_wins"
+)
+
+// syncConflict describes a record edited on both sides since its last sync
+type syncConflict struct {
+	ExternalID    string    `json:"external_id"`
+	Title         string    `json:"title"`
+	Winner        string    `json:"winner"` // "apple" or "db"
+	AppleModified time.Time `json:"apple_modified"`
+	DBUpdated     time.Time `json:"db_updated"`
+}
+
+// syncBidirectional reconciles Apple Reminders and the database in one pass. Records
+// changed on only one side flow to the other; records changed on both are conflicts
+// settled by the requested strategy.
+func (w *RemindersSyncWorkerState) syncBidirectional(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	if w.DB == nil {
+		return nil, fmt.Errorf("database not configured")
+	}
+
+	var req struct {
+		List     string `json:"list"`
+		Strategy string `json:"strategy"` // apple_wins, db_wins or newest_wins (default)
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+	if req.Strategy == "" {
+		req.Strategy = StrategyNewestWins
+	}
+	if req.Strategy != StrategyAppleWins && req.Strategy != StrategyDBWins && req.Strategy != StrategyNewestWins {
+		return nil, fmt.Errorf("unknown strategy %q: use %s, %s or %s", req.Strategy, StrategyAppleWins, StrategyDBWins, StrategyNewestWins)
+	}
+
+	reminders, err := w.fetchAppleReminders(ctx, req.List)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Apple Reminders: %w", err)
+	}
+
+	pulled, pushed, unchanged := 0, 0, 0
+	conflicts := make([]syncConflict, 0)
+	var errors []string
+
+	for _, reminder := range reminders {
+		var task RemindersTask
+		var notes, listName, priority sql.NullString
+		var dueDate, completedAt, syncedAt sql.NullTime
+		err := w.DB.QueryRowContext(ctx,
+			`SELECT id, title, notes, list_name, priority, due_date, completed, completed_at, updated_at, synced_at
+			 FROM tasks WHERE external_id = $1`,
+			reminder.ID,
+		).Scan(&task.ID, &task.Title, &notes, &listName, &priority, &dueDate, &task.Completed, &completedAt, &task.UpdatedAt, &syncedAt)
+
+		if err == sql.ErrNoRows {
+			if err := w.insertTask(ctx, reminder); err != nil {
+				return nil, fmt.Errorf("failed to insert task: %w", err)
+			}
+			pulled++
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+
+		task.ExternalID = reminder.ID
+		task.Notes = notes.String
+		task.ListName = listName.String
+		task.Priority = priority.String
+		task.DueDate = nullTimeToPtr(dueDate)
+		task.CompletedAt = nullTimeToPtr(completedAt)
+
+		// Without a sync timestamp there's no baseline, so treat both sides as changed
+		appleChanged := !syncedAt.Valid || reminder.ModifiedAt.After(syncedAt.Time)
+		dbChanged := !syncedAt.Valid || task.UpdatedAt.After(syncedAt.Time)
+
+		useApple := appleChanged
+		if appleChanged && dbChanged {
+			useApple = resolveConflict(req.Strategy, reminder.ModifiedAt, task.UpdatedAt)
+			conflict := syncConflict{
+				ExternalID:    reminder.ID,
+				Title:         task.Title,
+				Winner:        "db",
+				AppleModified: reminder.ModifiedAt,
+				DBUpdated:     task.UpdatedAt,
+			}
+			if useApple {
+				conflict.Winner = "apple"
+			}
+			conflicts = append(conflicts, conflict)
+		} else if !appleChanged && !dbChanged {
+			unchanged++
+			continue
+		}
+
+		if useApple {
+			if err := w.updateTaskFromApple(ctx, task.ID, reminder); err != nil {
+				return nil, fmt.Errorf("failed to update task: %w", err)
+			}
+			pulled++
+			continue
+		}
+
+		if err := w.updateAppleReminder(ctx, task, reminder.Completed); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to update reminder %s: %v", reminder.ID, err))
+			continue
+		}
+		w.DB.ExecContext(ctx, "UPDATE tasks SET synced_at = CURRENT_TIMESTAMP WHERE id = $1", task.ID)
+		pushed++
+	}
+
+	created, pushErrors, _, err := w.pushNewToApple(ctx, req.List, false)
+	if err != nil {
+		return nil, err
+	}
+	errors = append(errors, pushErrors...)
+
 	return json.Marshal(map[string]any{
-		"success": true,
-		"synced":  synced,
-		"errors":  errors,
+		"success":   true,
+		"strategy":  req.Strategy,
+		"pulled":    pulled,
+		"pushed":    pushed,
+		"created":   created,
+		"unchanged": unchanged,
+		"conflicts": conflicts,
+		"errors":    errors,
 	})
 }
 
+// resolveConflict reports whether the Apple side should win under strategy
+func resolveConflict(strategy string, appleModified, dbUpdated time.Time) bool {
+	switch strategy {
+	case StrategyAppleWins:
+		return true
+	case StrategyDBWins:
+		return false
+	default:
+		// Ties go to Apple, matching the one-way sync's long-standing behaviour
+		return !dbUpdated.After(appleModified)
+	}
+}
+
 // createReminder creates a reminder in both Apple Reminders and database
 func (w *RemindersSyncWorkerState) createReminder(ctx context.Context, input json.RawMessage) ([]byte, error) {
 	var req struct {
-		Title    string     `json:"title"`
-		Notes    string     `json:"notes"`
-		List     string     `json:"list"`
-		Priority string     `json:"priority"`
-		DueDate  *time.Time `json:"due_date"`
+		Title      string     `json:"title"`
+		Notes      string     `json:"notes"`
+		List       string     `json:"list"`
+		Priority   string     `json:"priority"`
+		DueDate    *time.Time `json:"due_date"`
+		Recurrence string     `json:"recurrence"` // optional: daily, weekly, monthly or yearly
 	}
 
 	if err := json.Unmarshal(input, &req); err != nil {
@@ -321,17 +774,24 @@ func (w *RemindersSyncWorkerState) createReminder(ctx context.Context, input jso
 		return nil, fmt.Errorf("title is required")
 	}
 
+	// Reject before touching Apple so a bad rule never leaves a half-made reminder
+	req.Recurrence = strings.ToLower(strings.TrimSpace(req.Recurrence))
+	if req.Recurrence != "" && !recurrences[req.Recurrence] {
+		return nil, fmt.Errorf("unsupported recurrence %q: remindctl supports daily, weekly, monthly and yearly", req.Recurrence)
+	}
+
 	if req.List == "" {
 		req.List = "Default"
 	}
 
 	// Create in Apple Reminders first
 	task := RemindersTask{
-		Title:    req.Title,
-		Notes:    req.Notes,
-		ListName: req.List,
-		Priority: req.Priority,
-		DueDate:  req.DueDate,
+		Title:      req.Title,
+		Notes:      req.Notes,
+		ListName:   req.List,
+		Priority:   req.Priority,
+		DueDate:    req.DueDate,
+		Recurrence: req.Recurrence,
 	}
 
 	externalID, err := w.createAppleReminder(ctx, task)
@@ -346,10 +806,10 @@ func (w *RemindersSyncWorkerState) createReminder(ctx context.Context, input jso
 	if w.DB != nil {
 		var id int64
 		err = w.DB.QueryRowContext(ctx,
-			`INSERT INTO tasks (title, notes, list_name, priority, due_date, external_id, source, synced_at)
-			 VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+			`INSERT INTO tasks (title, notes, list_name, priority, due_date, external_id, source, recurrence, synced_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP)
 			 RETURNING id`,
-			task.Title, task.Notes, task.ListName, task.Priority, task.DueDate, task.ExternalID, task.Source,
+			task.Title, task.Notes, task.ListName, task.Priority, task.DueDate, task.ExternalID, task.Source, nullIfEmpty(task.Recurrence),
 		).Scan(&id)
 		if err != nil {
 			// Don't fail - Apple reminder was created, just log
@@ -368,6 +828,7 @@ func (w *RemindersSyncWorkerState) createReminder(ctx context.Context, input jso
 		"external_id": externalID,
 		"title":       task.Title,
 		"list":        task.ListName,
+		"recurrence":  task.Recurrence,
 	})
 }
 
@@ -449,7 +910,7 @@ func (w *RemindersSyncWorkerState) listReminders(ctx context.Context, input json
 		req.Limit = 100
 	}
 
-	query := "SELECT id, title, notes, list_name, priority, due_date, completed, completed_at, external_id, source, created_at FROM tasks WHERE 1=1"
+	query := "SELECT id, title, notes, list_name, priority, due_date, completed, completed_at, external_id, source, recurrence, created_at FROM tasks WHERE deleted_at IS NULL"
 	var args []any
 	argNum := 1
 
@@ -477,12 +938,12 @@ func (w *RemindersSyncWorkerState) listReminders(ctx context.Context, input json
 	var tasks []RemindersTask
 	for rows.Next() {
 		var task RemindersTask
-		var notes, listName, priority, externalID, source sql.NullString
+		var notes, listName, priority, externalID, source, recurrence sql.NullString
 		var dueDate, completedAt sql.NullTime
 
 		err := rows.Scan(
 			&task.ID, &task.Title, &notes, &listName, &priority,
-			&dueDate, &task.Completed, &completedAt, &externalID, &source, &task.CreatedAt,
+			&dueDate, &task.Completed, &completedAt, &externalID, &source, &recurrence, &task.CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan task: %w", err)
@@ -495,6 +956,7 @@ func (w *RemindersSyncWorkerState) listReminders(ctx context.Context, input json
 		task.CompletedAt = nullTimeToPtr(completedAt)
 		task.ExternalID = externalID.String
 		task.Source = source.String
+		task.Recurrence = recurrence.String
 
 		tasks = append(tasks, task)
 	}
@@ -568,13 +1030,97 @@ func (w *RemindersSyncWorkerState) syncStatus(ctx context.Context, input json.Ra
 	return json.Marshal(status)
 }
 
+// ReminderList is an Apple Reminders list. Count is -1 when remindctl doesn't report it.
+type ReminderList struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// listLists enumerates the Apple Reminders lists
+func (w *RemindersSyncWorkerState) listLists(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	output, err := w.runRemindctl(ctx, "list", "--json")
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := parseReminderLists(output)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]any{
+		"lists": lists,
+		"count": len(lists),
+	})
+}
+
+// parseReminderLists accepts remindctl's list output either as a bare array or
+// wrapped in {"lists": [...]}
+func parseReminderLists(output []byte) ([]ReminderList, error) {
+	type rawList struct {
+		Title         string `json:"title"`
+		Name          string `json:"name"`
+		Count         *int   `json:"count"`
+		ReminderCount *int   `json:"reminderCount"`
+	}
+
+	var raw []rawList
+	if err := json.Unmarshal(output, &raw); err != nil {
+		var wrapped struct {
+			Lists []rawList `json:"lists"`
+		}
+		if err := json.Unmarshal(output, &wrapped); err != nil {
+			return nil, fmt.Errorf("failed to parse remindctl output: %w", err)
+		}
+		raw = wrapped.Lists
+	}
+
+	lists := make([]ReminderList, 0, len(raw))
+	for _, r := range raw {
+		list := ReminderList{Name: r.Name, Count: -1}
+		if list.Name == "" {
+			list.Name = r.Title
+		}
+		if r.Count != nil {
+			list.Count = *r.Count
+		} else if r.ReminderCount != nil {
+			list.Count = *r.ReminderCount
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// createList creates a new Apple Reminders list
+func (w *RemindersSyncWorkerState) createList(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	if _, err := w.runRemindctl(ctx, "list", "--create", "--json", "--", sanitizeArg(req.Name)); err != nil {
+		return nil, fmt.Errorf("failed to create list: %w", err)
+	}
+
+	return json.Marshal(map[string]any{
+		"success": true,
+		"name":    req.Name,
+	})
+}
+
 // --- Apple Reminders CLI helpers ---
 
 // fetchAppleReminders fetches all reminders from Apple Reminders via remindctl
 func (w *RemindersSyncWorkerState) fetchAppleReminders(ctx context.Context, list string) ([]AppleReminder, error) {
 	args := []string{"show", "all", "--json"}
 	if list != "" {
-		args = []string{"show", "--list", list, "--json"}
+		args = append([]string{"show"}, flagValue("--list", list)...)
+		args = append(args, "--json")
 	}
 
 	output, err := w.runRemindctl(ctx, args...)
@@ -612,18 +1158,9 @@ func (w *RemindersSyncWorkerState) fetchAppleReminders(ctx context.Context, list
 			listName = r.ListName
 		}
 
-		priority := "none"
+		priority := priorityFromApple(r.Priority)
 		if r.PriorityStr != "" {
 			priority = strings.ToLower(r.PriorityStr)
-		} else if r.Priority > 0 {
-			switch r.Priority {
-			case 1:
-				priority = "high"
-			case 5:
-				priority = "medium"
-			case 9:
-				priority = "low"
-			}
 		}
 
 		reminder := AppleReminder{
@@ -656,20 +1193,24 @@ func (w *RemindersSyncWorkerState) fetchAppleReminders(ctx context.Context, list
 
 // createAppleReminder creates a reminder in Apple Reminders
 func (w *RemindersSyncWorkerState) createAppleReminder(ctx context.Context, task RemindersTask) (string, error) {
-	args := []string{"add", "--json", "--title", task.Title}
+	// The title is always joined with "=", so one like "--list pwned" can't become a flag
+	args := []string{"add", "--json", "--title=" + sanitizeArg(task.Title)}
 
 	if task.ListName != "" {
-		args = append(args, "--list", task.ListName)
+		args = append(args, flagValue("--list", task.ListName)...)
 	}
 	if task.Notes != "" {
-		args = append(args, "--notes", task.Notes)
+		args = append(args, flagValue("--notes", task.Notes)...)
 	}
-	if task.Priority != "" && task.Priority != "none" {
-		args = append(args, "--priority", task.Priority)
+	if p := priorityToApple(task.Priority); p != "0" {
+		args = append(args, "--priority", p)
 	}
 	if task.DueDate != nil {
 		args = append(args, "--due", task.DueDate.Format("2006-01-02"))
 	}
+	if task.Recurrence != "" {
+		args = append(args, "--repeat", task.Recurrence)
+	}
 
 	output, err := w.runRemindctl(ctx, args...)
 	if err != nil {
@@ -694,6 +1235,30 @@ func (w *RemindersSyncWorkerState) createAppleReminder(ctx context.Context, task
 	return fmt.Sprintf("created_%d", time.Now().UnixNano()), nil
 }
 
+// updateAppleReminder pushes a task's fields to its existing Apple reminder
+func (w *RemindersSyncWorkerState) updateAppleReminder(ctx context.Context, task RemindersTask, appleCompleted bool) error {
+	args := []string{"edit", task.ExternalID, "--json"}
+	args = append(args, flagValue("--title", task.Title)...)
+	args = append(args, flagValue("--notes", task.Notes)...)
+
+	if task.ListName != "" {
+		args = append(args, flagValue("--list", task.ListName)...)
+	}
+	// Always sent so clearing the priority locally clears it in Apple too
+	args = append(args, "--priority", priorityToApple(task.Priority))
+	if task.DueDate != nil {
+		args = append(args, "--due", task.DueDate.Format("2006-01-02"))
+	}
+
+	if _, err := w.runRemindctl(ctx, args...); err != nil {
+		return err
+	}
+	if task.Completed && !appleCompleted {
+		return w.completeAppleReminder(ctx, task.ExternalID)
+	}
+	return nil
+}
+
 // completeAppleReminder marks a reminder as complete
 func (w *RemindersSyncWorkerState) completeAppleReminder(ctx context.Context, externalID string) error {
 	_, err := w.runRemindctl(ctx, "complete", "--json", externalID)
@@ -702,17 +1267,88 @@ func (w *RemindersSyncWorkerState) completeAppleReminder(ctx context.Context, ex
 
 // runRemindctl executes the remindctl CLI
 func (w *RemindersSyncWorkerState) runRemindctl(ctx context.Context, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(w.remindctlPath); err != nil {
+		return nil, fmt.Errorf("remindctl not found at %s: install it or set remindctl_path", w.remindctlPath)
+	}
+
+	var stderr bytes.Buffer
 	cmd := exec.CommandContext(ctx, w.remindctlPath, args...)
+	cmd.Stderr = &stderr
 	output, err := cmd.Output()
 	if err != nil {
+		command := "remindctl"
+		if len(args) > 0 {
+			command += " " + args[0]
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("remindctl error: %s", string(exitErr.Stderr))
+			// Some remindctl errors are printed to stdout, so fall back to it
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = strings.TrimSpace(string(output))
+			}
+			if msg == "" {
+				msg = "no output"
+			}
+			return nil, fmt.Errorf("%s exited with status %d: %s", command, exitErr.ExitCode(), msg)
 		}
-		return nil, fmt.Errorf("remindctl failed: %w", err)
+		return nil, fmt.Errorf("%s failed: %w", command, err)
 	}
 	return output, nil
 }
 
+// flagValue returns a remindctl option and its value. A value starting with a dash is
+// joined with "=" so it can't be read as a flag of its own.
+func flagValue(flag, value string) []string {
+	value = sanitizeArg(value)
+	if strings.HasPrefix(value, "-") {
+		return []string{flag + "=" + value}
+	}
+	return []string{flag, value}
+}
+
+// sanitizeArg collapses line breaks, which remindctl mishandles in titles and notes
+func sanitizeArg(value string) string {
+	return strings.TrimSpace(strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(value))
+}
+
+// Apple Reminders stores priority as an integer, as EventKit does:
+//
+//	0    none
+//	1-4  high   (written as 1)
+//	5    medium
+//	6-9  low    (written as 9)
+//
+// Tasks use the names. priorityToApple and priorityFromApple convert between the two
+// so a task survives a round trip through Apple unchanged.
+
+// priorityToApple converts a task priority name to remindctl's numeric scale
+func priorityToApple(priority string) string {
+	switch strings.ToLower(priority) {
+	case "high":
+		return "1"
+	case "medium":
+		return "5"
+	case "low":
+		return "9"
+	default:
+		return "0"
+	}
+}
+
+// priorityFromApple converts an Apple numeric priority to a task priority name
+func priorityFromApple(priority int) string {
+	switch {
+	case priority >= 1 && priority <= 4:
+		return "high"
+	case priority == 5:
+		return "medium"
+	case priority >= 6 && priority <= 9:
+		return "low"
+	default:
+		return "none"
+	}
+}
+
 // filterReminders filters reminders based on filter type
 func (w *RemindersSyncWorkerState) filterReminders(reminders []AppleReminder, filter string) []AppleReminder {
 	now := time.Now()
@@ -787,6 +1423,22 @@ func (w *RemindersSyncWorkerState) updateTaskFromApple(ctx context.Context, task
 	return err
 }
 
+// recurrences are the repeat rules remindctl's --repeat flag accepts
+var recurrences = map[string]bool{
+	"daily":   true,
+	"weekly":  true,
+	"monthly": true,
+	"yearly":  true,
+}
+
+// nullIfEmpty stores empty strings as NULL
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 // nullTimeToPtr converts sql.NullTime to *time.Time
 func nullTimeToPtr(nt sql.NullTime) *time.Time {
 	if nt.Valid {
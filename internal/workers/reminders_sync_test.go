@@ -0,0 +1,416 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTasksSchema is the tasks table before the worker's migrations, in SQLite syntax
+const testTasksSchema = `
+CREATE TABLE tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	notes TEXT,
+	list_name TEXT DEFAULT 'Default',
+	priority TEXT DEFAULT 'none',
+	due_date TIMESTAMP,
+	completed BOOLEAN DEFAULT FALSE,
+	completed_at TIMESTAMP,
+	external_id TEXT UNIQUE,
+	source TEXT DEFAULT 'mymcp',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	synced_at TIMESTAMP
+)`
+
+// fakeRemindctl is a stand-in remindctl script that prints a canned response
+// and records its arguments, one per line
+type fakeRemindctl struct {
+	path     string
+	output   string
+	argsFile string
+}
+
+func newFakeRemindctl(t *testing.T) *fakeRemindctl {
+	dir := t.TempDir()
+	f := &fakeRemindctl{
+		path:     filepath.Join(dir, "remindctl"),
+		output:   filepath.Join(dir, "output.json"),
+		argsFile: filepath.Join(dir, "args.txt"),
+	}
+	script := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" > %q\ncat %q\n", f.argsFile, f.output)
+	require.NoError(t, os.WriteFile(f.path, []byte(script), 0755))
+	f.respond(t, `{"reminders":[]}`)
+	return f
+}
+
+func (f *fakeRemindctl) respond(t *testing.T, output string) {
+	require.NoError(t, os.WriteFile(f.output, []byte(output), 0644))
+}
+
+func newTestRemindersWorker(t *testing.T) (*RemindersSyncWorkerState, *fakeRemindctl) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(testTasksSchema)
+	require.NoError(t, err)
+
+	fake := newFakeRemindctl(t)
+	w, err := NewRemindersSyncWorkerFromDB(db, RemindersConfig{RemindctlPath: fake.path})
+	require.NoError(t, err)
+	return w, fake
+}
+
+func TestNewRemindersSyncWorkerFromDB_MigratesTasksTable(t *testing.T) {
+	w, _ := newTestRemindersWorker(t)
+
+	_, err := w.DB.Exec("SELECT deleted_at, recurrence FROM tasks")
+	require.NoError(t, err)
+
+	// Migrating an up-to-date table is a no-op
+	_, err = NewRemindersSyncWorkerFromDB(w.DB, RemindersConfig{RemindctlPath: w.remindctlPath})
+	require.NoError(t, err)
+}
+
+func TestRemindersSyncIncremental(t *testing.T) {
+	w, fake := newTestRemindersWorker(t)
+	ctx := context.Background()
+
+	fake.respond(t, `{"reminders":[
+		{"id":"a","title":"Call bank","list":"Home","modificationDate":"2024-01-01T10:00:00Z"},
+		{"id":"b","title":"File taxes","list":"Home","modificationDate":"2024-02-01T10:00:00Z"}
+	]}`)
+
+	var resp struct {
+		Synced  int `json:"synced"`
+		Updated int `json:"updated"`
+		Skipped int `json:"skipped"`
+	}
+
+	out, err := w.Execute(ctx, "reminders_sync_incremental", []byte(`{}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 2, resp.Synced)
+	assert.Equal(t, 0, resp.Skipped)
+
+	// Nothing changed since the cursor, so nothing is examined
+	out, err = w.Execute(ctx, "reminders_sync_incremental", []byte(`{}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 0, resp.Synced)
+	assert.Equal(t, 2, resp.Skipped)
+
+	fake.respond(t, `{"reminders":[
+		{"id":"a","title":"Call bank","list":"Home","modificationDate":"2024-01-01T10:00:00Z"},
+		{"id":"b","title":"File taxes today","list":"Home","modificationDate":"2099-01-01T10:00:00Z"}
+	]}`)
+	out, err = w.Execute(ctx, "reminders_sync_incremental", []byte(`{}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 1, resp.Updated)
+	assert.Equal(t, 1, resp.Skipped)
+
+	var title string
+	require.NoError(t, w.DB.QueryRow("SELECT title FROM tasks WHERE external_id = 'b'").Scan(&title))
+	assert.Equal(t, "File taxes today", title)
+}
+
+func TestRemindersSyncToDB_RemovesDeleted(t *testing.T) {
+	w, fake := newTestRemindersWorker(t)
+	ctx := context.Background()
+
+	fake.respond(t, `{"reminders":[
+		{"id":"a","title":"Call bank","list":"Home"},
+		{"id":"b","title":"File taxes","list":"Home"},
+		{"id":"c","title":"Ship release","list":"Work"}
+	]}`)
+	_, err := w.Execute(ctx, "reminders_sync_to_db", []byte(`{}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Removed int `json:"removed"`
+	}
+
+	// Syncing only Home must not touch the Work reminder it didn't fetch
+	fake.respond(t, `{"reminders":[{"id":"a","title":"Call bank","list":"Home"}]}`)
+	out, err := w.Execute(ctx, "reminders_sync_to_db", []byte(`{"list":"Home"}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 1, resp.Removed)
+
+	var deleted sql.NullTime
+	require.NoError(t, w.DB.QueryRow("SELECT deleted_at FROM tasks WHERE external_id = 'b'").Scan(&deleted))
+	assert.True(t, deleted.Valid)
+	require.NoError(t, w.DB.QueryRow("SELECT deleted_at FROM tasks WHERE external_id = 'c'").Scan(&deleted))
+	assert.False(t, deleted.Valid)
+
+	fake.respond(t, `{"reminders":[{"id":"a","title":"Call bank","list":"Home"}]}`)
+	out, err = w.Execute(ctx, "reminders_sync_to_db", []byte(`{"hard_delete":true}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 1, resp.Removed)
+
+	var count int
+	require.NoError(t, w.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE external_id = 'c'").Scan(&count))
+	assert.Zero(t, count)
+}
+
+func TestRemindersSyncBidirectional_Strategies(t *testing.T) {
+	tests := []struct {
+		strategy  string
+		winner    string
+		wantTitle string
+	}{
+		{strategy: "", winner: "db", wantTitle: "Local title"}, // newest_wins: the DB edit is later
+		{strategy: "newest_wins", winner: "db", wantTitle: "Local title"},
+		{strategy: "db_wins", winner: "db", wantTitle: "Local title"},
+		{strategy: "apple_wins", winner: "apple", wantTitle: "Apple title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			w, fake := newTestRemindersWorker(t)
+			ctx := context.Background()
+
+			_, err := w.DB.Exec(`INSERT INTO tasks (title, list_name, external_id, source, updated_at, synced_at)
+				VALUES ('Local title', 'Home', 'a', 'apple', '2024-03-01 00:00:00', '2024-01-01 00:00:00')`)
+			require.NoError(t, err)
+			fake.respond(t, `{"reminders":[{"id":"a","title":"Apple title","list":"Home","modificationDate":"2024-02-01T00:00:00Z"}]}`)
+
+			input, _ := json.Marshal(map[string]string{"strategy": tt.strategy})
+			out, err := w.Execute(ctx, "reminders_sync", input)
+			require.NoError(t, err)
+
+			var resp struct {
+				Conflicts []syncConflict `json:"conflicts"`
+			}
+			require.NoError(t, json.Unmarshal(out, &resp))
+			require.Len(t, resp.Conflicts, 1)
+			assert.Equal(t, tt.winner, resp.Conflicts[0].Winner)
+
+			var title string
+			require.NoError(t, w.DB.QueryRow("SELECT title FROM tasks WHERE external_id = 'a'").Scan(&title))
+			assert.Equal(t, tt.wantTitle, title)
+
+			if tt.winner == "db" {
+				args, err := os.ReadFile(fake.argsFile)
+				require.NoError(t, err)
+				assert.Contains(t, string(args), "edit\na\n")
+				assert.Contains(t, string(args), "Local title")
+			}
+		})
+	}
+}
+
+func TestRemindersSyncBidirectional_UnknownStrategy(t *testing.T) {
+	w, _ := newTestRemindersWorker(t)
+	_, err := w.Execute(context.Background(), "reminders_sync", []byte(`{"strategy":"coin_flip"}`))
+	assert.ErrorContains(t, err, "unknown strategy")
+}
+
+func TestReminderPriorityRoundTrip(t *testing.T) {
+	for _, priority := range []string{"none", "low", "medium", "high"} {
+		t.Run(priority, func(t *testing.T) {
+			apple := priorityToApple(priority)
+			n, err := strconv.Atoi(apple)
+			require.NoError(t, err)
+			assert.Equal(t, priority, priorityFromApple(n))
+		})
+	}
+
+	assert.Equal(t, "0", priorityToApple(""))
+	assert.Equal(t, "high", priorityFromApple(3))
+	assert.Equal(t, "low", priorityFromApple(7))
+}
+
+func TestRemindersCreate_SendsNumericPriority(t *testing.T) {
+	w, fake := newTestRemindersWorker(t)
+	fake.respond(t, `{"reminders":[{"id":"new-1"}]}`)
+
+	_, err := w.Execute(context.Background(), "reminders_create", []byte(`{"title":"Pay rent","priority":"medium"}`))
+	require.NoError(t, err)
+
+	args, err := os.ReadFile(fake.argsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "--priority\n5\n")
+
+	// Reading it back from Apple yields the same name
+	fake.respond(t, `{"reminders":[{"id":"new-1","title":"Pay rent","priority":5}]}`)
+	reminders, err := w.fetchAppleReminders(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.Equal(t, "medium", reminders[0].Priority)
+}
+
+func TestRemindersListLists(t *testing.T) {
+	w, fake := newTestRemindersWorker(t)
+
+	var resp struct {
+		Lists []ReminderList `json:"lists"`
+	}
+
+	fake.respond(t, `[{"title":"Home","reminderCount":4},{"title":"Work"}]`)
+	out, err := w.Execute(context.Background(), "reminders_list_lists", []byte(`{}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, []ReminderList{{Name: "Home", Count: 4}, {Name: "Work", Count: -1}}, resp.Lists)
+
+	fake.respond(t, `{"lists":[{"name":"Errands","count":2}]}`)
+	out, err = w.Execute(context.Background(), "reminders_list_lists", []byte(`{}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, []ReminderList{{Name: "Errands", Count: 2}}, resp.Lists)
+}
+
+func TestRemindersCreateList(t *testing.T) {
+	w, fake := newTestRemindersWorker(t)
+
+	_, err := w.Execute(context.Background(), "reminders_create_list", []byte(`{"name":"Groceries"}`))
+	require.NoError(t, err)
+
+	args, err := os.ReadFile(fake.argsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "list\n--create\n--json\n--\nGroceries\n", string(args))
+
+	_, err = w.Execute(context.Background(), "reminders_create_list", []byte(`{"name":" "}`))
+	assert.ErrorContains(t, err, "name is required")
+}
+
+func TestRemindersMissingRemindctl(t *testing.T) {
+	w, err := NewRemindersSyncWorker(RemindersConfig{RemindctlPath: filepath.Join(t.TempDir(), "remindctl")})
+	require.NoError(t, err)
+
+	_, err = w.Execute(context.Background(), "reminders_list_lists", []byte(`{}`))
+	assert.ErrorContains(t, err, "remindctl not found")
+}
+
+func TestRemindersSync_DryRunLeavesDBUntouched(t *testing.T) {
+	w, fake := newTestRemindersWorker(t)
+	ctx := context.Background()
+
+	_, err := w.DB.Exec(`INSERT INTO tasks (title, external_id, source, updated_at) VALUES
+		('Old title', 'b', 'apple', '2024-01-01 00:00:00'),
+		('Gone from Apple', 'x', 'apple', '2024-01-01 00:00:00')`)
+	require.NoError(t, err)
+	_, err = w.DB.Exec(`INSERT INTO tasks (title, source) VALUES ('Local only', 'mymcp')`)
+	require.NoError(t, err)
+
+	snapshot := func() string {
+		rows, err := w.DB.Query("SELECT id, title, COALESCE(external_id, ''), deleted_at IS NULL, synced_at IS NULL FROM tasks ORDER BY id")
+		require.NoError(t, err)
+		defer rows.Close()
+		var out string
+		for rows.Next() {
+			var id int
+			var title, externalID string
+			var notDeleted, notSynced bool
+			require.NoError(t, rows.Scan(&id, &title, &externalID, &notDeleted, &notSynced))
+			out += fmt.Sprintf("%d|%s|%s|%v|%v\n", id, title, externalID, notDeleted, notSynced)
+		}
+		return out
+	}
+	before := snapshot()
+
+	fake.respond(t, `{"reminders":[
+		{"id":"a","title":"New in Apple","modificationDate":"2024-02-01T00:00:00Z"},
+		{"id":"b","title":"New title","modificationDate":"2024-02-01T00:00:00Z"}
+	]}`)
+
+	var resp struct {
+		DryRun  bool            `json:"dry_run"`
+		Actions []plannedAction `json:"actions"`
+	}
+
+	out, err := w.Execute(ctx, "reminders_sync_to_db", []byte(`{"dry_run":true}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.True(t, resp.DryRun)
+	require.Len(t, resp.Actions, 3)
+	assert.Equal(t, plannedAction{Action: "insert", Title: "New in Apple", Reason: "new in Apple Reminders"}, resp.Actions[0])
+	assert.Equal(t, "update", resp.Actions[1].Action)
+	assert.Equal(t, "New title", resp.Actions[1].Title)
+	assert.Equal(t, plannedAction{Action: "mark_deleted", Title: "Gone from Apple", Reason: "no longer in Apple Reminders"}, resp.Actions[2])
+
+	out, err = w.Execute(ctx, "reminders_sync_from_db", []byte(`{"dry_run":true}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.Len(t, resp.Actions, 1)
+	assert.Equal(t, plannedAction{Action: "create_reminder", Title: "Local only", Reason: "not yet in Apple Reminders"}, resp.Actions[0])
+
+	assert.Equal(t, before, snapshot())
+
+	// The last remindctl call was the fetch; no reminder was created
+	args, err := os.ReadFile(fake.argsFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(args), "add")
+}
+
+func TestRemindersCreate_FlagLikeTitleIsLiteral(t *testing.T) {
+	w, fake := newTestRemindersWorker(t)
+	fake.respond(t, `{"reminders":[{"id":"new-1"}]}`)
+
+	_, err := w.Execute(context.Background(), "reminders_create", []byte(`{"title":"--list pwned","notes":"-x\nsecond line"}`))
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(fake.argsFile)
+	require.NoError(t, err)
+	args := strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+
+	assert.Equal(t, []string{"add", "--json", "--title=--list pwned", "--list", "Default", "--notes=-x second line"}, args)
+}
+
+func TestRunRemindctl_SurfacesStderr(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remindctl")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\necho 'list not found: Work' >&2\nexit 3\n"), 0755))
+
+	w, err := NewRemindersSyncWorker(RemindersConfig{RemindctlPath: path})
+	require.NoError(t, err)
+
+	_, err = w.runRemindctl(context.Background(), "show", "--list", "Work")
+	assert.EqualError(t, err, "remindctl show exited with status 3: list not found: Work")
+}
+
+func TestRemindersCreate_Recurrence(t *testing.T) {
+	w, fake := newTestRemindersWorker(t)
+	ctx := context.Background()
+	fake.respond(t, `{"reminders":[{"id":"new-1"}]}`)
+
+	_, err := w.Execute(ctx, "reminders_create", []byte(`{"title":"Water plants","recurrence":"Weekly"}`))
+	require.NoError(t, err)
+
+	args, err := os.ReadFile(fake.argsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "--repeat\nweekly\n")
+
+	out, err := w.Execute(ctx, "reminders_list", []byte(`{}`))
+	require.NoError(t, err)
+	var resp struct {
+		Tasks []RemindersTask `json:"tasks"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	require.Len(t, resp.Tasks, 1)
+	assert.Equal(t, "weekly", resp.Tasks[0].Recurrence)
+}
+
+func TestRemindersCreate_UnsupportedRecurrence(t *testing.T) {
+	w, fake := newTestRemindersWorker(t)
+
+	_, err := w.Execute(context.Background(), "reminders_create", []byte(`{"title":"Stretch","recurrence":"hourly"}`))
+	assert.ErrorContains(t, err, `unsupported recurrence "hourly"`)
+
+	// remindctl was never invoked
+	_, err = os.Stat(fake.argsFile)
+	assert.True(t, os.IsNotExist(err))
+}
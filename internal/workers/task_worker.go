@@ -4,11 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"strings"
+	"sync"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // DBTask represents a task in the PostgreSQL database
@@ -36,6 +39,34 @@ type DBTask struct {
 	AppleReminderID string     `json:"apple_reminder_id,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"` // set when soft-deleted
+	ParentID        string     `json:"parent_id,omitempty"`  // set on subtasks
+	DependsOn       []string   `json:"depends_on,omitempty"` // ids of tasks that block this one
+}
+
+// taskColumns are the columns scanDBTask reads, in order
+const taskColumns = `id, title, description, client, project, email_subject, email_from, email_id,
+	due_date, status, priority, urgency, assigned_agent, source,
+	estimated_hours, actual_hours, hourly_rate, billing_status,
+	tags, document_refs, apple_reminder_id, created_at, updated_at, deleted_at,
+	parent_id, depends_on`
+
+// taskMigrations bring an existing tasks table up to date; each must be idempotent
+var taskMigrations = []string{
+	`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+	`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_id TEXT`,
+	`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS depends_on TEXT[]`,
+	`CREATE TABLE IF NOT EXISTS time_entries (
+		id SERIAL PRIMARY KEY,
+		task_id TEXT NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		ended_at TIMESTAMP,
+		duration_minutes INTEGER,
+		description TEXT,
+		agent_id TEXT
+	)`,
+	// At most one running timer per task
+	`CREATE UNIQUE INDEX IF NOT EXISTS time_entries_running_idx ON time_entries (task_id) WHERE ended_at IS NULL`,
 }
 
 // Task is an alias for DBTask for backwards compatibility
@@ -43,7 +74,66 @@ type Task = DBTask
 
 // TaskWorker manages task operations with PostgreSQL
 type TaskWorker struct {
-	db *sql.DB
+	db                 *sql.DB
+	EnforceTransitions bool // reject status changes not listed in taskTransitions
+	fullTextIndexOnce  sync.Once
+}
+
+// taskSearchVector is the document full-text search matches against. The GIN index
+// created by ensureFullTextIndex is on this exact expression, so keep them in sync.
+const taskSearchVector = `to_tsvector('english', coalesce(title, '') || ' ' || coalesce(description, ''))`
+
+// ensureFullTextIndex creates the GIN index behind fulltext search on first use.
+// Search still works without it, only slower, so a failure is logged rather than returned.
+func (w *TaskWorker) ensureFullTextIndex(ctx context.Context) {
+	w.fullTextIndexOnce.Do(func() {
+		query := "CREATE INDEX IF NOT EXISTS tasks_fulltext_idx ON tasks USING GIN (" + taskSearchVector + ")"
+		if _, err := w.db.ExecContext(ctx, query); err != nil {
+			fmt.Printf("Warning: failed to create full-text index: %v\n", err)
+		}
+	})
+}
+
+// ErrStaleTask is returned when an update's expected_updated_at no longer matches,
+// meaning someone else changed the task since the caller read it
+var ErrStaleTask = errors.New("task was modified concurrently; re-read it and retry")
+
+// TaskStatuses are the statuses a task may have; the standup report filters on them
+var TaskStatuses = []string{"open", "in_progress", "blocked", "completed", "cancelled"}
+
+// taskTransitions lists the statuses each status may move to when transitions are
+// enforced. Finished tasks can only be reopened.
+var taskTransitions = map[string][]string{
+	"open":        {"in_progress", "blocked", "completed", "cancelled"},
+	"in_progress": {"open", "blocked", "completed", "cancelled"},
+	"blocked":     {"open", "in_progress", "completed", "cancelled"},
+	"completed":   {"open"},
+	"cancelled":   {"open"},
+}
+
+// validateTaskStatus rejects statuses outside TaskStatuses
+func validateTaskStatus(status string) error {
+	for _, s := range TaskStatuses {
+		if status == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid status %q: must be one of %s", status, strings.Join(TaskStatuses, ", "))
+}
+
+// validateTaskTransition rejects moves not allowed by taskTransitions. Unchanged
+// statuses and unrecognised current statuses (legacy rows) are let through.
+func validateTaskTransition(from, to string) error {
+	allowed, known := taskTransitions[from]
+	if from == to || !known {
+		return nil
+	}
+	for _, s := range allowed {
+		if s == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot change status from %s to %s", from, to)
 }
 
 // NewTaskWorker creates a new TaskWorker with PostgreSQL connection
@@ -58,7 +148,21 @@ func NewTaskWorker(dbURL string) (*TaskWorker, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &TaskWorker{db: db}, nil
+	w := &TaskWorker{db: db}
+	if err := w.migrate(context.Background()); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// migrate applies taskMigrations
+func (w *TaskWorker) migrate(ctx context.Context) error {
+	for _, stmt := range taskMigrations {
+		if _, err := w.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to migrate tasks table: %w", err)
+		}
+	}
+	return nil
 }
 
 // NewTaskWorkerFromDB creates a TaskWorker from an existing DB connection
@@ -75,11 +179,17 @@ func (w *TaskWorker) Close() error {
 func (w *TaskWorker) GetTools() []ToolDef {
 	return []ToolDef{
 		{Name: "task_create", Description: "Create a new task with title, description, and optional fields"},
-		{Name: "task_search", Description: "Search tasks by various criteria (title, client, status, tags, date range)"},
+		{Name: "task_search", Description: "Search tasks by various criteria (title, client, status, tags, date range), optionally ranked by full-text relevance, with offset or after_id paging"},
 		{Name: "task_update", Description: "Update an existing task by ID"},
-		{Name: "task_delete", Description: "Delete a task by ID"},
+		{Name: "task_delete", Description: "Delete a task by ID (soft delete unless hard is set)"},
+		{Name: "task_restore", Description: "Restore a soft-deleted task by ID"},
 		{Name: "task_list", Description: "List tasks with optional filtering and pagination"},
 		{Name: "task_assign", Description: "Assign a task to an agent/user"},
+		{Name: "task_add_dependency", Description: "Mark a task as blocked by another task"},
+		{Name: "task_tree", Description: "Get a task with its subtasks nested"},
+		{Name: "task_timer_start", Description: "Start tracking time on a task"},
+		{Name: "task_timer_stop", Description: "Stop a task's running timer and add the time to its actual hours"},
+		{Name: "task_time_report", Description: "Summarize tracked minutes per task over a date range"},
 	}
 }
 
@@ -94,10 +204,22 @@ func (w *TaskWorker) Execute(ctx context.Context, name string, input json.RawMes
 		return w.updateTask(ctx, input)
 	case "task_delete", "task_task_delete":
 		return w.deleteTask(ctx, input)
+	case "task_restore", "task_task_restore":
+		return w.restoreTask(ctx, input)
 	case "task_list", "task_task_list":
 		return w.listTasks(ctx, input)
 	case "task_assign", "task_task_assign":
 		return w.assignTask(ctx, input)
+	case "task_add_dependency", "task_task_add_dependency":
+		return w.addDependency(ctx, input)
+	case "task_tree", "task_task_tree":
+		return w.taskTree(ctx, input)
+	case "task_timer_start", "task_task_timer_start":
+		return w.startTimer(ctx, input)
+	case "task_timer_stop", "task_task_timer_stop":
+		return w.stopTimer(ctx, input)
+	case "task_time_report", "task_task_time_report":
+		return w.timeReport(ctx, input)
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", name)
 	}
@@ -105,23 +227,24 @@ func (w *TaskWorker) Execute(ctx context.Context, name string, input json.RawMes
 
 // CreateTaskInput defines input for creating a task
 type CreateTaskInput struct {
-	Title          string    `json:"title"`
-	Description    string    `json:"description,omitempty"`
-	Client         string    `json:"client,omitempty"`
-	Project        string    `json:"project,omitempty"`
-	EmailSubject   string    `json:"email_subject,omitempty"`
-	EmailFrom      string    `json:"email_from,omitempty"`
-	EmailID        string    `json:"email_id,omitempty"`
+	Title          string     `json:"title"`
+	Description    string     `json:"description,omitempty"`
+	Client         string     `json:"client,omitempty"`
+	Project        string     `json:"project,omitempty"`
+	EmailSubject   string     `json:"email_subject,omitempty"`
+	EmailFrom      string     `json:"email_from,omitempty"`
+	EmailID        string     `json:"email_id,omitempty"`
 	DueDate        *time.Time `json:"due_date,omitempty"`
-	Status         string    `json:"status,omitempty"`
-	Priority       int       `json:"priority,omitempty"`
-	Urgency        string    `json:"urgency,omitempty"`
-	AssignedAgent  string    `json:"assigned_agent,omitempty"`
-	Source         string    `json:"source,omitempty"`
-	EstimatedHours float64   `json:"estimated_hours,omitempty"`
-	HourlyRate     float64   `json:"hourly_rate,omitempty"`
-	Tags           []string  `json:"tags,omitempty"`
-	DocumentRefs   []string  `json:"document_refs,omitempty"`
+	Status         string     `json:"status,omitempty"`
+	Priority       int        `json:"priority,omitempty"`
+	Urgency        string     `json:"urgency,omitempty"`
+	AssignedAgent  string     `json:"assigned_agent,omitempty"`
+	Source         string     `json:"source,omitempty"`
+	EstimatedHours float64    `json:"estimated_hours,omitempty"`
+	HourlyRate     float64    `json:"hourly_rate,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	DocumentRefs   []string   `json:"document_refs,omitempty"`
+	ParentID       string     `json:"parent_id,omitempty"` // makes the task a subtask
 }
 
 func (w *TaskWorker) createTask(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -138,6 +261,14 @@ func (w *TaskWorker) createTask(ctx context.Context, input json.RawMessage) ([]b
 	if req.Status == "" {
 		req.Status = "open"
 	}
+	if err := validateTaskStatus(req.Status); err != nil {
+		return nil, err
+	}
+	if req.ParentID != "" {
+		if _, err := w.getTask(ctx, req.ParentID); err != nil {
+			return nil, fmt.Errorf("parent: %w", err)
+		}
+	}
 	if req.Priority == 0 {
 		req.Priority = 3
 	}
@@ -152,8 +283,8 @@ func (w *TaskWorker) createTask(ctx context.Context, input json.RawMessage) ([]b
 		INSERT INTO tasks (
 			title, description, client, project, email_subject, email_from, email_id,
 			due_date, status, priority, urgency, assigned_agent, source,
-			estimated_hours, hourly_rate, tags, document_refs
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+			estimated_hours, hourly_rate, tags, document_refs, parent_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -178,6 +309,7 @@ func (w *TaskWorker) createTask(ctx context.Context, input json.RawMessage) ([]b
 		req.HourlyRate,
 		arrayToString(req.Tags),
 		arrayToString(req.DocumentRefs),
+		nullString(req.ParentID),
 	).Scan(&id, &createdAt, &updatedAt)
 
 	if err != nil {
@@ -203,6 +335,7 @@ func (w *TaskWorker) createTask(ctx context.Context, input json.RawMessage) ([]b
 		HourlyRate:    req.HourlyRate,
 		Tags:          req.Tags,
 		DocumentRefs:  req.DocumentRefs,
+		ParentID:      req.ParentID,
 		BillingStatus: "unbilled",
 		CreatedAt:     createdAt,
 		UpdatedAt:     updatedAt,
@@ -213,21 +346,24 @@ func (w *TaskWorker) createTask(ctx context.Context, input json.RawMessage) ([]b
 
 // SearchTasksInput defines search criteria
 type SearchTasksInput struct {
-	Query       string    `json:"query,omitempty"`
-	Client      string    `json:"client,omitempty"`
-	Project     string    `json:"project,omitempty"`
-	Status      string    `json:"status,omitempty"`
-	Urgency     string    `json:"urgency,omitempty"`
-	AssignedTo  string    `json:"assigned_to,omitempty"`
-	Tags        []string  `json:"tags,omitempty"`
-	FromDate    *time.Time `json:"from_date,omitempty"`
-	ToDate      *time.Time `json:"to_date,omitempty"`
-	DueBefore   *time.Time `json:"due_before,omitempty"`
-	DueAfter    *time.Time `json:"due_after,omitempty"`
-	Limit       int       `json:"limit,omitempty"`
-	Offset      int       `json:"offset,omitempty"`
-	OrderBy     string    `json:"order_by,omitempty"`
-	OrderDesc   bool      `json:"order_desc,omitempty"`
+	Query          string     `json:"query,omitempty"`
+	Client         string     `json:"client,omitempty"`
+	Project        string     `json:"project,omitempty"`
+	Status         string     `json:"status,omitempty"`
+	Urgency        string     `json:"urgency,omitempty"`
+	AssignedTo     string     `json:"assigned_to,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	FromDate       *time.Time `json:"from_date,omitempty"`
+	ToDate         *time.Time `json:"to_date,omitempty"`
+	DueBefore      *time.Time `json:"due_before,omitempty"`
+	DueAfter       *time.Time `json:"due_after,omitempty"`
+	Limit          int        `json:"limit,omitempty"`
+	Offset         int        `json:"offset,omitempty"`
+	OrderBy        string     `json:"order_by,omitempty"`
+	OrderDesc      bool       `json:"order_desc,omitempty"`
+	FullText       bool       `json:"fulltext,omitempty"` // match Query with PostgreSQL full-text search, most relevant first
+	IncludeDeleted bool       `json:"include_deleted,omitempty"`
+	AfterID        string     `json:"after_id,omitempty"` // keyset paging: return tasks after this one in (created_at, id) order
 }
 
 func (w *TaskWorker) searchTasks(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -247,8 +383,20 @@ func (w *TaskWorker) searchTasks(ctx context.Context, input json.RawMessage) ([]
 	conditions := []string{"1=1"}
 	args := []interface{}{}
 	argNum := 1
+	rankOrder := ""
+
+	if !req.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
 
-	if req.Query != "" {
+	if req.Query != "" && req.FullText {
+		w.ensureFullTextIndex(ctx)
+		tsQuery := fmt.Sprintf("plainto_tsquery('english', $%d)", argNum)
+		conditions = append(conditions, fmt.Sprintf("%s @@ %s", taskSearchVector, tsQuery))
+		rankOrder = fmt.Sprintf("ts_rank(%s, %s) DESC, ", taskSearchVector, tsQuery)
+		args = append(args, req.Query)
+		argNum++
+	} else if req.Query != "" {
 		conditions = append(conditions, fmt.Sprintf("(title ILIKE $%d OR description ILIKE $%d)", argNum, argNum))
 		args = append(args, "%"+req.Query+"%")
 		argNum++
@@ -304,34 +452,60 @@ func (w *TaskWorker) searchTasks(ctx context.Context, input json.RawMessage) ([]
 		argNum++
 	}
 
-	// Order by
-	orderCol := "created_at"
-	if req.OrderBy != "" {
-		validCols := map[string]bool{
-			"created_at": true, "updated_at": true, "due_date": true,
-			"priority": true, "title": true, "status": true,
+	// The count query takes exactly the filter args; the keyset anchor only bounds the page
+	whereClause := strings.Join(conditions, " AND ")
+	filterArgCount := len(args)
+
+	// Order by. keyset records whether the page is in (created_at, id) order, the
+	// only order an after_id cursor can resume
+	var orderBy string
+	keyset := false
+	if req.AfterID != "" {
+		var exists bool
+		if err := w.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1)", req.AfterID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
 		}
-		if validCols[req.OrderBy] {
-			orderCol = req.OrderBy
+		if !exists {
+			return nil, fmt.Errorf("task not found: %s", req.AfterID)
 		}
-	}
-	orderDir := "ASC"
-	if req.OrderDesc {
-		orderDir = "DESC"
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) > (SELECT created_at, id FROM tasks WHERE id = $%d)", argNum))
+		args = append(args, req.AfterID)
+		argNum++
+		// Keyset paging needs a stable total order, so it ignores order_by and offset
+		orderBy = "created_at ASC, id ASC"
+		rankOrder = ""
+		req.Offset = 0
+		keyset = true
+	} else {
+		orderCol := "created_at"
+		if req.OrderBy != "" {
+			validCols := map[string]bool{
+				"created_at": true, "updated_at": true, "due_date": true,
+				"priority": true, "title": true, "status": true,
+			}
+			if validCols[req.OrderBy] {
+				orderCol = req.OrderBy
+			}
+		}
+		orderDir := "ASC"
+		if req.OrderDesc {
+			orderDir = "DESC"
+		}
+		// id breaks ties so offset pages and the first keyset page stay stable
+		orderBy = orderCol + " " + orderDir + ", id ASC"
+		keyset = orderCol == "created_at" && !req.OrderDesc && rankOrder == ""
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, title, description, client, project, email_subject, email_from, email_id,
-			   due_date, status, priority, urgency, assigned_agent, source,
-			   estimated_hours, actual_hours, hourly_rate, billing_status,
-			   tags, document_refs, apple_reminder_id, created_at, updated_at
+		SELECT %s
 		FROM tasks
 		WHERE %s
-		ORDER BY %s %s
+		ORDER BY %s%s
 		LIMIT $%d OFFSET $%d
-	`, strings.Join(conditions, " AND "), orderCol, orderDir, argNum, argNum+1)
+	`, taskColumns, strings.Join(conditions, " AND "), rankOrder, orderBy, argNum, argNum+1)
 
-	args = append(args, req.Limit, req.Offset)
+	// Fetch one extra row to learn whether another page follows
+	args = append(args, req.Limit+1, req.Offset)
 
 	rows, err := w.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -347,31 +521,56 @@ func (w *TaskWorker) searchTasks(ctx context.Context, input json.RawMessage) ([]
 		}
 		tasks = append(tasks, task)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
 
-	return json.Marshal(map[string]interface{}{
-		"tasks": tasks,
-		"count": len(tasks),
-	})
+	hasMore := len(tasks) > req.Limit
+	if hasMore {
+		tasks = tasks[:req.Limit]
+	}
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM tasks WHERE " + whereClause
+	if err := w.db.QueryRowContext(ctx, countQuery, args[:filterArgCount]...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("count failed: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"tasks":    tasks,
+		"count":    len(tasks),
+		"total":    total,
+		"has_more": hasMore,
+		"offset":   req.Offset,
+		"limit":    req.Limit,
+	}
+	if keyset && hasMore && len(tasks) > 0 {
+		result["next_after_id"] = tasks[len(tasks)-1].ID
+	}
+	return json.Marshal(result)
 }
 
 // UpdateTaskInput defines what can be updated
 type UpdateTaskInput struct {
-	ID             string    `json:"id"`
-	Title          string    `json:"title,omitempty"`
-	Description    string    `json:"description,omitempty"`
-	Client         string    `json:"client,omitempty"`
-	Project        string    `json:"project,omitempty"`
+	ID             string     `json:"id"`
+	Title          string     `json:"title,omitempty"`
+	Description    string     `json:"description,omitempty"`
+	Client         string     `json:"client,omitempty"`
+	Project        string     `json:"project,omitempty"`
 	DueDate        *time.Time `json:"due_date,omitempty"`
-	Status         string    `json:"status,omitempty"`
-	Priority       int       `json:"priority,omitempty"`
-	Urgency        string    `json:"urgency,omitempty"`
-	AssignedAgent  string    `json:"assigned_agent,omitempty"`
-	EstimatedHours float64   `json:"estimated_hours,omitempty"`
-	ActualHours    float64   `json:"actual_hours,omitempty"`
-	HourlyRate     float64   `json:"hourly_rate,omitempty"`
-	BillingStatus  string    `json:"billing_status,omitempty"`
-	Tags           []string  `json:"tags,omitempty"`
-	DocumentRefs   []string  `json:"document_refs,omitempty"`
+	Status         string     `json:"status,omitempty"`
+	Priority       int        `json:"priority,omitempty"`
+	Urgency        string     `json:"urgency,omitempty"`
+	AssignedAgent  string     `json:"assigned_agent,omitempty"`
+	EstimatedHours float64    `json:"estimated_hours,omitempty"`
+	ActualHours    float64    `json:"actual_hours,omitempty"`
+	HourlyRate     float64    `json:"hourly_rate,omitempty"`
+	BillingStatus  string     `json:"billing_status,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	DocumentRefs   []string   `json:"document_refs,omitempty"`
+	// ExpectedUpdatedAt makes the update conditional on the task not having changed
+	// since it was read; a mismatch fails with ErrStaleTask
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at,omitempty"`
 }
 
 func (w *TaskWorker) updateTask(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -384,6 +583,25 @@ func (w *TaskWorker) updateTask(ctx context.Context, input json.RawMessage) ([]b
 		return nil, fmt.Errorf("id is required")
 	}
 
+	if req.Status != "" {
+		if err := validateTaskStatus(req.Status); err != nil {
+			return nil, err
+		}
+		if w.EnforceTransitions {
+			var current string
+			err := w.db.QueryRowContext(ctx, "SELECT status FROM tasks WHERE id = $1", req.ID).Scan(&current)
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("task not found: %s", req.ID)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read task status: %w", err)
+			}
+			if err := validateTaskTransition(current, req.Status); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Build dynamic update
 	updates := []string{}
 	args := []interface{}{}
@@ -452,21 +670,30 @@ func (w *TaskWorker) updateTask(ctx context.Context, input json.RawMessage) ([]b
 
 	// Add ID for WHERE clause
 	args = append(args, req.ID)
+	where := fmt.Sprintf("id = $%d", argNum)
+	if req.ExpectedUpdatedAt != nil {
+		where += fmt.Sprintf(" AND updated_at = $%d", argNum+1)
+		args = append(args, *req.ExpectedUpdatedAt)
+	}
 
 	query := fmt.Sprintf(`
 		UPDATE tasks
 		SET %s
-		WHERE id = $%d
-		RETURNING id, title, description, client, project, email_subject, email_from, email_id,
-				  due_date, status, priority, urgency, assigned_agent, source,
-				  estimated_hours, actual_hours, hourly_rate, billing_status,
-				  tags, document_refs, apple_reminder_id, created_at, updated_at
-	`, strings.Join(updates, ", "), argNum)
+		WHERE %s
+		RETURNING %s
+	`, strings.Join(updates, ", "), where, taskColumns)
 
 	row := w.db.QueryRowContext(ctx, query, args...)
 	task, err := scanDBTask(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
+			if req.ExpectedUpdatedAt != nil {
+				var exists bool
+				err := w.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1)", req.ID).Scan(&exists)
+				if err == nil && exists {
+					return nil, fmt.Errorf("task %s: %w", req.ID, ErrStaleTask)
+				}
+			}
 			return nil, fmt.Errorf("task not found: %s", req.ID)
 		}
 		return nil, fmt.Errorf("update failed: %w", err)
@@ -477,7 +704,8 @@ func (w *TaskWorker) updateTask(ctx context.Context, input json.RawMessage) ([]b
 
 // DeleteTaskInput defines deletion input
 type DeleteTaskInput struct {
-	ID string `json:"id"`
+	ID   string `json:"id"`
+	Hard bool   `json:"hard,omitempty"` // remove the row instead of setting deleted_at
 }
 
 func (w *TaskWorker) deleteTask(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -490,7 +718,11 @@ func (w *TaskWorker) deleteTask(ctx context.Context, input json.RawMessage) ([]b
 		return nil, fmt.Errorf("id is required")
 	}
 
-	result, err := w.db.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", req.ID)
+	query := "UPDATE tasks SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL"
+	if req.Hard {
+		query = "DELETE FROM tasks WHERE id = $1"
+	}
+	result, err := w.db.ExecContext(ctx, query, req.ID)
 	if err != nil {
 		return nil, fmt.Errorf("delete failed: %w", err)
 	}
@@ -505,22 +737,55 @@ func (w *TaskWorker) deleteTask(ctx context.Context, input json.RawMessage) ([]b
 	}
 
 	return json.Marshal(map[string]interface{}{
-		"success":      true,
-		"deleted_id":   req.ID,
+		"success":       true,
+		"deleted_id":    req.ID,
+		"hard":          req.Hard,
 		"rows_affected": rows,
 	})
 }
 
+func (w *TaskWorker) restoreTask(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	if req.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE tasks
+		SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING %s
+	`, taskColumns)
+
+	row := w.db.QueryRowContext(ctx, query, req.ID)
+	task, err := scanDBTask(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("deleted task not found: %s", req.ID)
+		}
+		return nil, fmt.Errorf("restore failed: %w", err)
+	}
+
+	return json.Marshal(task)
+}
+
 // ListTasksInput defines list parameters
 type ListTasksInput struct {
-	Status     string `json:"status,omitempty"`
-	Client     string `json:"client,omitempty"`
-	Project    string `json:"project,omitempty"`
-	AssignedTo string `json:"assigned_to,omitempty"`
-	Limit      int    `json:"limit,omitempty"`
-	Offset     int    `json:"offset,omitempty"`
-	OrderBy    string `json:"order_by,omitempty"`
-	OrderDesc  bool   `json:"order_desc,omitempty"`
+	Status         string `json:"status,omitempty"`
+	Client         string `json:"client,omitempty"`
+	Project        string `json:"project,omitempty"`
+	AssignedTo     string `json:"assigned_to,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
+	Offset         int    `json:"offset,omitempty"`
+	OrderBy        string `json:"order_by,omitempty"`
+	OrderDesc      bool   `json:"order_desc,omitempty"`
+	IncludeDeleted bool   `json:"include_deleted,omitempty"`
 }
 
 func (w *TaskWorker) listTasks(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -540,6 +805,9 @@ func (w *TaskWorker) listTasks(ctx context.Context, input json.RawMessage) ([]by
 	args := []interface{}{}
 	argNum := 1
 
+	if !req.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
 	if req.Status != "" {
 		conditions = append(conditions, fmt.Sprintf("status = $%d", argNum))
 		args = append(args, req.Status)
@@ -582,16 +850,15 @@ func (w *TaskWorker) listTasks(ctx context.Context, input json.RawMessage) ([]by
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, title, description, client, project, email_subject, email_from, email_id,
-			   due_date, status, priority, urgency, assigned_agent, source,
-			   estimated_hours, actual_hours, hourly_rate, billing_status,
-			   tags, document_refs, apple_reminder_id, created_at, updated_at
+		SELECT %s
 		FROM tasks
 		%s
 		ORDER BY %s %s
 		LIMIT $%d OFFSET $%d
-	`, whereClause, orderCol, orderDir, argNum, argNum+1)
+	`, taskColumns, whereClause, orderCol, orderDir, argNum, argNum+1)
 
+	// The count query takes exactly the filter args, not LIMIT/OFFSET
+	filterArgCount := len(args)
 	args = append(args, req.Limit, req.Offset)
 
 	rows, err := w.db.QueryContext(ctx, query, args...)
@@ -615,7 +882,7 @@ func (w *TaskWorker) listTasks(ctx context.Context, input json.RawMessage) ([]by
 		countQuery = "SELECT COUNT(*) FROM tasks " + whereClause
 	}
 	var total int
-	if err := w.db.QueryRowContext(ctx, countQuery, args[:argNum-1]...).Scan(&total); err != nil {
+	if err := w.db.QueryRowContext(ctx, countQuery, args[:filterArgCount]...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("count failed: %w", err)
 	}
 
@@ -647,15 +914,12 @@ func (w *TaskWorker) assignTask(ctx context.Context, input json.RawMessage) ([]b
 		return nil, fmt.Errorf("assigned_agent is required")
 	}
 
-	query := `
+	query := fmt.Sprintf(`
 		UPDATE tasks
 		SET assigned_agent = $1, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $2
-		RETURNING id, title, description, client, project, email_subject, email_from, email_id,
-				  due_date, status, priority, urgency, assigned_agent, source,
-				  estimated_hours, actual_hours, hourly_rate, billing_status,
-				  tags, document_refs, apple_reminder_id, created_at, updated_at
-	`
+		RETURNING %s
+	`, taskColumns)
 
 	row := w.db.QueryRowContext(ctx, query, req.AssignedAgent, req.ID)
 	task, err := scanDBTask(row)
@@ -669,6 +933,369 @@ func (w *TaskWorker) assignTask(ctx context.Context, input json.RawMessage) ([]b
 	return json.Marshal(task)
 }
 
+// getTask loads a live (not soft-deleted) task by id
+func (w *TaskWorker) getTask(ctx context.Context, id string) (*Task, error) {
+	query := fmt.Sprintf("SELECT %s FROM tasks WHERE id = $1 AND deleted_at IS NULL", taskColumns)
+	task, err := scanDBTask(w.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("task not found: %s", id)
+	}
+	return task, err
+}
+
+// addDependency records that task_id is blocked by depends_on_id, refusing links
+// that would make a task (indirectly) depend on itself
+func (w *TaskWorker) addDependency(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		TaskID      string `json:"task_id"`
+		DependsOnID string `json:"depends_on_id"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	if req.TaskID == "" || req.DependsOnID == "" {
+		return nil, fmt.Errorf("task_id and depends_on_id are required")
+	}
+	if req.TaskID == req.DependsOnID {
+		return nil, fmt.Errorf("a task cannot depend on itself")
+	}
+
+	task, err := w.getTask(ctx, req.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.getTask(ctx, req.DependsOnID); err != nil {
+		return nil, err
+	}
+	for _, id := range task.DependsOn {
+		if id == req.DependsOnID {
+			return json.Marshal(task)
+		}
+	}
+
+	// Walk what depends_on_id already depends on; reaching task_id means a cycle
+	graph, err := w.dependencyGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	stack := []string{req.DependsOnID}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == req.TaskID {
+			return nil, fmt.Errorf("dependency cycle: %s already depends on %s", req.DependsOnID, req.TaskID)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		stack = append(stack, graph[id]...)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE tasks
+		SET depends_on = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING %s
+	`, taskColumns)
+
+	row := w.db.QueryRowContext(ctx, query, arrayToString(append(task.DependsOn, req.DependsOnID)), req.TaskID)
+	updated, err := scanDBTask(row)
+	if err != nil {
+		return nil, fmt.Errorf("add dependency failed: %w", err)
+	}
+
+	return json.Marshal(updated)
+}
+
+// dependencyGraph maps each task id to the ids it depends on
+func (w *TaskWorker) dependencyGraph(ctx context.Context) (map[string][]string, error) {
+	rows, err := w.db.QueryContext(ctx, "SELECT id, depends_on FROM tasks WHERE depends_on IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	graph := make(map[string][]string)
+	for rows.Next() {
+		var id string
+		var dependsOn pq.StringArray
+		if err := rows.Scan(&id, &dependsOn); err != nil {
+			return nil, err
+		}
+		graph[id] = dependsOn
+	}
+	return graph, rows.Err()
+}
+
+// TaskTreeNode is a task with its subtasks nested beneath it
+type TaskTreeNode struct {
+	*Task
+	Subtasks []*TaskTreeNode `json:"subtasks,omitempty"`
+}
+
+func (w *TaskWorker) taskTree(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	if req.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	root, err := w.getTask(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &TaskTreeNode{Task: root}
+	seen := map[string]bool{root.ID: true}
+	queue := []*TaskTreeNode{tree}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		children, err := w.subtasks(ctx, node.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			// parent_id is only set at creation, but guard against hand-edited loops
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			childNode := &TaskTreeNode{Task: child}
+			node.Subtasks = append(node.Subtasks, childNode)
+			queue = append(queue, childNode)
+		}
+	}
+
+	return json.Marshal(tree)
+}
+
+// subtasks returns the live tasks whose parent is id, oldest first
+func (w *TaskWorker) subtasks(ctx context.Context, id string) ([]*Task, error) {
+	query := fmt.Sprintf("SELECT %s FROM tasks WHERE parent_id = $1 AND deleted_at IS NULL ORDER BY created_at, id", taskColumns)
+	rows, err := w.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subtasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*Task
+	for rows.Next() {
+		task, err := scanDBTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// TimeEntry is a span of tracked work on a task, matching the standup report's shape
+type TimeEntry struct {
+	ID              string     `json:"id"`
+	TaskID          string     `json:"task_id"`
+	StartedAt       *time.Time `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Description     string     `json:"description"`
+	AgentID         string     `json:"agent_id"`
+}
+
+func (w *TaskWorker) startTimer(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		TaskID      string `json:"task_id"`
+		Description string `json:"description,omitempty"`
+		AgentID     string `json:"agent_id,omitempty"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	if req.TaskID == "" {
+		return nil, fmt.Errorf("task_id is required")
+	}
+	if _, err := w.getTask(ctx, req.TaskID); err != nil {
+		return nil, err
+	}
+
+	// The unique index also enforces this; checking first gives a clearer error
+	var runningID string
+	err := w.db.QueryRowContext(ctx, "SELECT id FROM time_entries WHERE task_id = $1 AND ended_at IS NULL", req.TaskID).Scan(&runningID)
+	if err == nil {
+		return nil, fmt.Errorf("timer already running for task %s (entry %s)", req.TaskID, runningID)
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check running timers: %w", err)
+	}
+
+	startedAt := time.Now().UTC()
+	entry := TimeEntry{
+		TaskID:      req.TaskID,
+		StartedAt:   &startedAt,
+		Description: req.Description,
+		AgentID:     req.AgentID,
+	}
+	err = w.db.QueryRowContext(ctx, `
+		INSERT INTO time_entries (task_id, started_at, description, agent_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, req.TaskID, startedAt, nullString(req.Description), nullString(req.AgentID)).Scan(&entry.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+
+	return json.Marshal(entry)
+}
+
+func (w *TaskWorker) stopTimer(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		TaskID string `json:"task_id"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	if req.TaskID == "" {
+		return nil, fmt.Errorf("task_id is required")
+	}
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	entry := TimeEntry{TaskID: req.TaskID}
+	var startedAt time.Time
+	var description, agentID sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, started_at, description, agent_id
+		FROM time_entries
+		WHERE task_id = $1 AND ended_at IS NULL
+	`, req.TaskID).Scan(&entry.ID, &startedAt, &description, &agentID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no running timer for task %s", req.TaskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find running timer: %w", err)
+	}
+
+	endedAt := time.Now().UTC()
+	entry.StartedAt = &startedAt
+	entry.EndedAt = &endedAt
+	entry.DurationMinutes = int(math.Round(endedAt.Sub(startedAt).Minutes()))
+	entry.Description = description.String
+	entry.AgentID = agentID.String
+
+	if _, err := tx.ExecContext(ctx, "UPDATE time_entries SET ended_at = $1, duration_minutes = $2 WHERE id = $3",
+		endedAt, entry.DurationMinutes, entry.ID); err != nil {
+		return nil, fmt.Errorf("failed to stop timer: %w", err)
+	}
+
+	var actualHours float64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE tasks
+		SET actual_hours = COALESCE(actual_hours, 0) + $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING actual_hours
+	`, float64(entry.DurationMinutes)/60, req.TaskID).Scan(&actualHours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update actual hours: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"entry":        entry,
+		"actual_hours": actualHours,
+	})
+}
+
+// timeReport sums finished time entries per task for entries started in [from, to).
+// The range defaults to the last seven days.
+func (w *TaskWorker) timeReport(ctx context.Context, input json.RawMessage) ([]byte, error) {
+	var req struct {
+		From   *time.Time `json:"from,omitempty"`
+		To     *time.Time `json:"to,omitempty"`
+		TaskID string     `json:"task_id,omitempty"`
+	}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	to := time.Now().UTC()
+	if req.To != nil {
+		to = req.To.UTC()
+	}
+	from := to.AddDate(0, 0, -7)
+	if req.From != nil {
+		from = req.From.UTC()
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	conditions := []string{"e.ended_at IS NOT NULL", "e.started_at >= $1", "e.started_at < $2"}
+	args := []interface{}{from, to}
+	if req.TaskID != "" {
+		conditions = append(conditions, "e.task_id = $3")
+		args = append(args, req.TaskID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT e.task_id, COALESCE(t.title, ''), SUM(e.duration_minutes), COUNT(*)
+		FROM time_entries e
+		LEFT JOIN tasks t ON CAST(t.id AS TEXT) = e.task_id
+		WHERE %s
+		GROUP BY e.task_id, t.title
+		ORDER BY SUM(e.duration_minutes) DESC, e.task_id
+	`, strings.Join(conditions, " AND "))
+
+	rows, err := w.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("time report failed: %w", err)
+	}
+	defer rows.Close()
+
+	type taskTime struct {
+		TaskID  string `json:"task_id"`
+		Title   string `json:"title"`
+		Minutes int    `json:"minutes"`
+		Entries int    `json:"entries"`
+	}
+	tasks := []taskTime{}
+	total := 0
+	for rows.Next() {
+		var tt taskTime
+		if err := rows.Scan(&tt.TaskID, &tt.Title, &tt.Minutes, &tt.Entries); err != nil {
+			return nil, err
+		}
+		total += tt.Minutes
+		tasks = append(tasks, tt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"from":          from,
+		"to":            to,
+		"tasks":         tasks,
+		"total_minutes": total,
+	})
+}
+
 // Helper functions
 
 func scanDBTask(scanner interface {
@@ -679,7 +1306,10 @@ func scanDBTask(scanner interface {
 	var dueDate sql.NullTime
 	var assignedAgent, appleReminderID sql.NullString
 	var estimatedHours, actualHours, hourlyRate sql.NullFloat64
-	var tags, documentRefs sql.NullString
+	var tags, documentRefs pq.StringArray
+	var deletedAt sql.NullTime
+	var parentID sql.NullString
+	var dependsOn pq.StringArray
 
 	err := scanner.Scan(
 		&task.ID,
@@ -705,6 +1335,9 @@ func scanDBTask(scanner interface {
 		&appleReminderID,
 		&task.CreatedAt,
 		&task.UpdatedAt,
+		&deletedAt,
+		&parentID,
+		&dependsOn,
 	)
 	if err != nil {
 		return nil, err
@@ -729,9 +1362,14 @@ func scanDBTask(scanner interface {
 	if hourlyRate.Valid {
 		task.HourlyRate = hourlyRate.Float64
 	}
-	task.Tags = parseArray(tags.String)
-	task.DocumentRefs = parseArray(documentRefs.String)
+	task.Tags = arrayValues(tags)
+	task.DocumentRefs = arrayValues(documentRefs)
 	task.AppleReminderID = appleReminderID.String
+	if deletedAt.Valid {
+		task.DeletedAt = &deletedAt.Time
+	}
+	task.ParentID = parentID.String
+	task.DependsOn = arrayValues(dependsOn)
 
 	return task, nil
 }
@@ -743,43 +1381,19 @@ func nullString(s string) interface{} {
 	return s
 }
 
+// arrayToString encodes arr as a Postgres array literal, quoting and escaping each
+// element so commas, quotes and spaces survive; empty arrays are stored as NULL
 func arrayToString(arr []string) interface{} {
 	if len(arr) == 0 {
 		return nil
 	}
-	return "{" + strings.Join(arr, ",") + "}"
+	return pq.StringArray(arr)
 }
 
-func parseArray(s string) []string {
-	if s == "" || s == "{}" || s == "null" {
-		return nil
-	}
-	// Remove braces
-	s = strings.Trim(s, "{}")
-	if s == "" {
+// arrayValues returns the elements of a scanned array column, nil when NULL or empty
+func arrayValues(a pq.StringArray) []string {
+	if len(a) == 0 {
 		return nil
 	}
-	// Split by comma, handling quoted strings
-	var result []string
-	var current strings.Builder
-	inQuotes := false
-	for _, r := range s {
-		switch r {
-		case '"':
-			inQuotes = !inQuotes
-		case ',':
-			if !inQuotes {
-				result = append(result, strings.Trim(current.String(), `"`))
-				current.Reset()
-			} else {
-				current.WriteRune(r)
-			}
-		default:
-			current.WriteRune(r)
-		}
-	}
-	if current.Len() > 0 {
-		result = append(result, strings.Trim(current.String(), `"`))
-	}
-	return result
+	return a
 }
\ No newline at end of file
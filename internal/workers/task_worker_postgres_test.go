@@ -0,0 +1,94 @@
+//go:build postgres
+
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests need PostgreSQL for full-text search. Run them with
+//
+//	MYMCP_TEST_POSTGRES_URL=postgres://... go test -tags postgres ./internal/workers
+//
+// Each test works in a throwaway schema, so any database the user can create schemas in will do.
+func newPostgresTaskWorker(t *testing.T) *TaskWorker {
+	url := os.Getenv("MYMCP_TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("MYMCP_TEST_POSTGRES_URL not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1) // keep search_path on the one connection
+	t.Cleanup(func() { db.Close() })
+
+	schema := fmt.Sprintf("mymcp_test_%d", time.Now().UnixNano())
+	_, err = db.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	_, err = db.Exec("SET search_path TO " + schema)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		CREATE TABLE tasks (
+			id SERIAL PRIMARY KEY,
+			title TEXT NOT NULL,
+			description TEXT,
+			client TEXT,
+			project TEXT,
+			email_subject TEXT,
+			email_from TEXT,
+			email_id TEXT,
+			due_date TIMESTAMP,
+			status TEXT DEFAULT 'open',
+			priority INTEGER DEFAULT 3,
+			urgency TEXT DEFAULT 'medium',
+			assigned_agent TEXT,
+			source TEXT DEFAULT 'manual',
+			estimated_hours NUMERIC,
+			actual_hours NUMERIC,
+			hourly_rate NUMERIC,
+			billing_status TEXT DEFAULT 'unbilled',
+			tags TEXT[],
+			document_refs TEXT[],
+			apple_reminder_id TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP,
+			parent_id TEXT,
+			depends_on TEXT[]
+		)`)
+	require.NoError(t, err)
+	return NewTaskWorkerFromDB(db)
+}
+
+func TestSearchTasks_FullTextRanksByRelevance(t *testing.T) {
+	w := newPostgresTaskWorker(t)
+	createTestTask(t, w, map[string]any{"title": "Update invoice template", "description": "Minor wording change"})
+	createTestTask(t, w, map[string]any{"title": "Invoicing backlog", "description": "Send the overdue invoices and chase unpaid invoices"})
+	createTestTask(t, w, map[string]any{"title": "Renew domain", "description": "Expires next month"})
+
+	out, err := w.Execute(context.Background(), "task_search", json.RawMessage(`{"query":"invoices","fulltext":true}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Tasks []Task `json:"tasks"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	// Stemming matches "invoice" and "invoicing" too; the task mentioning invoices most ranks first
+	require.Len(t, resp.Tasks, 2)
+	assert.Equal(t, "Invoicing backlog", resp.Tasks[0].Title)
+
+	var indexed bool
+	require.NoError(t, w.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'tasks_fulltext_idx' AND schemaname = current_schema())`).Scan(&indexed))
+	assert.True(t, indexed)
+}
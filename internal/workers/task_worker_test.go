@@ -0,0 +1,457 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTaskWorkerSchema mirrors the subcontracting tasks and time_entries tables in SQLite syntax
+const testTaskWorkerSchema = `
+CREATE TABLE tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	description TEXT,
+	client TEXT,
+	project TEXT,
+	email_subject TEXT,
+	email_from TEXT,
+	email_id TEXT,
+	due_date TIMESTAMP,
+	status TEXT DEFAULT 'open',
+	priority INTEGER DEFAULT 3,
+	urgency TEXT DEFAULT 'medium',
+	assigned_agent TEXT,
+	source TEXT DEFAULT 'manual',
+	estimated_hours REAL,
+	actual_hours REAL,
+	hourly_rate REAL,
+	billing_status TEXT DEFAULT 'unbilled',
+	tags TEXT,
+	document_refs TEXT,
+	apple_reminder_id TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMP,
+	parent_id TEXT,
+	depends_on TEXT
+);
+CREATE TABLE time_entries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	task_id TEXT NOT NULL,
+	started_at TIMESTAMP NOT NULL,
+	ended_at TIMESTAMP,
+	duration_minutes INTEGER,
+	description TEXT,
+	agent_id TEXT
+);
+CREATE UNIQUE INDEX time_entries_running_idx ON time_entries (task_id) WHERE ended_at IS NULL`
+
+func newTestTaskWorker(t *testing.T) *TaskWorker {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(testTaskWorkerSchema)
+	require.NoError(t, err)
+	return NewTaskWorkerFromDB(db)
+}
+
+func createTestTask(t *testing.T, w *TaskWorker, fields map[string]any) *Task {
+	input, _ := json.Marshal(fields)
+	out, err := w.Execute(context.Background(), "task_create", input)
+	require.NoError(t, err)
+
+	var task Task
+	require.NoError(t, json.Unmarshal(out, &task))
+	return &task
+}
+
+func updateTestTask(w *TaskWorker, id, status string) (*Task, error) {
+	input, _ := json.Marshal(map[string]any{"id": id, "status": status})
+	out, err := w.Execute(context.Background(), "task_update", input)
+	if err != nil {
+		return nil, err
+	}
+	var task Task
+	if err := json.Unmarshal(out, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func TestUpdateTask_ValidStatus(t *testing.T) {
+	w := newTestTaskWorker(t)
+	task := createTestTask(t, w, map[string]any{"title": "Draft proposal"})
+
+	updated, err := updateTestTask(w, task.ID, "in_progress")
+	require.NoError(t, err)
+	assert.Equal(t, "in_progress", updated.Status)
+}
+
+func TestUpdateTask_RejectsUnknownStatus(t *testing.T) {
+	w := newTestTaskWorker(t)
+	task := createTestTask(t, w, map[string]any{"title": "Draft proposal"})
+
+	_, err := updateTestTask(w, task.ID, "complet")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid status "complet"`)
+	assert.Contains(t, err.Error(), "open, in_progress, blocked, completed, cancelled")
+
+	_, err = w.Execute(context.Background(), "task_create", json.RawMessage(`{"title":"x","status":"done"}`))
+	assert.Error(t, err)
+}
+
+func TestUpdateTask_IllegalTransition(t *testing.T) {
+	w := newTestTaskWorker(t)
+	task := createTestTask(t, w, map[string]any{"title": "Draft proposal", "status": "cancelled"})
+
+	// Without enforcement any known status is accepted
+	_, err := updateTestTask(w, task.ID, "in_progress")
+	require.NoError(t, err)
+	_, err = updateTestTask(w, task.ID, "cancelled")
+	require.NoError(t, err)
+
+	w.EnforceTransitions = true
+	_, err = updateTestTask(w, task.ID, "in_progress")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot change status from cancelled to in_progress")
+
+	reopened, err := updateTestTask(w, task.ID, "open")
+	require.NoError(t, err)
+	assert.Equal(t, "open", reopened.Status)
+}
+
+func TestListTasks_CountsFilteredTasks(t *testing.T) {
+	w := newTestTaskWorker(t)
+	for _, title := range []string{"a", "b", "c"} {
+		createTestTask(t, w, map[string]any{"title": title, "client": "acme"})
+	}
+	createTestTask(t, w, map[string]any{"title": "d", "client": "acme", "status": "blocked"})
+	createTestTask(t, w, map[string]any{"title": "e", "client": "globex"})
+
+	out, err := w.Execute(context.Background(), "task_list", json.RawMessage(`{"status":"open","client":"acme","limit":2}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Tasks []Task `json:"tasks"`
+		Count int    `json:"count"`
+		Total int    `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 2, resp.Count)
+	assert.Equal(t, 3, resp.Total)
+	for _, task := range resp.Tasks {
+		assert.Equal(t, "open", task.Status)
+		assert.Equal(t, "acme", task.Client)
+	}
+}
+
+func TestSearchTasks_KeysetPagingHasNoGapsOrDuplicates(t *testing.T) {
+	w := newTestTaskWorker(t)
+	want := map[string]bool{}
+	for i := 0; i < 7; i++ {
+		task := createTestTask(t, w, map[string]any{"title": fmt.Sprintf("task %d", i), "client": "acme"})
+		want[task.ID] = true
+	}
+	createTestTask(t, w, map[string]any{"title": "other", "client": "globex"})
+
+	type page struct {
+		Tasks       []Task `json:"tasks"`
+		Total       int    `json:"total"`
+		HasMore     bool   `json:"has_more"`
+		NextAfterID string `json:"next_after_id"`
+	}
+
+	seen := map[string]bool{}
+	afterID := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "paging did not terminate")
+
+		input, _ := json.Marshal(map[string]any{"client": "acme", "limit": 3, "after_id": afterID})
+		out, err := w.Execute(context.Background(), "task_search", input)
+		require.NoError(t, err)
+
+		var resp page
+		require.NoError(t, json.Unmarshal(out, &resp))
+		assert.Equal(t, 7, resp.Total)
+		for _, task := range resp.Tasks {
+			assert.False(t, seen[task.ID], "duplicate task %s", task.ID)
+			seen[task.ID] = true
+		}
+		if !resp.HasMore {
+			assert.Empty(t, resp.NextAfterID)
+			break
+		}
+		afterID = resp.NextAfterID
+	}
+	assert.Equal(t, want, seen)
+}
+
+func TestSearchTasks_OffsetPagingReportsHasMore(t *testing.T) {
+	w := newTestTaskWorker(t)
+	for i := 0; i < 4; i++ {
+		createTestTask(t, w, map[string]any{"title": fmt.Sprintf("task %d", i)})
+	}
+
+	var resp struct {
+		Count   int  `json:"count"`
+		Total   int  `json:"total"`
+		HasMore bool `json:"has_more"`
+	}
+	out, err := w.Execute(context.Background(), "task_search", json.RawMessage(`{"limit":2,"offset":1}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 2, resp.Count)
+	assert.Equal(t, 4, resp.Total)
+	assert.True(t, resp.HasMore)
+
+	out, err = w.Execute(context.Background(), "task_search", json.RawMessage(`{"limit":2,"offset":2}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, 2, resp.Count)
+	assert.False(t, resp.HasMore)
+}
+
+func TestSearchTasks_NextAfterIDOnlyForCreatedAtOrder(t *testing.T) {
+	w := newTestTaskWorker(t)
+	for i := 0; i < 3; i++ {
+		createTestTask(t, w, map[string]any{"title": fmt.Sprintf("task %d", i)})
+	}
+
+	for _, tc := range []struct {
+		input string
+		want  bool
+	}{
+		{`{"limit":2}`, true},
+		{`{"limit":2,"order_by":"created_at"}`, true},
+		{`{"limit":2,"order_by":"title"}`, false},
+		{`{"limit":2,"order_desc":true}`, false},
+	} {
+		out, err := w.Execute(context.Background(), "task_search", json.RawMessage(tc.input))
+		require.NoError(t, err)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(out, &resp))
+		assert.Equal(t, true, resp["has_more"], tc.input)
+		_, ok := resp["next_after_id"]
+		assert.Equal(t, tc.want, ok, tc.input)
+	}
+}
+
+func TestSearchTasks_UnknownAfterID(t *testing.T) {
+	w := newTestTaskWorker(t)
+	_, err := w.Execute(context.Background(), "task_search", json.RawMessage(`{"after_id":"999"}`))
+	assert.ErrorContains(t, err, "task not found")
+}
+
+func listTestTasks(t *testing.T, w *TaskWorker, tool, input string) []Task {
+	out, err := w.Execute(context.Background(), tool, json.RawMessage(input))
+	require.NoError(t, err)
+	var resp struct {
+		Tasks []Task `json:"tasks"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	return resp.Tasks
+}
+
+func TestDeleteTask_SoftDeleteThenRestore(t *testing.T) {
+	w := newTestTaskWorker(t)
+	task := createTestTask(t, w, map[string]any{"title": "Quarterly report", "client": "acme"})
+	createTestTask(t, w, map[string]any{"title": "Team lunch"})
+
+	input, _ := json.Marshal(map[string]any{"id": task.ID})
+	_, err := w.Execute(context.Background(), "task_delete", input)
+	require.NoError(t, err)
+
+	assert.Len(t, listTestTasks(t, w, "task_list", `{}`), 1)
+	assert.Empty(t, listTestTasks(t, w, "task_search", `{"client":"acme"}`))
+	deleted := listTestTasks(t, w, "task_list", `{"include_deleted":true}`)
+	require.Len(t, deleted, 2)
+
+	// Deleting again finds nothing live to delete
+	_, err = w.Execute(context.Background(), "task_delete", input)
+	assert.Error(t, err)
+
+	out, err := w.Execute(context.Background(), "task_restore", input)
+	require.NoError(t, err)
+	var restored Task
+	require.NoError(t, json.Unmarshal(out, &restored))
+	assert.Nil(t, restored.DeletedAt)
+	assert.Len(t, listTestTasks(t, w, "task_search", `{"client":"acme"}`), 1)
+
+	_, err = w.Execute(context.Background(), "task_restore", input)
+	assert.Error(t, err)
+}
+
+func TestDeleteTask_Hard(t *testing.T) {
+	w := newTestTaskWorker(t)
+	task := createTestTask(t, w, map[string]any{"title": "Quarterly report"})
+
+	input, _ := json.Marshal(map[string]any{"id": task.ID, "hard": true})
+	_, err := w.Execute(context.Background(), "task_delete", input)
+	require.NoError(t, err)
+
+	assert.Empty(t, listTestTasks(t, w, "task_list", `{"include_deleted":true}`))
+	_, err = w.Execute(context.Background(), "task_restore", input)
+	assert.Error(t, err)
+}
+
+func TestCreateTask_Subtask(t *testing.T) {
+	w := newTestTaskWorker(t)
+	parent := createTestTask(t, w, map[string]any{"title": "Launch site"})
+
+	child := createTestTask(t, w, map[string]any{"title": "Write copy", "parent_id": parent.ID})
+	assert.Equal(t, parent.ID, child.ParentID)
+
+	_, err := w.Execute(context.Background(), "task_create", json.RawMessage(`{"title":"orphan","parent_id":"999"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "task not found: 999")
+}
+
+func TestTaskTree_NestsSubtasks(t *testing.T) {
+	w := newTestTaskWorker(t)
+	root := createTestTask(t, w, map[string]any{"title": "Launch site"})
+	copyTask := createTestTask(t, w, map[string]any{"title": "Write copy", "parent_id": root.ID})
+	createTestTask(t, w, map[string]any{"title": "Proofread", "parent_id": copyTask.ID})
+	createTestTask(t, w, map[string]any{"title": "Buy domain", "parent_id": root.ID})
+
+	input, _ := json.Marshal(map[string]any{"id": root.ID})
+	out, err := w.Execute(context.Background(), "task_tree", input)
+	require.NoError(t, err)
+
+	var tree TaskTreeNode
+	require.NoError(t, json.Unmarshal(out, &tree))
+	assert.Equal(t, "Launch site", tree.Title)
+	require.Len(t, tree.Subtasks, 2)
+	assert.Equal(t, "Write copy", tree.Subtasks[0].Title)
+	assert.Equal(t, "Buy domain", tree.Subtasks[1].Title)
+	require.Len(t, tree.Subtasks[0].Subtasks, 1)
+	assert.Equal(t, "Proofread", tree.Subtasks[0].Subtasks[0].Title)
+}
+
+func TestAddDependency_RejectsCycles(t *testing.T) {
+	w := newTestTaskWorker(t)
+	a := createTestTask(t, w, map[string]any{"title": "design"})
+	b := createTestTask(t, w, map[string]any{"title": "build"})
+	c := createTestTask(t, w, map[string]any{"title": "ship"})
+
+	addDependency := func(taskID, dependsOnID string) (*Task, error) {
+		input, _ := json.Marshal(map[string]any{"task_id": taskID, "depends_on_id": dependsOnID})
+		out, err := w.Execute(context.Background(), "task_add_dependency", input)
+		if err != nil {
+			return nil, err
+		}
+		var task Task
+		require.NoError(t, json.Unmarshal(out, &task))
+		return &task, nil
+	}
+
+	updated, err := addDependency(b.ID, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{a.ID}, updated.DependsOn)
+	_, err = addDependency(c.ID, b.ID)
+	require.NoError(t, err)
+
+	_, err = addDependency(a.ID, c.ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency cycle")
+	_, err = addDependency(a.ID, a.ID)
+	assert.Error(t, err)
+}
+
+func TestTaskTimer_StartStopAndReport(t *testing.T) {
+	w := newTestTaskWorker(t)
+	task := createTestTask(t, w, map[string]any{"title": "Client audit"})
+	input, _ := json.Marshal(map[string]any{"task_id": task.ID})
+
+	_, err := w.Execute(context.Background(), "task_timer_start", input)
+	require.NoError(t, err)
+	_, err = w.Execute(context.Background(), "task_timer_start", input)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timer already running")
+
+	// Pretend the timer has been running for an hour and a half
+	_, err = w.db.Exec("UPDATE time_entries SET started_at = $1", time.Now().UTC().Add(-90*time.Minute))
+	require.NoError(t, err)
+
+	out, err := w.Execute(context.Background(), "task_timer_stop", input)
+	require.NoError(t, err)
+	var stopped struct {
+		Entry       TimeEntry `json:"entry"`
+		ActualHours float64   `json:"actual_hours"`
+	}
+	require.NoError(t, json.Unmarshal(out, &stopped))
+	assert.Equal(t, 90, stopped.Entry.DurationMinutes)
+	assert.InDelta(t, 1.5, stopped.ActualHours, 1e-9)
+
+	_, err = w.Execute(context.Background(), "task_timer_stop", input)
+	assert.Error(t, err)
+
+	out, err = w.Execute(context.Background(), "task_time_report", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	var report struct {
+		Tasks []struct {
+			TaskID  string `json:"task_id"`
+			Title   string `json:"title"`
+			Minutes int    `json:"minutes"`
+		} `json:"tasks"`
+		TotalMinutes int `json:"total_minutes"`
+	}
+	require.NoError(t, json.Unmarshal(out, &report))
+	require.Len(t, report.Tasks, 1)
+	assert.Equal(t, "Client audit", report.Tasks[0].Title)
+	assert.Equal(t, 90, report.TotalMinutes)
+}
+
+func TestUpdateTask_StaleExpectedUpdatedAt(t *testing.T) {
+	w := newTestTaskWorker(t)
+	task := createTestTask(t, w, map[string]any{"title": "Draft proposal"})
+	read, err := updateTestTask(w, task.ID, "in_progress")
+	require.NoError(t, err)
+
+	update := func(title string) error {
+		input, _ := json.Marshal(map[string]any{"id": task.ID, "title": title, "expected_updated_at": read.UpdatedAt})
+		_, err := w.Execute(context.Background(), "task_update", input)
+		return err
+	}
+
+	// The first writer still sees the version it read; the second has been overtaken
+	require.NoError(t, update("Draft proposal v2"))
+	err = update("Draft proposal v3")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrStaleTask)
+
+	// Without an expectation the last write still wins
+	input, _ := json.Marshal(map[string]any{"id": task.ID, "title": "Draft proposal v3"})
+	_, err = w.Execute(context.Background(), "task_update", input)
+	require.NoError(t, err)
+
+	input, _ = json.Marshal(map[string]any{"id": "999", "title": "x", "expected_updated_at": read.UpdatedAt})
+	_, err = w.Execute(context.Background(), "task_update", input)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrStaleTask)
+}
+
+func TestTaskTags_RoundTripSpecialCharacters(t *testing.T) {
+	w := newTestTaskWorker(t)
+	tags := []string{"client: Acme, Inc", `the "big" one`, `back\slash`, "plain"}
+	task := createTestTask(t, w, map[string]any{"title": "Contract review", "tags": tags})
+
+	listed := listTestTasks(t, w, "task_list", `{}`)
+	require.Len(t, listed, 1)
+	assert.Equal(t, tags, listed[0].Tags)
+
+	input, _ := json.Marshal(map[string]any{"id": task.ID, "tags": []string{"a,b", `"`}})
+	out, err := w.Execute(context.Background(), "task_update", input)
+	require.NoError(t, err)
+	var updated Task
+	require.NoError(t, json.Unmarshal(out, &updated))
+	assert.Equal(t, []string{"a,b", `"`}, updated.Tags)
+}
@@ -1,20 +1,38 @@
 package workers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/andybalholm/cascadia"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 )
 
+const webUserAgent = "Mozilla/5.0 (compatible; MCP-Bot/1.0)"
+
+// defaultWebMaxResponseBytes caps how much of a response body the web tools read
+const defaultWebMaxResponseBytes = 10 << 20
+
 type WebWorker struct {
-	httpClient *http.Client
+	httpClient       *http.Client
+	RobotsTTL        time.Duration // how long a host's robots.txt is cached
+	HostDelay        time.Duration // minimum time between fetches from one host
+	MaxResponseBytes int64         // longer bodies are truncated
+
+	mu        sync.Mutex
+	robots    map[string]robotsEntry
+	lastFetch map[string]time.Time
 }
 
 func NewWebWorker() *WebWorker {
@@ -22,7 +40,121 @@ func NewWebWorker() *WebWorker {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		RobotsTTL:        time.Hour,
+		HostDelay:        time.Second,
+		MaxResponseBytes: defaultWebMaxResponseBytes,
+		robots:           make(map[string]robotsEntry),
+		lastFetch:        make(map[string]time.Time),
+	}
+}
+
+// webPage is a fetched response with its body read up to MaxResponseBytes. Only
+// text bodies are read; the tools never return anything else.
+type webPage struct {
+	resp      *http.Response
+	mediaType string // declared, or sniffed if the server didn't say
+	body      []byte
+	truncated bool
+}
+
+func (w *WebWorker) get(ctx context.Context, rawURL string, headers map[string]string) (*webPage, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+	httpReq.Header.Set("User-Agent", webUserAgent)
+
+	resp, err := w.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
+
+	// Trust the declared type, sniffing the first 512 bytes only when there is none
+	var sniffed []byte
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		sniffed, err = io.ReadAll(io.LimitReader(resp.Body, 512))
+		if err != nil {
+			return nil, err
+		}
+		contentType = http.DetectContentType(sniffed)
+	}
+	page := &webPage{resp: resp, mediaType: parseWebMediaType(contentType)}
+	if !page.isText() {
+		return page, nil
+	}
+
+	limit := w.MaxResponseBytes
+	if limit <= 0 {
+		limit = defaultWebMaxResponseBytes
+	}
+	// Read one byte past the limit to tell a body of exactly limit bytes from a longer one
+	rest, err := io.ReadAll(io.LimitReader(resp.Body, limit+1-int64(len(sniffed))))
+	if err != nil {
+		return nil, err
+	}
+	body := append(sniffed, rest...)
+	page.body = body
+	if int64(len(body)) > limit {
+		page.body = body[:limit]
+		page.truncated = true
+	}
+	return page, nil
+}
+
+// parseWebMediaType returns the media type of a Content-Type value without its
+// parameters
+func parseWebMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(contentType)
+	}
+	return mediaType
+}
+
+// isText reports whether the body is text or markup that's safe to parse and return
+func (p *webPage) isText() bool {
+	mediaType := p.mediaType
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+xml"),
+		strings.HasSuffix(mediaType, "+json"):
+		return true
+	}
+	switch mediaType {
+	case "application/xml", "application/json", "application/javascript", "application/ecmascript":
+		return true
+	}
+	return false
+}
+
+func (p *webPage) note() string {
+	return fmt.Sprintf("skipped non-text content of type %s", p.mediaType)
+}
+
+// size is the number of body bytes read, or for a skipped body the declared length
+// (-1 if unknown)
+func (p *webPage) size() int64 {
+	if !p.isText() {
+		return p.resp.ContentLength
+	}
+	return int64(len(p.body))
+}
+
+// skipped is the result of a tool that parses pages when the response isn't text
+func (p *webPage) skipped(rawURL string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"url":          rawURL,
+		"status":       p.resp.Status,
+		"content_type": p.mediaType,
+		"size":         p.size(),
+		"truncated":    p.truncated,
+		"note":         p.note(),
+	})
 }
 
 func (w *WebWorker) GetTools() []ToolDef {
@@ -56,8 +188,9 @@ func (w *WebWorker) Execute(ctx context.Context, name string, input json.RawMess
 }
 
 type FetchInput struct {
-	URL     string            `json:"url"`
-	Headers map[string]string `json:"headers"`
+	URL           string            `json:"url"`
+	Headers       map[string]string `json:"headers"`
+	RespectRobots bool              `json:"respect_robots"`
 }
 
 func (w *WebWorker) fetch(ctx context.Context, input json.RawMessage) ([]byte, error) {
@@ -68,35 +201,47 @@ func (w *WebWorker) fetch(ctx context.Context, input json.RawMessage) ([]byte, e
 		return nil, fmt.Errorf("url is required")
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", req.URL, nil)
+	target, err := url.Parse(req.URL)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid url: %w", err)
 	}
-
-	for k, v := range req.Headers {
-		httpReq.Header.Set(k, v)
+	if req.RespectRobots {
+		robots, err := w.robotsFor(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		if !robots.allowed(target.RequestURI()) {
+			return json.Marshal(map[string]interface{}{
+				"url":     req.URL,
+				"allowed": false,
+				"error":   "disallowed by robots.txt",
+			})
+		}
 	}
-	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (compatible; MCP-Bot/1.0)")
-
-	resp, err := w.httpClient.Do(httpReq)
-	if err != nil {
+	if err := w.waitForHost(ctx, target.Host); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	page, err := w.get(ctx, req.URL, req.Headers)
 	if err != nil {
 		return nil, err
 	}
 
-	return json.Marshal(map[string]interface{}{
+	result := map[string]interface{}{
 		"url":          req.URL,
-		"status":       resp.Status,
-		"status_code":  resp.StatusCode,
-		"headers":      resp.Header,
-		"content":      string(body),
-		"content_type": resp.Header.Get("Content-Type"),
-	})
+		"status":       page.resp.Status,
+		"status_code":  page.resp.StatusCode,
+		"headers":      page.resp.Header,
+		"content_type": page.resp.Header.Get("Content-Type"),
+		"size":         page.size(),
+		"truncated":    page.truncated,
+	}
+	if page.isText() {
+		result["content"] = string(page.body)
+	} else {
+		result["note"] = page.note()
+	}
+	return json.Marshal(result)
 }
 
 type ScrapeInput struct {
@@ -112,29 +257,99 @@ func (w *WebWorker) scrape(ctx context.Context, input json.RawMessage) ([]byte,
 		return nil, fmt.Errorf("url is required")
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", req.URL, nil)
+	page, err := w.get(ctx, req.URL, nil)
 	if err != nil {
 		return nil, err
 	}
-	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (compatible; MCP-Bot/1.0)")
-
-	resp, err := w.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
+	if !page.isText() {
+		return page.skipped(req.URL)
 	}
-	defer resp.Body.Close()
 
-	doc, err := html.Parse(resp.Body)
+	doc, err := html.Parse(bytes.NewReader(page.body))
 	if err != nil {
 		return nil, err
 	}
 
-	var results []map[string]string
 	selector := req.Selector
 	if selector == "" {
 		selector = "p, h1, h2, h3, a"
 	}
 
+	// Selectors are CSS; anything that doesn't parse is treated as a list of tag names
+	var results []ScrapeResult
+	mode := "css"
+	if sel, err := cascadia.ParseGroup(selector); err == nil {
+		results = scrapeSelector(doc, sel, documentBase(doc, page.resp.Request.URL))
+	} else {
+		mode = "tags"
+		results = scrapeTags(doc, selector)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"url":       req.URL,
+		"mode":      mode,
+		"results":   results,
+		"count":     len(results),
+		"truncated": page.truncated,
+	})
+}
+
+type ScrapeResult struct {
+	Tag        string            `json:"tag"`
+	Text       string            `json:"text"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// scrapeAttributes are the attributes worth returning for a matched element
+var scrapeAttributes = []string{"id", "class", "href", "src", "alt", "title", "name"}
+
+func scrapeSelector(doc *html.Node, sel cascadia.Matcher, base *url.URL) []ScrapeResult {
+	var results []ScrapeResult
+	for _, n := range cascadia.QueryAll(doc, sel) {
+		result := ScrapeResult{
+			Tag:  n.Data,
+			Text: strings.Join(strings.Fields(nodeText(n)), " "),
+		}
+		for _, attr := range n.Attr {
+			if !slices.Contains(scrapeAttributes, attr.Key) {
+				continue
+			}
+			if result.Attributes == nil {
+				result.Attributes = make(map[string]string)
+			}
+			if attr.Key == "href" || attr.Key == "src" {
+				result.Attributes[attr.Key] = resolveURL(base, attr.Val)
+			} else {
+				result.Attributes[attr.Key] = attr.Val
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// nodeText returns all the text under n, skipping scripts and styles
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	if n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style) {
+		return ""
+	}
+	var text strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		text.WriteString(nodeText(c))
+		if c.Type == html.ElementNode {
+			text.WriteString(" ")
+		}
+	}
+	return text.String()
+}
+
+// scrapeTags matches elements whose tag is in the comma-separated list, returning the
+// text directly inside each
+func scrapeTags(doc *html.Node, selector string) []ScrapeResult {
+	var results []ScrapeResult
 	var traverse func(n *html.Node)
 	traverse = func(n *html.Node) {
 		if n.Type == html.ElementNode {
@@ -149,9 +364,9 @@ func (w *WebWorker) scrape(ctx context.Context, input json.RawMessage) ([]byte,
 							text += c.Data
 						}
 					}
-					results = append(results, map[string]string{
-						"tag":  tag,
-						"text": strings.TrimSpace(text),
+					results = append(results, ScrapeResult{
+						Tag:  tag,
+						Text: strings.TrimSpace(text),
 					})
 					break
 				}
@@ -162,12 +377,7 @@ func (w *WebWorker) scrape(ctx context.Context, input json.RawMessage) ([]byte,
 		}
 	}
 	traverse(doc)
-
-	return json.Marshal(map[string]interface{}{
-		"url":     req.URL,
-		"results": results,
-		"count":   len(results),
-	})
+	return results
 }
 
 type ExtractLinksInput struct {
@@ -184,23 +394,20 @@ func (w *WebWorker) extractLinks(ctx context.Context, input json.RawMessage) ([]
 		return nil, fmt.Errorf("url is required")
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", req.URL, nil)
+	page, err := w.get(ctx, req.URL, nil)
 	if err != nil {
 		return nil, err
 	}
-	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (compatible; MCP-Bot/1.0)")
-
-	resp, err := w.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
+	if !page.isText() {
+		return page.skipped(req.URL)
 	}
-	defer resp.Body.Close()
 
-	doc, err := html.Parse(resp.Body)
+	doc, err := html.Parse(bytes.NewReader(page.body))
 	if err != nil {
 		return nil, err
 	}
 
+	base := documentBase(doc, page.resp.Request.URL)
 	var links []map[string]string
 	var findLinks func(n *html.Node)
 	findLinks = func(n *html.Node) {
@@ -220,21 +427,21 @@ func (w *WebWorker) extractLinks(ctx context.Context, input json.RawMessage) ([]
 			}
 
 			if href != "" {
+				resolved := resolveURL(base, href)
+				link := map[string]string{
+					"href":     resolved,
+					"raw_href": href,
+					"text":     strings.TrimSpace(text),
+				}
 				if len(req.FilterScheme) > 0 {
 					for _, scheme := range req.FilterScheme {
-						if strings.HasPrefix(href, scheme+":") {
-							links = append(links, map[string]string{
-								"href": href,
-								"text": strings.TrimSpace(text),
-							})
+						if strings.HasPrefix(resolved, scheme+":") {
+							links = append(links, link)
 							break
 						}
 					}
 				} else {
-					links = append(links, map[string]string{
-						"href": href,
-						"text": strings.TrimSpace(text),
-					})
+					links = append(links, link)
 				}
 			}
 		}
@@ -245,9 +452,10 @@ func (w *WebWorker) extractLinks(ctx context.Context, input json.RawMessage) ([]
 	findLinks(doc)
 
 	return json.Marshal(map[string]interface{}{
-		"url":   req.URL,
-		"links": links,
-		"count": len(links),
+		"url":       req.URL,
+		"links":     links,
+		"count":     len(links),
+		"truncated": page.truncated,
 	})
 }
 
@@ -264,23 +472,20 @@ func (w *WebWorker) extractImages(ctx context.Context, input json.RawMessage) ([
 		return nil, fmt.Errorf("url is required")
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", req.URL, nil)
+	page, err := w.get(ctx, req.URL, nil)
 	if err != nil {
 		return nil, err
 	}
-	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (compatible; MCP-Bot/1.0)")
-
-	resp, err := w.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
+	if !page.isText() {
+		return page.skipped(req.URL)
 	}
-	defer resp.Body.Close()
 
-	doc, err := html.Parse(resp.Body)
+	doc, err := html.Parse(bytes.NewReader(page.body))
 	if err != nil {
 		return nil, err
 	}
 
+	base := documentBase(doc, page.resp.Request.URL)
 	var images []map[string]string
 	var findImages func(n *html.Node)
 	findImages = func(n *html.Node) {
@@ -295,7 +500,7 @@ func (w *WebWorker) extractImages(ctx context.Context, input json.RawMessage) ([
 				}
 			}
 			if src != "" {
-				img := map[string]string{"src": src}
+				img := map[string]string{"src": resolveURL(base, src), "raw_src": src}
 				if req.Alt {
 					img["alt"] = alt
 				}
@@ -309,9 +514,10 @@ func (w *WebWorker) extractImages(ctx context.Context, input json.RawMessage) ([
 	findImages(doc)
 
 	return json.Marshal(map[string]interface{}{
-		"url":    req.URL,
-		"images": images,
-		"count":  len(images),
+		"url":       req.URL,
+		"images":    images,
+		"count":     len(images),
+		"truncated": page.truncated,
 	})
 }
 
@@ -332,24 +538,15 @@ func (w *WebWorker) search(ctx context.Context, input json.RawMessage) ([]byte,
 		return nil, fmt.Errorf("query is required")
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", req.URL, nil)
-	if err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (compatible; MCP-Bot/1.0)")
-
-	resp, err := w.httpClient.Do(httpReq)
+	page, err := w.get(ctx, req.URL, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if !page.isText() {
+		return page.skipped(req.URL)
 	}
 
-	content := string(body)
+	content := string(page.body)
 	if !req.CaseSensitive {
 		content = strings.ToLower(content)
 		req.Query = strings.ToLower(req.Query)
@@ -358,9 +555,10 @@ func (w *WebWorker) search(ctx context.Context, input json.RawMessage) ([]byte,
 	matches := strings.Count(content, req.Query)
 
 	return json.Marshal(map[string]interface{}{
-		"url":     req.URL,
-		"query":   req.Query,
-		"matches": matches,
+		"url":       req.URL,
+		"query":     req.Query,
+		"matches":   matches,
+		"truncated": page.truncated,
 	})
 }
 
@@ -376,19 +574,15 @@ func (w *WebWorker) extractMetadata(ctx context.Context, input json.RawMessage)
 		return nil, fmt.Errorf("url is required")
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", req.URL, nil)
+	page, err := w.get(ctx, req.URL, nil)
 	if err != nil {
 		return nil, err
 	}
-	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (compatible; MCP-Bot/1.0)")
-
-	resp, err := w.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, err
+	if !page.isText() {
+		return page.skipped(req.URL)
 	}
-	defer resp.Body.Close()
 
-	doc, err := html.Parse(resp.Body)
+	doc, err := html.Parse(bytes.NewReader(page.body))
 	if err != nil {
 		return nil, err
 	}
@@ -423,11 +617,44 @@ func (w *WebWorker) extractMetadata(ctx context.Context, input json.RawMessage)
 	}
 
 	metadata["url"] = req.URL
-	metadata["status"] = resp.Status
+	metadata["status"] = page.resp.Status
+	if page.truncated {
+		metadata["truncated"] = "true"
+	}
 
 	return json.Marshal(metadata)
 }
 
+var baseHrefSelector = cascadia.MustCompile("base[href]")
+
+// documentBase returns the URL relative references in doc resolve against: the href of
+// its first <base> element with one, or else the URL the page was fetched from
+func documentBase(doc *html.Node, pageURL *url.URL) *url.URL {
+	n := cascadia.Query(doc, baseHrefSelector)
+	if n == nil {
+		return pageURL
+	}
+	for _, attr := range n.Attr {
+		if attr.Key != "href" {
+			continue
+		}
+		if u, err := url.Parse(strings.TrimSpace(attr.Val)); err == nil {
+			return pageURL.ResolveReference(u)
+		}
+	}
+	return pageURL
+}
+
+// resolveURL makes ref absolute against the page it was found on, leaving it as is if
+// it can't be parsed
+func resolveURL(base *url.URL, ref string) string {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
 func extractTitle(n *html.Node) string {
 	if n.Type == html.ElementNode && n.DataAtom == atom.Title {
 		if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
@@ -0,0 +1,203 @@
+package workers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// webUserAgentToken is the product token robots.txt groups are matched against
+const webUserAgentToken = "mcp-bot"
+
+// robotsRules holds the rules of the robots.txt group that applies to us
+type robotsRules struct {
+	rules []robotsRule
+}
+
+type robotsRule struct {
+	allow   bool
+	length  int // length of the pattern, so the most specific rule wins
+	pattern *regexp.Regexp
+}
+
+type robotsEntry struct {
+	rules   *robotsRules
+	fetched time.Time
+}
+
+// allowed reports whether path (including any query) may be fetched. The longest
+// matching rule wins, and Allow wins a tie, as in RFC 9309.
+func (r *robotsRules) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	best := -1
+	allow := true
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > best || (rule.length == best && rule.allow) {
+			best = rule.length
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// parseRobots returns the rules of the group naming our user agent, or of the "*"
+// group if none does
+func parseRobots(body string) *robotsRules {
+	var ours, wildcard []robotsRule
+	var named bool // some group names us, even if it has no rules
+	var agents []string
+	var rules []robotsRule
+	inAgents := false
+
+	flush := func() {
+		for _, agent := range agents {
+			switch {
+			case agent == "*":
+				wildcard = append(wildcard, rules...)
+			case agent == webUserAgentToken:
+				named = true
+				ours = append(ours, rules...)
+			}
+		}
+		agents, rules = nil, nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				flush()
+			}
+			inAgents = true
+			agent, _, _ := strings.Cut(strings.ToLower(value), "/")
+			agents = append(agents, agent)
+		case "allow", "disallow":
+			inAgents = false
+			// An empty Disallow allows everything, which is the default anyway
+			if value == "" {
+				continue
+			}
+			rules = append(rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: robotsPattern(value),
+			})
+		default:
+			inAgents = false
+		}
+	}
+	flush()
+
+	if named {
+		return &robotsRules{rules: ours}
+	}
+	return &robotsRules{rules: wildcard}
+}
+
+// robotsPattern compiles a robots.txt path pattern, where * matches any run of
+// characters and a trailing $ anchors the end of the path
+func robotsPattern(p string) *regexp.Regexp {
+	anchored := strings.HasSuffix(p, "$")
+	p = strings.TrimSuffix(p, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(p), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// robotsFor returns the robots.txt rules for u's host, fetching them if the cached
+// copy is missing or older than the TTL
+func (w *WebWorker) robotsFor(ctx context.Context, u *url.URL) (*robotsRules, error) {
+	host := u.Scheme + "://" + u.Host
+
+	w.mu.Lock()
+	entry, ok := w.robots[host]
+	w.mu.Unlock()
+	if ok && time.Since(entry.fetched) < w.RobotsTTL {
+		return entry.rules, nil
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", host+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("User-Agent", webUserAgent)
+
+	resp, err := w.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var rules *robotsRules
+	switch {
+	case resp.StatusCode >= 500:
+		// The site can't say what's allowed, so assume nothing is
+		rules = &robotsRules{rules: []robotsRule{{allow: false, pattern: robotsPattern("/")}}}
+	case resp.StatusCode >= 400:
+		// No robots.txt means no restrictions
+		rules = nil
+	default:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 500*1024))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read robots.txt: %w", err)
+		}
+		rules = parseRobots(string(body))
+	}
+
+	w.mu.Lock()
+	w.robots[host] = robotsEntry{rules: rules, fetched: time.Now()}
+	w.mu.Unlock()
+	return rules, nil
+}
+
+// waitForHost blocks until HostDelay has passed since the last fetch from host,
+// reserving the next slot so concurrent fetches queue up behind each other
+func (w *WebWorker) waitForHost(ctx context.Context, host string) error {
+	if w.HostDelay <= 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	now := time.Now()
+	slot := now
+	if last, ok := w.lastFetch[host]; ok && last.Add(w.HostDelay).After(now) {
+		slot = last.Add(w.HostDelay)
+	}
+	w.lastFetch[host] = slot
+	w.mu.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,327 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebFetch_RespectsRobots(t *testing.T) {
+	var robotsFetches, pageFetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			robotsFetches.Add(1)
+			w.Write([]byte("User-agent: *\nDisallow: /\n\nUser-agent: MCP-Bot\nDisallow: /private\nAllow: /private/ok\n"))
+		default:
+			pageFetches.Add(1)
+			w.Write([]byte("<html><body>hello</body></html>"))
+		}
+	}))
+	defer server.Close()
+
+	w := NewWebWorker()
+	w.HostDelay = 0
+	ctx := context.Background()
+
+	out, err := w.Execute(ctx, "web_fetch", json.RawMessage(`{"url":"`+server.URL+`/private/data","respect_robots":true}`))
+	require.NoError(t, err)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, false, resp["allowed"])
+	assert.Equal(t, "disallowed by robots.txt", resp["error"])
+	assert.Equal(t, int32(0), pageFetches.Load())
+
+	for _, path := range []string{"/public", "/private/ok"} {
+		out, err = w.Execute(ctx, "web_fetch", json.RawMessage(`{"url":"`+server.URL+path+`","respect_robots":true}`))
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "hello", path)
+	}
+	assert.Equal(t, int32(2), pageFetches.Load())
+	assert.Equal(t, int32(1), robotsFetches.Load(), "robots.txt should be cached")
+
+	// Without respect_robots the rules aren't consulted
+	out, err = w.Execute(ctx, "web_fetch", json.RawMessage(`{"url":"`+server.URL+`/private/data"}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "hello")
+}
+
+func TestParseRobots(t *testing.T) {
+	rules := parseRobots(`
+# comment
+User-agent: googlebot
+User-agent: mcp-bot/1.0
+Disallow: /*.pdf$
+Disallow: /tmp
+Allow: /tmp/public
+
+User-agent: *
+Disallow: /
+`)
+	assert.False(t, rules.allowed("/docs/report.pdf"))
+	assert.True(t, rules.allowed("/docs/report.pdf?download=1"))
+	assert.False(t, rules.allowed("/tmp/x"))
+	assert.True(t, rules.allowed("/tmp/public/x"))
+	assert.True(t, rules.allowed("/index.html"))
+
+	// Our group replaces "*" entirely rather than adding to it
+	assert.True(t, parseRobots("User-agent: MCP-Bot\nAllow: /x\n\nUser-agent: *\nDisallow: /\n").allowed("/y"))
+	assert.False(t, parseRobots("User-agent: *\nDisallow: /\n").allowed("/x"))
+}
+
+func TestWebFetch_DelaysBetweenFetchesToOneHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	w := NewWebWorker()
+	w.HostDelay = 100 * time.Millisecond
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := w.Execute(context.Background(), "fetch", json.RawMessage(`{"url":"`+server.URL+`"}`))
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestWebExtractLinksAndImages_ResolvesRelativeURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>
+			<a href="/about">About</a>
+			<a href="next.html">Next</a>
+			<a href="https://example.com/x?y=1">Elsewhere</a>
+			<a href="//cdn.example.com/lib.js">CDN</a>
+			<a href="mailto:team@example.com">Mail</a>
+			<img src="../img.png" alt="logo">
+			<img src="//cdn.example.com/pic.jpg">
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	w := NewWebWorker()
+	ctx := context.Background()
+
+	out, err := w.Execute(ctx, "web_extract_links", json.RawMessage(`{"url":"`+server.URL+`/docs/guide/index.html"}`))
+	require.NoError(t, err)
+	var linksResp struct {
+		Links []map[string]string `json:"links"`
+	}
+	require.NoError(t, json.Unmarshal(out, &linksResp))
+	var hrefs, raw []string
+	for _, link := range linksResp.Links {
+		hrefs = append(hrefs, link["href"])
+		raw = append(raw, link["raw_href"])
+	}
+	assert.Equal(t, []string{
+		server.URL + "/about",
+		server.URL + "/docs/guide/next.html",
+		"https://example.com/x?y=1",
+		"http://cdn.example.com/lib.js",
+		"mailto:team@example.com",
+	}, hrefs)
+	assert.Equal(t, []string{"/about", "next.html", "https://example.com/x?y=1", "//cdn.example.com/lib.js", "mailto:team@example.com"}, raw)
+
+	// Scheme filters apply to the resolved URL
+	out, err = w.Execute(ctx, "web_extract_links", json.RawMessage(`{"url":"`+server.URL+`/docs/guide/index.html","filter_scheme":["https"]}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"count":1`)
+
+	out, err = w.Execute(ctx, "web_extract_images", json.RawMessage(`{"url":"`+server.URL+`/docs/guide/index.html"}`))
+	require.NoError(t, err)
+	var imagesResp struct {
+		Images []map[string]string `json:"images"`
+	}
+	require.NoError(t, json.Unmarshal(out, &imagesResp))
+	require.Len(t, imagesResp.Images, 2)
+	assert.Equal(t, server.URL+"/docs/img.png", imagesResp.Images[0]["src"])
+	assert.Equal(t, "../img.png", imagesResp.Images[0]["raw_src"])
+	assert.Equal(t, "http://cdn.example.com/pic.jpg", imagesResp.Images[1]["src"])
+}
+
+func TestWebExtract_HonorsBaseHref(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head>
+			<base target="_blank">
+			<base href="/static/v2/">
+		</head><body>
+			<a href="next.html">Next</a>
+			<img src="logo.png">
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	w := NewWebWorker()
+	w.HostDelay = 0
+	ctx := context.Background()
+	page := `{"url":"` + server.URL + `/docs/index.html"}`
+
+	out, err := w.Execute(ctx, "web_extract_links", json.RawMessage(page))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"href":"`+server.URL+`/static/v2/next.html"`)
+
+	out, err = w.Execute(ctx, "web_extract_images", json.RawMessage(page))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"src":"`+server.URL+`/static/v2/logo.png"`)
+
+	out, err = w.Execute(ctx, "web_scrape", json.RawMessage(`{"url":"`+server.URL+`/docs/index.html","selector":"a"}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"href":"`+server.URL+`/static/v2/next.html"`)
+}
+
+func TestWebScrape_CSSSelector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>
+			<div id="main">
+				<a class="external link" href="https://example.com">Example <b>site</b></a>
+				<a href="/local">Local</a>
+				<p>Intro</p>
+			</div>
+			<a class="external" href="//cdn.example.com/docs" title="Docs">Docs</a>
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	w := NewWebWorker()
+	scrape := func(selector string) (string, []ScrapeResult) {
+		input, _ := json.Marshal(map[string]string{"url": server.URL, "selector": selector})
+		out, err := w.Execute(context.Background(), "web_scrape", input)
+		require.NoError(t, err)
+		var resp struct {
+			Mode    string         `json:"mode"`
+			Results []ScrapeResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(out, &resp))
+		return resp.Mode, resp.Results
+	}
+
+	mode, results := scrape("a.external")
+	assert.Equal(t, "css", mode)
+	assert.Equal(t, []ScrapeResult{
+		{Tag: "a", Text: "Example site", Attributes: map[string]string{"class": "external link", "href": "https://example.com"}},
+		{Tag: "a", Text: "Docs", Attributes: map[string]string{"class": "external", "href": "http://cdn.example.com/docs", "title": "Docs"}},
+	}, results)
+
+	_, results = scrape("#main > p")
+	require.Len(t, results, 1)
+	assert.Equal(t, "Intro", results[0].Text)
+
+	// Selectors that aren't valid CSS fall back to matching tag names
+	mode, results = scrape("p,,b")
+	assert.Equal(t, "tags", mode)
+	require.Len(t, results, 2)
+	assert.Equal(t, "b", results[0].Tag)
+	assert.Equal(t, "p", results[1].Tag)
+}
+
+func TestWebFetch_TruncatesOversizedBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("a", 4096)))
+	}))
+	defer server.Close()
+
+	w := NewWebWorker()
+	w.HostDelay = 0
+	w.MaxResponseBytes = 1000
+	out, err := w.Execute(context.Background(), "fetch", json.RawMessage(`{"url":"`+server.URL+`"}`))
+	require.NoError(t, err)
+	var resp struct {
+		Content   string `json:"content"`
+		Size      int    `json:"size"`
+		Truncated bool   `json:"truncated"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.True(t, resp.Truncated)
+	assert.Equal(t, 1000, resp.Size)
+	assert.Len(t, resp.Content, 1000)
+
+	// A body of exactly the limit isn't truncated
+	w.MaxResponseBytes = 4096
+	out, err = w.Execute(context.Background(), "fetch", json.RawMessage(`{"url":"`+server.URL+`"}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.False(t, resp.Truncated)
+}
+
+func TestWebFetch_DoesNotDownloadDeclaredBinary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Length", "1048576")
+		w.Write(make([]byte, 64))
+		w.(http.Flusher).Flush()
+		// Stall mid-body; a client that reads on would wait out the timeout
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	w := NewWebWorker()
+	w.HostDelay = 0
+	start := time.Now()
+	out, err := w.Execute(context.Background(), "fetch", json.RawMessage(`{"url":"`+server.URL+`"}`))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "skipped non-text content of type video/mp4", resp["note"])
+	assert.Equal(t, float64(1048576), resp["size"])
+}
+
+func TestWebFetch_SniffsMissingContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Suppress the server's own sniffing so the header is really missing
+		w.Header()["Content-Type"] = nil
+		if r.URL.Path == "/image" {
+			w.Write([]byte("\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 1024)))
+			return
+		}
+		w.Write([]byte("<html><body>" + strings.Repeat("hello ", 200) + "</body></html>"))
+	}))
+	defer server.Close()
+
+	w := NewWebWorker()
+	w.HostDelay = 0
+	out, err := w.Execute(context.Background(), "fetch", json.RawMessage(`{"url":"`+server.URL+`/page"}`))
+	require.NoError(t, err)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "<html><body>"+strings.Repeat("hello ", 200)+"</body></html>", resp["content"])
+
+	out, err = w.Execute(context.Background(), "fetch", json.RawMessage(`{"url":"`+server.URL+`/image"}`))
+	require.NoError(t, err)
+	resp = nil
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "skipped non-text content of type image/png", resp["note"])
+	assert.NotContains(t, resp, "content")
+}
+
+func TestWebWorker_SkipsNonTextContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0x00, 0x01, 0x02, '<', 'a', '>'})
+	}))
+	defer server.Close()
+
+	w := NewWebWorker()
+	w.HostDelay = 0
+	for _, tool := range []string{"fetch", "scrape", "extract_links", "extract_images", "extract_metadata", "search"} {
+		out, err := w.Execute(context.Background(), tool, json.RawMessage(`{"url":"`+server.URL+`","query":"a"}`))
+		require.NoError(t, err, tool)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(out, &resp), tool)
+		assert.Equal(t, "skipped non-text content of type application/octet-stream", resp["note"], tool)
+		assert.NotContains(t, resp, "content", tool)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"unicode/utf8"
 )
 
 type ToolDef struct {
@@ -134,3 +135,15 @@ func (w *FileIOWorker) resolvePath(path string) string {
 	}
 	return filepath.Join(w.basePath, path)
 }
+
+// truncateUTF8 returns at most n bytes of s, cutting before a multi-byte character
+// rather than through it
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
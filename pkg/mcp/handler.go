@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/ericksa/mymcp/internal/audit"
@@ -21,6 +22,7 @@ type Worker interface {
 type Handler struct {
 	config  *config.Config
 	audit   *audit.Auditor
+	mu      sync.RWMutex // guards workers, which RegisterWorker can change while serving
 	workers map[string]Worker
 	server  *mcp.Server
 }
@@ -68,26 +70,84 @@ func NewHandler(cfg *config.Config) *Handler {
 		h.workers["dataset"] = workers.NewDatasetWorker(cfg.MCP.Workers.Dataset.BasePath)
 	}
 
-	// RAG worker
+	// Project worker, with per-type settings such as lint commands from frameworks
+	if cfg.MCP.Workers.Project.Enabled {
+		projectWorker := workers.NewProjectWorker(cfg.MCP.Workers.BasePath, "")
+		projectWorker.SetFrameworks(cfg.MCP.Workers.Project.Frameworks)
+		h.workers["project"] = projectWorker
+	}
+
+	// RAG worker, embedding through LM Studio into the vector database when both are
+	// enabled and falling back to keyword search otherwise
 	if cfg.MCP.Workers.RAG.Enabled {
-		ragWorker := workers.NewRAGWorkerState(workers.RAGConfig{
+		ragConfig := workers.RAGConfig{
 			ChunkSize:    cfg.MCP.Workers.RAG.ChunkSize,
 			ChunkOverlap: cfg.MCP.Workers.RAG.ChunkOverlap,
-			Collection:   "rag",
-		})
+			Collection:   cfg.MCP.Workers.RAG.Collection,
+		}
+		if ragConfig.Collection == "" {
+			ragConfig.Collection = "rag"
+		}
+		ragWorker := workers.NewRAGWorkerState(ragConfig)
+		if cfg.MCP.Workers.Vector.Enabled && cfg.MCP.Workers.LMStudio.Enabled {
+			vectorWorker, err := workers.NewRAGWorkerWithBackends(ragConfig, workers.VectorStoreConfig{
+				Backend:        cfg.MCP.Workers.Vector.Backend,
+				Endpoint:       cfg.MCP.Workers.Vector.Endpoint,
+				DistanceMetric: cfg.MCP.Workers.Vector.DistanceMetric,
+			}, cfg.MCP.Workers.LMStudio.Endpoint, cfg.MCP.Workers.RAG.EmbedderModel)
+			if err != nil {
+				fmt.Printf("Warning: failed to initialize RAG vector search, using keyword search: %v\n", err)
+			} else {
+				ragWorker = vectorWorker
+				// Embed through the LM Studio worker so the embedding model loads on demand
+				if lmstudioWorker, ok := h.workers["lmstudio"].(*workers.LMStudioWorker); ok {
+					ragWorker.SetEmbedder(workers.NewLMStudioEmbedder(lmstudioWorker, cfg.MCP.Workers.RAG.EmbedderModel))
+				}
+			}
+		}
+		// rag_ask answers with LM Studio when it is enabled
+		if lmstudioWorker, ok := h.workers["lmstudio"].(*workers.LMStudioWorker); ok {
+			ragWorker.SetLLMCaller(workers.NewLMStudioCaller(lmstudioWorker, cfg.MCP.Workers.RAG.LLMModel))
+		}
 		h.workers["rag"] = ragWorker
 	}
 
 	// Contract worker (always enabled)
 	contractWorker := workers.NewContractWorkerState()
+	contractWorker.DayFirstDates = cfg.MCP.Workers.Contract.DayFirstDates
+	contractWorker.MaxFileBytes = cfg.MCP.Workers.Contract.MaxFileBytes
 	// Connect to RAG if available
 	if ragWorker, ok := h.workers["rag"].(*workers.RAGWorkerState); ok {
 		contractWorker.SetRAGWorker(ragWorker)
 	}
+	// Persist parsed contracts if a storage path is configured
+	if path := cfg.MCP.Workers.Contract.StoragePath; path != "" {
+		store, err := workers.NewFileContractStore(path)
+		if err != nil {
+			fmt.Printf("Warning: failed to initialize contract store: %v\n", err)
+		} else {
+			contractWorker.SetStore(store)
+		}
+	}
 	h.workers["contract"] = contractWorker
 
-	// Orchestrator worker
-	h.workers["orchestrator"] = workers.NewOrchestratorWorkerState(10, 120*time.Second)
+	// Orchestrator worker, persisting agents, runs, and workflows if a storage path is configured
+	var orchestratorStore workers.OrchestratorStore
+	if path := cfg.MCP.Workers.Orchestrator.StoragePath; path != "" {
+		store, err := workers.NewFileOrchestratorStore(path)
+		if err != nil {
+			fmt.Printf("Warning: failed to initialize orchestrator store: %v\n", err)
+		} else {
+			orchestratorStore = store
+		}
+	}
+	orchestratorWorker := workers.NewOrchestratorWorkerState(10, 120*time.Second, orchestratorStore)
+	orchestratorWorker.StrictTools = cfg.MCP.Workers.Orchestrator.StrictTools
+	// Agents registered with provider "lmstudio" run against the LM Studio server
+	if lmstudioWorker, ok := h.workers["lmstudio"].(*workers.LMStudioWorker); ok {
+		orchestratorWorker.SetProvider("lmstudio", workers.NewLMStudioProvider(lmstudioWorker))
+	}
+	h.workers["orchestrator"] = orchestratorWorker
 
 	// Email parser worker for local mail access
 	h.workers["email_parser"] = workers.NewEmailParserWorker(cfg.MCP.Workers.EmailParser.MaildirPath)
@@ -100,6 +160,9 @@ func NewHandler(cfg *config.Config) *Handler {
 			SecretKey: cfg.MCP.Workers.MinIO.SecretKey,
 			Bucket:    cfg.MCP.Workers.MinIO.DefaultBucket,
 			UseSSL:    cfg.MCP.Workers.MinIO.UseSSL,
+			Region:    cfg.MCP.Workers.MinIO.Region,
+
+			AllowedBuckets: cfg.MCP.Workers.MinIO.AllowedBuckets,
 		})
 		if err != nil {
 			fmt.Printf("Warning: failed to initialize MinIO worker: %v\n", err)
@@ -115,6 +178,7 @@ func NewHandler(cfg *config.Config) *Handler {
 			// Log error but don't fail - task worker is optional
 			fmt.Printf("Warning: failed to initialize task worker: %v\n", err)
 		} else {
+			taskWorker.EnforceTransitions = cfg.MCP.Workers.Task.EnforceTransitions
 			h.workers["task"] = taskWorker
 		}
 	}
@@ -135,6 +199,15 @@ func NewHandler(cfg *config.Config) *Handler {
 		}
 	}
 
+	// Agents may name tools by their short or fully prefixed name
+	var toolNames []string
+	for name, tools := range h.ListTools() {
+		for _, tool := range tools {
+			toolNames = append(toolNames, tool.Name, name+"_"+tool.Name)
+		}
+	}
+	orchestratorWorker.SetKnownTools(toolNames)
+
 	h.initMCPServer()
 	return h
 }
@@ -189,13 +262,50 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.server.Run(r.Context(), &mcp.StdioTransport{})
 }
 
+// RegisterWorker adds or replaces the worker serving tools prefixed with name
+func (h *Handler) RegisterWorker(name string, w Worker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.workers[name] = w
+}
+
+// Worker returns the worker registered under name
+func (h *Handler) Worker(name string) (Worker, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	w, ok := h.workers[name]
+	return w, ok
+}
+
+// ListTools returns the tools exposed by each registered worker, keyed by worker name
+func (h *Handler) ListTools() map[string][]workers.ToolDef {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	tools := make(map[string][]workers.ToolDef, len(h.workers))
+	for name, worker := range h.workers {
+		tools[name] = worker.GetTools()
+	}
+	return tools
+}
+
 func (h *Handler) ExecuteTool(ctx context.Context, toolName string, args json.RawMessage) ([]byte, error) {
+	worker, shortName := h.route(toolName)
+	if worker == nil {
+		return nil, fmt.Errorf("tool not found: %s", toolName)
+	}
+	return worker.Execute(ctx, shortName, args)
+}
+
+// route finds the worker whose name prefixes toolName, releasing the lock before
+// the tool runs
+func (h *Handler) route(toolName string) (Worker, string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 	for name, worker := range h.workers {
 		fullPrefix := name + "_"
 		if len(toolName) > len(fullPrefix) && toolName[:len(fullPrefix)] == fullPrefix {
-			shortName := toolName[len(fullPrefix):]
-			return worker.Execute(ctx, shortName, args)
+			return worker, toolName[len(fullPrefix):]
 		}
 	}
-	return nil, fmt.Errorf("tool not found: %s", toolName)
+	return nil, ""
 }
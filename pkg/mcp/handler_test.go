@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ericksa/mymcp/internal/config"
+	"github.com/ericksa/mymcp/internal/workers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandler_RAGAsksLMStudio(t *testing.T) {
+	var received workers.LMStudioChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "Paris"}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.MCP.Workers.BasePath = t.TempDir()
+	cfg.MCP.Workers.LMStudio.Enabled = true
+	cfg.MCP.Workers.LMStudio.Endpoint = server.URL
+	cfg.MCP.Workers.RAG.Enabled = true
+	cfg.MCP.Workers.RAG.LLMModel = "qwen2.5-7b-instruct"
+	h := NewHandler(cfg)
+
+	_, err := h.ExecuteTool(context.Background(), "rag_rag_ingest", json.RawMessage(`{"title": "France", "content": "The capital of France is Paris."}`))
+	require.NoError(t, err)
+	out, err := h.ExecuteTool(context.Background(), "rag_rag_ask", json.RawMessage(`{"query": "capital of France"}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Answer string `json:"answer"`
+	}
+	require.NoError(t, json.Unmarshal(out, &resp))
+	assert.Equal(t, "Paris", resp.Answer)
+	assert.Equal(t, "qwen2.5-7b-instruct", received.Model)
+}
+
+func TestNewHandler_ProjectFrameworksFromConfig(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(base, "package.json"), []byte("{}"), 0644))
+
+	cfg := &config.Config{}
+	cfg.MCP.Workers.BasePath = base
+	cfg.MCP.Workers.Project.Enabled = true
+	cfg.MCP.Workers.Project.Frameworks = map[string]interface{}{
+		"node": map[string]interface{}{"lint": "definitely-not-a-linter ."},
+	}
+	h := NewHandler(cfg)
+
+	out, err := h.ExecuteTool(context.Background(), "project_lint", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "linter not installed: definitely-not-a-linter")
+}
+
+// echoWorker returns the name of the tool it was asked to run
+type echoWorker struct{}
+
+func (echoWorker) GetTools() []workers.ToolDef { return []workers.ToolDef{{Name: "run"}} }
+
+func (echoWorker) Execute(ctx context.Context, name string, input json.RawMessage) ([]byte, error) {
+	return []byte(name), nil
+}
+
+func TestRegisterWorker_SafeWhileServing(t *testing.T) {
+	h := NewHandler(&config.Config{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			h.RegisterWorker(fmt.Sprintf("echo%d", i), echoWorker{})
+		}(i)
+		go func() {
+			defer wg.Done()
+			h.ExecuteTool(context.Background(), "echo0_run", nil)
+			h.ListTools()
+		}()
+	}
+	wg.Wait()
+
+	out, err := h.ExecuteTool(context.Background(), "echo7_run", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "run", string(out))
+}